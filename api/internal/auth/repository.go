@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mimsy-cms/mimsy/internal/config"
 )
 
@@ -17,6 +18,16 @@ type User struct {
 	UpdatedAt          time.Time `json:"updated_at"`
 }
 
+// PublicUser is the part of a user that can be read by anyone, such as the author of a published resource.
+type PublicUser struct {
+	ID int64 `json:"id"`
+}
+
+// Public returns the public part of the user.
+func (u *User) Public() PublicUser {
+	return PublicUser{ID: u.ID}
+}
+
 type Repository interface {
 	CountUsers(ctx context.Context) (int, error)
 	InsertUser(ctx context.Context, email, password string, isAdmin, mustChange bool) error
@@ -30,6 +41,7 @@ type Repository interface {
 	GetUserBySessionToken(ctx context.Context, token string) (*User, error)
 	GetUsers(ctx context.Context) ([]User, error)
 	FindUserById(ctx context.Context, id int64) (*User, error)
+	FindUsersByIds(ctx context.Context, ids []int64) ([]User, error)
 }
 
 type repository struct{}
@@ -139,3 +151,23 @@ func (r *repository) FindUserById(ctx context.Context, id int64) (*User, error)
 		Scan(&u.ID, &u.Email, &u.PasswordHash, &u.IsAdmin, &u.MustChangePassword, &u.CreatedAt, &u.UpdatedAt)
 	return &u, err
 }
+
+// FindUsersByIds returns the users with the given identifiers, the missing ones are omitted.
+func (r *repository) FindUsersByIds(ctx context.Context, ids []int64) ([]User, error) {
+	rows, err := config.GetDB(ctx).QueryContext(ctx, `SELECT id, email, password, is_admin, must_change_password, created_at, updated_at FROM "user" WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.IsAdmin, &u.MustChangePassword, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
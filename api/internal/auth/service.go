@@ -32,6 +32,7 @@ type Service interface {
 	GetUserBySessionToken(ctx context.Context, sessionToken string) (*User, error)
 	GetUsers(ctx context.Context) ([]User, error)
 	FindUserById(ctx context.Context, id int64) (*User, error)
+	FindUsersByIds(ctx context.Context, ids []int64) ([]User, error)
 }
 
 func (s *service) GetUserBySessionToken(ctx context.Context, sessionToken string) (*User, error) {
@@ -173,3 +174,7 @@ func (s *service) GetUsers(ctx context.Context) ([]User, error) {
 func (s *service) FindUserById(ctx context.Context, id int64) (*User, error) {
 	return s.authRepository.FindUserById(ctx, id)
 }
+
+func (s *service) FindUsersByIds(ctx context.Context, ids []int64) ([]User, error) {
+	return s.authRepository.FindUsersByIds(ctx, ids)
+}
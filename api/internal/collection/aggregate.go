@@ -0,0 +1,379 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// DateTruncUnits are the precisions to which dates can be truncated to group resources.
+var DateTruncUnits = []string{"hour", "day", "week", "month", "quarter", "year"}
+
+// DateTrunc groups resources by a date field truncated to a unit, such as the month they were created in.
+type DateTrunc struct {
+	Field string
+	Unit  string
+}
+
+// AggregateParams are the parameters used to aggregate the resources of a collection.
+type AggregateParams struct {
+	// Count adds the number of resources of each group.
+	Count bool
+	// GroupBy are the fields whose values split the resources in groups.
+	GroupBy []string
+	// DateTrunc are the date fields whose truncated values split the resources in groups.
+	DateTrunc []DateTrunc
+	// Sum, Avg, Min and Max are the fields aggregated within each group.
+	Sum []string
+	Avg []string
+	Min []string
+	Max []string
+
+	Filters []Filter
+	// PublishedOnly excludes the draft resources.
+	PublishedOnly bool
+	// Limit is the maximum number of groups to return, 0 means no limit.
+	Limit  uint64
+	Offset uint64
+}
+
+// AggregateGroup is a group of resources with the values aggregated over them.
+// Without grouping, every resource is in a single group.
+type AggregateGroup struct {
+	// Group are the values of the grouping fields shared by the resources of the group.
+	Group map[string]any `json:"group"`
+	Count *int64         `json:"count,omitempty"`
+	Sum   map[string]any `json:"sum,omitempty"`
+	Avg   map[string]any `json:"avg,omitempty"`
+	Min   map[string]any `json:"min,omitempty"`
+	Max   map[string]any `json:"max,omitempty"`
+}
+
+// ParseAggregateParams parses the query string of a request aggregating resources.
+//
+// The aggregates are requested with `count=true` and with the comma separated fields of `sum`, `avg`,
+// `min` and `max`. Resources are grouped by the comma separated fields of `groupBy`, and by the date
+// fields of `dateTrunc` truncated to a unit (`dateTrunc=created_at:month`). Grouping by a multi_relation
+// field puts a resource in the group of each resource it relates to, so that `groupBy=tags` counts the
+// posts of each tag. The filters and the pagination of the groups are given as in ParseFindResourcesParams,
+// sorting is not supported as groups are ordered by their values.
+//
+// The fields are only checked against the collection definition when the query is built.
+func ParseAggregateParams(values url.Values) (*AggregateParams, error) {
+	findParams, err := ParseFindResourcesParams(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(findParams.Sort) > 0 {
+		return nil, fmt.Errorf("%w: aggregates cannot be sorted", ErrInvalidQuery)
+	}
+
+	params := &AggregateParams{
+		Filters: findParams.Filters,
+		Limit:   findParams.Limit,
+		Offset:  findParams.Offset,
+		GroupBy: splitFieldList(values.Get("groupBy")),
+		Sum:     splitFieldList(values.Get("sum")),
+		Avg:     splitFieldList(values.Get("avg")),
+		Min:     splitFieldList(values.Get("min")),
+		Max:     splitFieldList(values.Get("max")),
+	}
+
+	if count := values.Get("count"); count != "" {
+		if params.Count, err = strconv.ParseBool(count); err != nil {
+			return nil, fmt.Errorf("%w: count must be a boolean", ErrInvalidQuery)
+		}
+	}
+
+	for _, trunc := range splitFieldList(values.Get("dateTrunc")) {
+		field, unit, ok := strings.Cut(trunc, ":")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("%w: dateTrunc must be given as field:unit", ErrInvalidQuery)
+		}
+		if !slices.Contains(DateTruncUnits, unit) {
+			return nil, fmt.Errorf("%w: dateTrunc unit must be one of %s", ErrInvalidQuery, strings.Join(DateTruncUnits, ", "))
+		}
+		params.DateTrunc = append(params.DateTrunc, DateTrunc{Field: field, Unit: unit})
+	}
+
+	if !params.Count && len(params.Sum)+len(params.Avg)+len(params.Min)+len(params.Max) == 0 {
+		return nil, fmt.Errorf("%w: at least one of count, sum, avg, min or max is required", ErrInvalidQuery)
+	}
+
+	return params, nil
+}
+
+// splitFieldList splits a comma separated list of fields, ignoring the blank ones.
+func splitFieldList(value string) []string {
+	var fields []string
+	for field := range strings.SplitSeq(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Field kinds supported by each aggregation, dates are grouped with dateTrunc.
+// Multi relations are grouped by through their join table.
+var (
+	groupableKinds   = []fieldKind{kindText, kindNumber, kindBoolean, kindReference}
+	summableKinds    = []fieldKind{kindNumber}
+	comparableKinds  = []fieldKind{kindNumber, kindDate}
+	truncatableKinds = []fieldKind{kindDate}
+)
+
+// aggregateColumn is a column selected by an aggregate query, and where its value goes in a group.
+type aggregateColumn struct {
+	expression string
+	// target is the map of the group holding the value, nil for the count.
+	target func(group *AggregateGroup) map[string]any
+	key    string
+	codec  Codec
+}
+
+// Aggregate returns the groups of the resources matching the filters of the params.
+func (q *selectQuery) Aggregate(ctx context.Context, params *AggregateParams) ([]AggregateGroup, error) {
+	query, args, columns, err := q.buildAggregateQuery(q.tableName, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build aggregate SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute aggregate query: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []AggregateGroup{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		valuesPtrs := make([]any, len(columns))
+		for i := range values {
+			valuesPtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuesPtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate row: %w", err)
+		}
+
+		group := AggregateGroup{Group: map[string]any{}}
+		for i, column := range columns {
+			if column.target == nil {
+				count := values[i].(int64)
+				group.Count = &count
+				continue
+			}
+
+			value, err := column.codec.Decode(values[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode aggregate %q: %w", column.key, err)
+			}
+			column.target(&group)[column.key] = value
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over aggregate rows: %w", err)
+	}
+
+	return groups, nil
+}
+
+// buildAggregateQuery returns the query aggregating the resources, checking the fields against the collection
+// fields and the kinds each aggregation supports. The returned columns describe the selected values, in order.
+func (q *selectQuery) buildAggregateQuery(tableName string, params *AggregateParams) (string, []any, []aggregateColumn, error) {
+	var groupColumns, metricColumns []aggregateColumn
+	groupKeys := map[string]bool{}
+
+	addGroup := func(name, expression string, codec Codec) error {
+		if groupKeys[name] {
+			return fmt.Errorf("%w: resources are already grouped by %q", ErrInvalidQuery, name)
+		}
+		groupKeys[name] = true
+		groupColumns = append(groupColumns, aggregateColumn{
+			expression: expression,
+			target:     func(group *AggregateGroup) map[string]any { return group.Group },
+			key:        name,
+			codec:      codec,
+		})
+		return nil
+	}
+
+	var joins []string
+	for _, name := range params.GroupBy {
+		// A resource is counted in the group of each resource it relates to, through the join table of the relation.
+		// Resources without related resources are in the null group, as for a relation.
+		if element, ok := q.fields[name]; ok && element.Type == "multi_relation" {
+			relation, err := NewRelation(tableName, name, element)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("%w: field %q cannot be grouped by: %v", ErrInvalidQuery, name, err)
+			}
+			joinTable := pq.QuoteIdentifier(relation.JoinTable)
+			joins = append(joins, fmt.Sprintf("%s ON %s.%s = %s.\"id\"",
+				joinTable, joinTable, pq.QuoteIdentifier(relation.OwnerColumn), pq.QuoteIdentifier(tableName)))
+
+			if err := addGroup(name, joinTable+"."+pq.QuoteIdentifier(relation.TargetColumn), referenceCodec{}); err != nil {
+				return "", nil, nil, err
+			}
+			continue
+		}
+
+		field, err := q.lookupAggregateField(name, "grouped by", groupableKinds)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if err := addGroup(name, pq.QuoteIdentifier(field.column), kindCodec(field.kind)); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	for _, trunc := range params.DateTrunc {
+		field, err := q.lookupAggregateField(trunc.Field, "truncated", truncatableKinds)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if !slices.Contains(DateTruncUnits, trunc.Unit) {
+			return "", nil, nil, fmt.Errorf("%w: unknown dateTrunc unit %q", ErrInvalidQuery, trunc.Unit)
+		}
+		// The unit is one of DateTruncUnits, it is inlined so that the grouped and selected expressions are identical
+		expression := fmt.Sprintf("date_trunc('%s', %s)", trunc.Unit, pq.QuoteIdentifier(field.column))
+		if err := addGroup(trunc.Field, expression, timeCodec{}); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	if params.Count {
+		metricColumns = append(metricColumns, aggregateColumn{expression: "COUNT(*)", key: "count"})
+	}
+
+	metrics := []struct {
+		function string
+		names    []string
+		kinds    []fieldKind
+		target   func(group *AggregateGroup) map[string]any
+	}{
+		{"SUM", params.Sum, summableKinds, func(group *AggregateGroup) map[string]any { return initMap(&group.Sum) }},
+		{"AVG", params.Avg, summableKinds, func(group *AggregateGroup) map[string]any { return initMap(&group.Avg) }},
+		{"MIN", params.Min, comparableKinds, func(group *AggregateGroup) map[string]any { return initMap(&group.Min) }},
+		{"MAX", params.Max, comparableKinds, func(group *AggregateGroup) map[string]any { return initMap(&group.Max) }},
+	}
+	for _, metric := range metrics {
+		for _, name := range metric.names {
+			field, err := q.lookupAggregateField(name, "aggregated with "+strings.ToLower(metric.function), metric.kinds)
+			if err != nil {
+				return "", nil, nil, err
+			}
+
+			// Sums and averages of integers are numeric, the other aggregates keep the type of the column
+			codec := kindCodec(field.kind)
+			if metric.function == "SUM" || metric.function == "AVG" {
+				codec = numberCodec{}
+			}
+
+			metricColumns = append(metricColumns, aggregateColumn{
+				expression: fmt.Sprintf("%s(%s)", metric.function, pq.QuoteIdentifier(field.column)),
+				target:     metric.target,
+				key:        name,
+				codec:      codec,
+			})
+		}
+	}
+
+	columns := append(groupColumns, metricColumns...)
+	expressions := make([]string, len(columns))
+	for i, column := range columns {
+		expressions[i] = column.expression
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	b := psql.Select(expressions...).From(pq.QuoteIdentifier(tableName))
+	for _, join := range joins {
+		b = b.LeftJoin(join)
+	}
+
+	b, err := q.applyFilters(b, &FindResourcesParams{Filters: params.Filters, PublishedOnly: params.PublishedOnly})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	for _, column := range groupColumns {
+		b = b.GroupBy(column.expression).OrderBy(column.expression + " ASC")
+	}
+
+	if params.Limit > 0 {
+		b = b.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		b = b.Offset(params.Offset)
+	}
+
+	query, args, err := b.ToSql()
+	return query, args, columns, err
+}
+
+// lookupAggregateField returns a field of the collection, checking that its kind supports an aggregation.
+func (q *selectQuery) lookupAggregateField(name string, operation string, kinds []fieldKind) (queryableField, error) {
+	field, err := lookupQueryableField(q.fields, name)
+	if err != nil {
+		return queryableField{}, err
+	}
+	if !slices.Contains(kinds, field.kind) {
+		return queryableField{}, fmt.Errorf("%w: field %q cannot be %s", ErrInvalidQuery, name, operation)
+	}
+	return field, nil
+}
+
+// kindCodec returns the codec decoding the values of the columns of a kind.
+func kindCodec(kind fieldKind) Codec {
+	switch kind {
+	case kindText:
+		return textCodec{}
+	case kindNumber:
+		return numberCodec{}
+	case kindDate:
+		return timeCodec{}
+	case kindBoolean:
+		return booleanCodec{}
+	case kindReference:
+		return referenceCodec{}
+	default:
+		return rawCodec{}
+	}
+}
+
+// initMap returns the map, creating it when it is nil.
+func initMap(m *map[string]any) map[string]any {
+	if *m == nil {
+		*m = map[string]any{}
+	}
+	return *m
+}
+
+// AggregateResources returns the groups of the resources of a collection with their aggregated values.
+func (r *repository) AggregateResources(ctx context.Context, collection *Collection, params *AggregateParams) ([]AggregateGroup, error) {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+
+	groups, err := NewSelectQuery(collection.Slug, fields).Aggregate(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate resources: %w", err)
+	}
+
+	return groups, nil
+}
+
+// AggregateResources returns the groups of the resources of a collection with their aggregated values.
+func (s *service) AggregateResources(ctx context.Context, collection *Collection, params *AggregateParams) ([]AggregateGroup, error) {
+	return s.collectionRepository.AggregateResources(ctx, collection, params)
+}
@@ -0,0 +1,78 @@
+package collection_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Aggregate Tests
+// =================================================================================================
+
+func TestAggregateResources_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	count := int64(4)
+	groups := []collection.AggregateGroup{{Group: map[string]any{"author": int64(2)}, Count: &count}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(posts, nil)
+	mockService.EXPECT().
+		AggregateResources(gomock.Any(), posts, &collection.AggregateParams{Count: true, GroupBy: []string{"author"}, PublishedOnly: true}).
+		Return(groups, nil)
+
+	req := httptest.NewRequest("GET", "/collections/posts/aggregate?count=true&groupBy=author", nil)
+	req.SetPathValue("slug", "posts")
+
+	w := executeRequest(http.HandlerFunc(handler.AggregateResources), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `[{"group":{"author":2},"count":4}]` {
+		t.Errorf("unexpected response %s", body)
+	}
+}
+
+func TestAggregateResources_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(posts, nil)
+	mockService.EXPECT().
+		AggregateResources(gomock.Any(), posts, gomock.Any()).
+		Return(nil, fmt.Errorf("failed to aggregate resources: %w", collection.ErrInvalidQuery))
+
+	req := httptest.NewRequest("GET", "/collections/posts/aggregate?sum=title", nil)
+	req.SetPathValue("slug", "posts")
+
+	w := executeRequest(http.HandlerFunc(handler.AggregateResources), req, t)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+
+	// Without any aggregate, the request is rejected before the collection is looked up
+	req = httptest.NewRequest("GET", "/collections/posts/aggregate?groupBy=author", nil)
+	req.SetPathValue("slug", "posts")
+
+	if w := executeRequest(http.HandlerFunc(handler.AggregateResources), req, t); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+func TestParseAggregateParams(t *testing.T) {
+	params, err := ParseAggregateParams(url.Values{
+		"count":             {"true"},
+		"groupBy":           {"author, visible"},
+		"dateTrunc":         {"created_at:month"},
+		"sum":               {"views"},
+		"where[views][gte]": {"10"},
+		"limit":             {"20"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &AggregateParams{
+		Count:     true,
+		GroupBy:   []string{"author", "visible"},
+		DateTrunc: []DateTrunc{{Field: "created_at", Unit: "month"}},
+		Sum:       []string{"views"},
+		Filters:   []Filter{{Field: "views", Operator: OperatorGte, Value: "10"}},
+		Limit:     20,
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("expected params %+v, got %+v", expected, params)
+	}
+}
+
+func TestParseAggregateParams_Invalid(t *testing.T) {
+	for _, values := range []url.Values{
+		{"groupBy": {"author"}},
+		{"count": {"maybe"}},
+		{"count": {"true"}, "dateTrunc": {"created_at"}},
+		{"count": {"true"}, "dateTrunc": {"created_at:decade"}},
+		{"count": {"true"}, "sort": {"title"}},
+	} {
+		if _, err := ParseAggregateParams(values); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("expected ErrInvalidQuery for %v, got %v", values, err)
+		}
+	}
+}
+
+func TestBuildAggregateQuery_GroupsAndMetrics(t *testing.T) {
+	q := newTestSelectQuery()
+
+	params := &AggregateParams{
+		Count:         true,
+		GroupBy:       []string{"author"},
+		DateTrunc:     []DateTrunc{{Field: "created_at", Unit: "month"}},
+		Sum:           []string{"views"},
+		Max:           []string{"published_at"},
+		Filters:       []Filter{{Field: "visible", Operator: OperatorEquals, Value: "true"}},
+		PublishedOnly: true,
+		Limit:         10,
+	}
+
+	query, args, columns, err := q.buildAggregateQuery(q.tableName, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT "author_id", date_trunc('month', "created_at"), COUNT(*), SUM("views"), MAX("published_at") FROM "posts" ` +
+		`WHERE "deleted_at" IS NULL AND "status" = $1 AND "visible" = $2 ` +
+		`GROUP BY "author_id", date_trunc('month', "created_at") ORDER BY "author_id" ASC, date_trunc('month', "created_at") ASC LIMIT 10`
+	if query != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, query)
+	}
+	if !reflect.DeepEqual(args, []any{StatusPublished, true}) {
+		t.Errorf("unexpected args %v", args)
+	}
+	if len(columns) != 5 {
+		t.Errorf("expected 5 columns, got %d", len(columns))
+	}
+}
+
+func TestBuildAggregateQuery_GroupByMultiRelation(t *testing.T) {
+	q := newTestSelectQuery()
+
+	params := &AggregateParams{
+		Count:   true,
+		GroupBy: []string{"tags", "author"},
+		Sum:     []string{"views"},
+		Filters: []Filter{{Field: "visible", Operator: OperatorEquals, Value: "true"}},
+	}
+
+	query, args, columns, err := q.buildAggregateQuery(q.tableName, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT "posts_tags_relation_tags"."tags_id", "author_id", COUNT(*), SUM("views") FROM "posts" ` +
+		`LEFT JOIN "posts_tags_relation_tags" ON "posts_tags_relation_tags"."posts_id" = "posts"."id" ` +
+		`WHERE "deleted_at" IS NULL AND "visible" = $1 ` +
+		`GROUP BY "posts_tags_relation_tags"."tags_id", "author_id" ORDER BY "posts_tags_relation_tags"."tags_id" ASC, "author_id" ASC`
+	if query != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, query)
+	}
+	if !reflect.DeepEqual(args, []any{true}) {
+		t.Errorf("unexpected args %v", args)
+	}
+	if columns[0].key != "tags" || columns[0].codec != (referenceCodec{}) {
+		t.Errorf("expected the first column to group by tags, got %+v", columns[0])
+	}
+}
+
+func TestBuildAggregateQuery_RejectsUnsupportedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *AggregateParams
+	}{
+		{name: "unknown field", params: &AggregateParams{Count: true, GroupBy: []string{"unknown"}}},
+		{name: "group by date", params: &AggregateParams{Count: true, GroupBy: []string{"created_at"}}},
+		{name: "group by rich text", params: &AggregateParams{Count: true, GroupBy: []string{"body"}}},
+		{name: "multi relation grouped twice", params: &AggregateParams{Count: true, GroupBy: []string{"tags", "tags"}}},
+		{name: "truncate text", params: &AggregateParams{Count: true, DateTrunc: []DateTrunc{{Field: "title", Unit: "day"}}}},
+		{name: "grouped twice", params: &AggregateParams{Count: true, GroupBy: []string{"author", "author"}}},
+		{name: "sum of text", params: &AggregateParams{Sum: []string{"title"}}},
+		{name: "average of date", params: &AggregateParams{Avg: []string{"created_at"}}},
+		{name: "minimum of boolean", params: &AggregateParams{Min: []string{"visible"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestSelectQuery()
+
+			_, _, _, err := q.buildAggregateQuery(q.tableName, tt.params)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAggregate_DecodesGroups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	month := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "author_id", date_trunc('month', "created_at"), COUNT(*), AVG("views") FROM "posts"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"author_id", "date_trunc", "count", "avg"}).
+			AddRow(int64(2), month, int64(3), []byte("12.5")).
+			AddRow(nil, month, int64(1), nil))
+
+	groups, err := newTestSelectQuery().Aggregate(ctx, &AggregateParams{
+		Count:     true,
+		GroupBy:   []string{"author"},
+		DateTrunc: []DateTrunc{{Field: "created_at", Unit: "month"}},
+		Avg:       []string{"views"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	three, one := int64(3), int64(1)
+	expected := []AggregateGroup{
+		{Group: map[string]any{"author": int64(2), "created_at": month}, Count: &three, Avg: map[string]any{"views": json.Number("12.5")}},
+		{Group: map[string]any{"author": nil, "created_at": month}, Count: &one, Avg: map[string]any{"views": nil}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %+v, got %+v", expected, groups)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+// MaxBatchSize is the maximum number of operations of a batch.
+const MaxBatchSize = 100
+
+// BatchOperationType is the kind of change made by an operation of a batch.
+type BatchOperationType string
+
+const (
+	BatchCreate BatchOperationType = "create"
+	BatchUpdate BatchOperationType = "update"
+	BatchDelete BatchOperationType = "delete"
+)
+
+// BatchOperation is a change to a resource, executed with the other operations of its batch.
+type BatchOperation struct {
+	Op         BatchOperationType `json:"op"`
+	Collection string             `json:"collection"`
+	Slug       string             `json:"slug"`
+	// Content is the content of the resource to create, or the fields to change for an update.
+	Content map[string]any `json:"content,omitempty"`
+	// IfMatch is the ETag of the version an update or a deletion is based on, as in an `If-Match` header.
+	IfMatch string `json:"ifMatch,omitempty"`
+}
+
+// BatchStatus is the outcome of an operation of a batch.
+type BatchStatus string
+
+const (
+	// BatchSucceeded operations are committed.
+	BatchSucceeded BatchStatus = "succeeded"
+	// BatchFailed is the status of the operation that failed the batch.
+	BatchFailed BatchStatus = "failed"
+	// BatchRolledBack operations succeeded before the batch failed, their changes were discarded.
+	BatchRolledBack BatchStatus = "rolled_back"
+	// BatchSkipped operations come after the operation that failed the batch, they were not executed.
+	BatchSkipped BatchStatus = "skipped"
+)
+
+// BatchResult is the outcome of an operation of a batch, at the same index as the operation.
+type BatchResult struct {
+	Op         BatchOperationType `json:"op"`
+	Collection string             `json:"collection"`
+	Slug       string             `json:"slug"`
+	Status     BatchStatus        `json:"status"`
+	// Resource is the created or updated resource, once the batch is committed.
+	Resource *Resource `json:"resource,omitempty"`
+	// Errors are the invalid fields of the content of the failed operation.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Error is the reason why the operation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchError is returned when an operation fails, in which case none of the operations of the batch are applied.
+type BatchError struct {
+	// Index is the index of the failed operation.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteBatch executes the operations in order within a single transaction.
+// When an operation fails, every change is rolled back and a *BatchError is returned along with the results,
+// which tell which operation failed and why.
+func (s *service) ExecuteBatch(ctx context.Context, operations []BatchOperation, executedBy int64) ([]BatchResult, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: a batch must have at least one operation", ErrInvalidContent)
+	}
+	if len(operations) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: a batch must have at most %d operations", ErrInvalidContent, MaxBatchSize)
+	}
+
+	results := make([]BatchResult, len(operations))
+	for i, operation := range operations {
+		results[i] = BatchResult{Op: operation.Op, Collection: operation.Collection, Slug: operation.Slug, Status: BatchSkipped}
+	}
+
+	failed := -1
+	err := config.WithinTx(ctx, func(ctx context.Context) error {
+		collections := map[string]*Collection{}
+
+		for i, operation := range operations {
+			resource, err := s.executeOperation(ctx, collections, operation, executedBy)
+			if err != nil {
+				failed = i
+				return &BatchError{Index: i, Err: err}
+			}
+
+			results[i].Status = BatchSucceeded
+			results[i].Resource = resource
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		// The transaction could not be committed, so none of the operations is applied
+		for i := range results {
+			results[i].Status = BatchRolledBack
+			results[i].Resource = nil
+		}
+		return results, err
+	}
+
+	for i := range failed {
+		results[i].Status = BatchRolledBack
+		results[i].Resource = nil
+	}
+	results[failed].Status = BatchFailed
+	setBatchError(&results[failed], batchErr.Err)
+
+	return results, batchErr
+}
+
+// executeOperation executes an operation of a batch, returning the resource it created or updated.
+// The collections are cached by slug, as a batch usually changes many resources of the same collections.
+func (s *service) executeOperation(ctx context.Context, collections map[string]*Collection, operation BatchOperation, executedBy int64) (*Resource, error) {
+	if operation.Slug == "" {
+		return nil, fmt.Errorf("%w: slug is required", ErrInvalidContent)
+	}
+
+	collection, ok := collections[operation.Collection]
+	if !ok {
+		var err error
+		if collection, err = s.FindBySlug(ctx, operation.Collection); err != nil {
+			return nil, err
+		}
+		collections[operation.Collection] = collection
+	}
+
+	ctx = ContextWithPrecondition(ctx, ParseIfMatch(operation.IfMatch))
+
+	switch operation.Op {
+	case BatchCreate:
+		return s.CreateResource(ctx, collection, operation.Slug, executedBy, operation.Content)
+	case BatchUpdate:
+		return s.UpdateResource(ctx, collection, operation.Slug, executedBy, operation.Content)
+	case BatchDelete:
+		resource, err := s.FindResource(ctx, collection, operation.Slug)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.DeleteResource(ctx, collection, resource, executedBy)
+	default:
+		return nil, fmt.Errorf("%w: op must be %s, %s or %s", ErrInvalidContent, BatchCreate, BatchUpdate, BatchDelete)
+	}
+}
+
+// setBatchError reports why an operation failed, unexpected errors are hidden.
+func setBatchError(result *BatchResult, err error) {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		result.Errors = validationErr.Errors
+		result.Error = "validation failed"
+	case errors.Is(err, ErrNotFound):
+		result.Error = "not found"
+	case errors.Is(err, ErrAlreadyExists):
+		result.Error = "a resource with this slug already exists"
+	case errors.Is(err, ErrPreconditionFailed):
+		result.Error = "the resource was modified since the version given in ifMatch"
+	case errors.Is(err, ErrReferenced):
+		result.Error = "the resource is referenced by other resources"
+	case errors.Is(err, ErrInvalidContent):
+		result.Error = err.Error()
+	default:
+		result.Error = "Internal Server Error"
+	}
+}
+
+// batchErrorStatus returns the status of the response of a batch that failed because of an error.
+func batchErrorStatus(err error) int {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyExists), errors.Is(err, ErrReferenced):
+		return http.StatusConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrInvalidContent):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
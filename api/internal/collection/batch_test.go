@@ -0,0 +1,156 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Batch Tests
+// =================================================================================================
+
+func TestService_ExecuteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	articles := collection.NewTestCollection("articles", bulkFields)
+	created := &collection.Resource{Id: 1, Slug: "first", Fields: map[string]any{"title": "First"}}
+	existing := &collection.Resource{Id: 2, Slug: "second", Fields: map[string]any{}}
+
+	// The collection is only looked up once for the whole batch
+	mockRepo.EXPECT().FindBySlug(gomock.Any(), "articles").Return(articles, nil)
+	mockRepo.EXPECT().CreateResource(gomock.Any(), articles, "first", int64(3), map[string]any{"title": "First"}).Return(created, nil)
+	mockRepo.EXPECT().FindResource(gomock.Any(), articles, "second").Return(existing, nil)
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*articles}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+	mockRepo.EXPECT().DeleteResource(gomock.Any(), existing).Return(nil)
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	results, err := service.ExecuteBatch(context.Background(), []collection.BatchOperation{
+		{Op: collection.BatchCreate, Collection: "articles", Slug: "first", Content: map[string]any{"title": "First"}},
+		{Op: collection.BatchDelete, Collection: "articles", Slug: "second"},
+	}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []collection.BatchResult{
+		{Op: collection.BatchCreate, Collection: "articles", Slug: "first", Status: collection.BatchSucceeded, Resource: created},
+		{Op: collection.BatchDelete, Collection: "articles", Slug: "second", Status: collection.BatchSucceeded},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected results %+v, got %+v", expected, results)
+	}
+}
+
+func TestService_ExecuteBatch_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	articles := collection.NewTestCollection("articles", bulkFields)
+
+	mockRepo.EXPECT().FindBySlug(gomock.Any(), "articles").Return(articles, nil)
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), articles, "first", int64(3), map[string]any{"title": "Retitled"}).
+		Return(&collection.Resource{Id: 1, Slug: "first", Fields: map[string]any{}}, nil)
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), articles, "missing", int64(3), map[string]any{"title": "Retitled"}).
+		Return(nil, collection.ErrNotFound)
+
+	results, err := service.ExecuteBatch(context.Background(), []collection.BatchOperation{
+		{Op: collection.BatchUpdate, Collection: "articles", Slug: "first", Content: map[string]any{"title": "Retitled"}},
+		{Op: collection.BatchUpdate, Collection: "articles", Slug: "missing", Content: map[string]any{"title": "Retitled"}},
+		{Op: collection.BatchDelete, Collection: "articles", Slug: "last"},
+	}, 3)
+
+	var batchErr *collection.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, collection.ErrNotFound) {
+		t.Fatalf("expected the second operation to fail with not found, got %v", err)
+	}
+
+	statuses := []collection.BatchStatus{results[0].Status, results[1].Status, results[2].Status}
+	if !reflect.DeepEqual(statuses, []collection.BatchStatus{collection.BatchRolledBack, collection.BatchFailed, collection.BatchSkipped}) {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if results[0].Resource != nil {
+		t.Error("expected the rolled back resource not to be returned")
+	}
+	if results[1].Error != "not found" {
+		t.Errorf("expected the failure to be reported, got %q", results[1].Error)
+	}
+}
+
+func TestService_ExecuteBatch_Empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	if _, err := service.ExecuteBatch(context.Background(), nil, 1); !errors.Is(err, collection.ErrInvalidContent) {
+		t.Errorf("expected an invalid content error, got %v", err)
+	}
+}
+
+func TestBatch_ValidationFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	user := createMockUser()
+	operations := []collection.BatchOperation{{Op: collection.BatchCreate, Collection: "articles", Slug: "first", Content: map[string]any{}}}
+	results := []collection.BatchResult{{Op: collection.BatchCreate, Collection: "articles", Slug: "first", Status: collection.BatchFailed,
+		Error: "validation failed", Errors: []collection.FieldError{{Path: "title", Reason: "is required"}}}}
+
+	mockService.EXPECT().
+		ExecuteBatch(gomock.Any(), operations, user.ID).
+		Return(results, &collection.BatchError{Index: 0, Err: &collection.ValidationError{Errors: results[0].Errors}})
+
+	req := newJSONRequest(t, "POST", "/batch", `{"operations": [{"op": "create", "collection": "articles", "slug": "first", "content": {}}]}`)
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.Batch), req, t)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status Unprocessable Entity, got %v", w.Code)
+	}
+
+	var response collection.BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(response.Results, results) {
+		t.Errorf("expected results %+v, got %+v", results, response.Results)
+	}
+}
+
+func TestBatch_Unauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := collection.NewHandler(mocks.NewMockService(ctrl))
+
+	req := newJSONRequest(t, "POST", "/batch", `{"operations": []}`)
+
+	w := executeRequest(http.HandlerFunc(handler.Batch), req, t)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+}
@@ -0,0 +1,286 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// Blocks are read and written as an ordered list of objects, each with its type in `block_type` and the
+// values of the fields of its type. They are stored in a table per blocks field, with a row per block
+// referencing the row of its parent, see schema_generator.HandleBlocksField.
+
+// blocksTable describes the table storing the blocks of a field.
+type blocksTable struct {
+	// name is the name of the table, `<table>_<field>_blocks`.
+	name string
+	// types are the block types of the field, by name.
+	types map[string]*blockType
+}
+
+// blockType describes the columns storing the fields of a block type.
+type blockType struct {
+	// codecs are the codecs of the fields, by unprefixed column.
+	codecs map[string]Codec
+	// blocks are the tables of the nested blocks fields, by field name.
+	blocks map[string]*blocksTable
+}
+
+// newBlocksTable returns the table storing the blocks of a field of a table, which is either
+// a collection or the table of the parent blocks.
+func newBlocksTable(tableName string, fieldName string, element mimsy_schema.SchemaElement) *blocksTable {
+	table := &blocksTable{
+		name:  schema_generator.GetBlocksTableName(tableName, fieldName),
+		types: map[string]*blockType{},
+	}
+
+	for name, fields := range element.GetBlocks() {
+		blockType := &blockType{codecs: columnCodecs(fields), blocks: map[string]*blocksTable{}}
+		for field, fieldElement := range fields {
+			if fieldElement.Type == "blocks" {
+				blockType.blocks[field] = newBlocksTable(table.name, schema_generator.GetBlockColumnName(name, field), fieldElement)
+			}
+		}
+		table.types[name] = blockType
+	}
+
+	return table
+}
+
+// blocksTables returns the tables of the blocks fields of a collection, by field name.
+func blocksTables(collectionSlug string, fields mimsy_schema.CollectionFields) map[string]*blocksTable {
+	tables := map[string]*blocksTable{}
+	for name, element := range fields {
+		if element.Type == "blocks" {
+			tables[name] = newBlocksTable(collectionSlug, name, element)
+		}
+	}
+	return tables
+}
+
+// columns returns the columns selected to read the blocks, in a stable order.
+func (t *blocksTable) columns() []string {
+	columns := []string{"id", schema_generator.BlockParentColumn, schema_generator.BlockTypeColumn}
+	for _, name := range slices.Sorted(maps.Keys(t.types)) {
+		for _, column := range slices.Sorted(maps.Keys(t.types[name].codecs)) {
+			columns = append(columns, schema_generator.GetBlockColumnName(name, column))
+		}
+	}
+	return columns
+}
+
+// find returns the blocks of the given parent rows, in order, nested blocks included.
+func (t *blocksTable) find(ctx context.Context, parentIds []int64) (map[int64][]any, error) {
+	blocks := map[int64][]any{}
+	if len(parentIds) == 0 {
+		return blocks, nil
+	}
+
+	columns := t.columns()
+	quotedColumns := make([]string, len(columns))
+	indexes := make(map[string]int, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = pq.QuoteIdentifier(column)
+		indexes[column] = i
+	}
+
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(quotedColumns...).
+		From(pq.QuoteIdentifier(t.name)).
+		Where(sq.Eq{pq.QuoteIdentifier(schema_generator.BlockParentColumn): parentIds}).
+		OrderBy(pq.QuoteIdentifier(schema_generator.BlockParentColumn), pq.QuoteIdentifier(schema_generator.BlockPositionColumn)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build blocks SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks %s: %w", t.name, err)
+	}
+	defer rows.Close()
+
+	// The blocks holding nested blocks, by identifier, and their identifiers by type
+	parents := map[int64]map[string]any{}
+	idsByType := map[string][]int64{}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		valuesPtrs := make([]any, len(columns))
+		for i := range values {
+			valuesPtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuesPtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan block row: %w", err)
+		}
+
+		id, _ := values[0].(int64)
+		parentId, _ := values[1].(int64)
+		decoded, err := textCodec{}.Decode(values[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode block type: %w", err)
+		}
+
+		name, _ := decoded.(string)
+		blockType, ok := t.types[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown block type %q in %s", name, t.name)
+		}
+
+		block := map[string]any{schema_generator.BlockTypeColumn: name}
+		for column, codec := range blockType.codecs {
+			prefixed := schema_generator.GetBlockColumnName(name, column)
+			value, err := codec.Decode(values[indexes[prefixed]])
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode block column %q: %w", prefixed, err)
+			}
+			block[column] = value
+		}
+
+		if len(blockType.blocks) > 0 {
+			parents[id] = block
+			idsByType[name] = append(idsByType[name], id)
+		}
+		blocks[parentId] = append(blocks[parentId], block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over block rows: %w", err)
+	}
+
+	for name, ids := range idsByType {
+		for field, nested := range t.types[name].blocks {
+			nestedBlocks, err := nested.find(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, id := range ids {
+				parents[id][field] = emptyIfNil(nestedBlocks[id])
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// set replaces the blocks of a parent row by the given list, nested blocks included.
+// It must be called within the transaction of the change of the parent.
+func (t *blocksTable) set(ctx context.Context, fieldName string, parentId int64, value any) error {
+	var items []any
+	if value != nil {
+		var ok bool
+		if items, ok = value.([]any); !ok {
+			return fmt.Errorf("%w: field %q must be a list of blocks", ErrInvalidContent, fieldName)
+		}
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	// The nested blocks are deleted along with their parent
+	query, args, err := psql.
+		Delete(pq.QuoteIdentifier(t.name)).
+		Where(sq.Eq{pq.QuoteIdentifier(schema_generator.BlockParentColumn): parentId}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build blocks delete SQL query: %w", err)
+	}
+
+	if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete blocks of field %q: %w", fieldName, err)
+	}
+
+	for position, item := range items {
+		path := fmt.Sprintf("%s[%d]", fieldName, position)
+
+		block, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %s must be a block", ErrInvalidContent, path)
+		}
+
+		name, _ := block[schema_generator.BlockTypeColumn].(string)
+		blockType, ok := t.types[name]
+		if !ok {
+			return fmt.Errorf("%w: %s has an unknown block type %q", ErrInvalidContent, path, name)
+		}
+
+		columns := []string{
+			pq.QuoteIdentifier(schema_generator.BlockParentColumn),
+			pq.QuoteIdentifier(schema_generator.BlockPositionColumn),
+			pq.QuoteIdentifier(schema_generator.BlockTypeColumn),
+		}
+		values := []any{parentId, position, name}
+
+		for _, column := range slices.Sorted(maps.Keys(blockType.codecs)) {
+			encoded, err := blockType.codecs[column].Encode(block[column])
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", path, column, err)
+			}
+
+			columns = append(columns, pq.QuoteIdentifier(schema_generator.GetBlockColumnName(name, column)))
+			values = append(values, encoded)
+		}
+
+		query, args, err := psql.
+			Insert(pq.QuoteIdentifier(t.name)).
+			Columns(columns...).
+			Values(values...).
+			Suffix(`RETURNING "id"`).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build block insert SQL query: %w", err)
+		}
+
+		var id int64
+		if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			return fmt.Errorf("failed to insert block %s: %w", path, err)
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(blockType.blocks)) {
+			if err := blockType.blocks[field].set(ctx, fmt.Sprintf("%s.%s", path, field), id, block[field]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadBlocks reads the blocks fields of the resources, resources without blocks have an empty list.
+func loadBlocks(ctx context.Context, tables map[string]*blocksTable, resources []*Resource) error {
+	if len(tables) == 0 || len(resources) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(resources))
+	for i, resource := range resources {
+		ids[i] = resource.Id
+	}
+
+	for name, table := range tables {
+		blocks, err := table.find(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range resources {
+			resource.Fields[name] = emptyIfNil(blocks[resource.Id])
+		}
+	}
+
+	return nil
+}
+
+func emptyIfNil(blocks []any) []any {
+	if blocks == nil {
+		return []any{}
+	}
+	return blocks
+}
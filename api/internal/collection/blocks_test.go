@@ -0,0 +1,137 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// testBlocksElement has a flat block type and a block type holding nested blocks.
+var testBlocksElement = mimsy_schema.SchemaElement{Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+	Blocks: map[string]mimsy_schema.CollectionFields{
+		"hero": {
+			"heading": {Type: "string"},
+			"image":   {Type: "relation", RelatesTo: "<builtins.media>"},
+		},
+		"columns": {
+			"items": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+				Blocks: map[string]mimsy_schema.CollectionFields{"text": {"body": {Type: "string"}}},
+			}},
+		},
+	},
+}}
+
+func TestBlocksTable_Set(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "pages_content_blocks" WHERE "parent_id" = $1`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "pages_content_blocks" ("parent_id","position","block_type","hero__heading","hero__image_id") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(int64(7), 0, "hero", "Welcome", int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(10)))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "pages_content_blocks" ("parent_id","position","block_type") VALUES ($1,$2,$3) RETURNING "id"`)).
+		WithArgs(int64(7), 1, "columns").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(11)))
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "pages_content_blocks_columns__items_blocks" WHERE "parent_id" = $1`)).
+		WithArgs(int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "pages_content_blocks_columns__items_blocks" ("parent_id","position","block_type","text__body") VALUES ($1,$2,$3,$4) RETURNING "id"`)).
+		WithArgs(int64(11), 0, "text", "First").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(20)))
+
+	value := []any{
+		map[string]any{"block_type": "hero", "heading": "Welcome", "image_id": float64(4)},
+		map[string]any{"block_type": "columns", "items": []any{
+			map[string]any{"block_type": "text", "body": "First"},
+		}},
+	}
+
+	if err := newBlocksTable("pages", "content", testBlocksElement).set(ctx, "content", 7, value); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBlocksTable_Set_UnknownType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "pages_content_blocks" WHERE "parent_id" = $1`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	value := []any{map[string]any{"block_type": "quote"}}
+	if err := newBlocksTable("pages", "content", testBlocksElement).set(ctx, "content", 7, value); !errors.Is(err, ErrInvalidContent) {
+		t.Errorf("expected ErrInvalidContent, got %v", err)
+	}
+}
+
+func TestLoadBlocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id", "parent_id", "block_type", "hero__heading", "hero__image_id" FROM "pages_content_blocks" WHERE "parent_id" IN ($1,$2) ORDER BY "parent_id", "position"`)).
+		WithArgs(int64(7), int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "block_type", "hero__heading", "hero__image_id"}).
+			AddRow(int64(10), int64(7), "hero", "Welcome", int64(4)).
+			AddRow(int64(11), int64(7), "columns", nil, nil))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id", "parent_id", "block_type", "text__body" FROM "pages_content_blocks_columns__items_blocks" WHERE "parent_id" IN ($1) ORDER BY "parent_id", "position"`)).
+		WithArgs(int64(11)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "block_type", "text__body"}).
+			AddRow(int64(20), int64(11), "text", "First"))
+
+	resources := []*Resource{{Id: 7, Fields: map[string]any{}}, {Id: 8, Fields: map[string]any{}}}
+	tables := blocksTables("pages", mimsy_schema.CollectionFields{"content": testBlocksElement, "title": {Type: "string"}})
+
+	if err := loadBlocks(ctx, tables, resources); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []any{
+		map[string]any{"block_type": "hero", "heading": "Welcome", "image_id": int64(4)},
+		map[string]any{"block_type": "columns", "items": []any{
+			map[string]any{"block_type": "text", "body": "First"},
+		}},
+	}
+	if !reflect.DeepEqual(resources[0].Fields["content"], expected) {
+		t.Errorf("expected %v, got %v", expected, resources[0].Fields["content"])
+	}
+	if blocks := resources[1].Fields["content"]; !reflect.DeepEqual(blocks, []any{}) {
+		t.Errorf("expected no blocks, got %v", blocks)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,592 @@
+package collection
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// BulkFormat is the format of the files resources are exported to and imported from.
+type BulkFormat string
+
+const (
+	// BulkFormatNDJSON writes a resource as a JSON object on each line.
+	BulkFormatNDJSON BulkFormat = "ndjson"
+	// BulkFormatCSV writes a resource on each row, under a header naming the columns.
+	// Localized and rich text values are written as JSON, many-to-many relations as comma separated identifiers.
+	BulkFormatCSV BulkFormat = "csv"
+)
+
+// ParseBulkFormat returns the format of a `format` query parameter, NDJSON when empty.
+func ParseBulkFormat(value string) (BulkFormat, error) {
+	switch BulkFormat(value) {
+	case "", BulkFormatNDJSON:
+		return BulkFormatNDJSON, nil
+	case BulkFormatCSV:
+		return BulkFormatCSV, nil
+	default:
+		return "", fmt.Errorf("%w: format must be ndjson or csv", ErrInvalidQuery)
+	}
+}
+
+// ContentType returns the media type of the files of the format.
+func (f BulkFormat) ContentType() string {
+	if f == BulkFormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// exportPageSize is the number of resources read at once while exporting a collection.
+const exportPageSize = MaxLimit
+
+// ExportResources calls fn with every resource of a collection, drafts included and trashed resources excluded,
+// in the order of their identifiers. Many-to-many relations are set to the identifiers of the related resources,
+// so that the exported resources can be imported back.
+func (s *service) ExportResources(ctx context.Context, collection *Collection, fn func(resource Resource) error) error {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	relations := map[string]*Relation{}
+	for name, element := range fields {
+		if element.Type != "multi_relation" {
+			continue
+		}
+		relation, err := NewRelation(collection.Slug, name, element)
+		if err != nil {
+			return err
+		}
+		relations[name] = relation
+	}
+
+	// Pages are read after the last identifier, so that resources created meanwhile do not shift them
+	var lastId int64
+	for {
+		params := &FindResourcesParams{
+			Filters: []Filter{{Field: "id", Operator: OperatorGt, Value: strconv.FormatInt(lastId, 10)}},
+			Sort:    []Sort{{Field: "id"}},
+			Limit:   exportPageSize,
+		}
+
+		resources, _, err := s.collectionRepository.FindResources(ctx, collection, params)
+		if err != nil {
+			return err
+		}
+		if len(resources) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(resources))
+		for i, resource := range resources {
+			ids[i] = resource.Id
+		}
+
+		for name, relation := range relations {
+			relationIds, err := s.collectionRepository.FindRelationIds(ctx, relation, ids)
+			if err != nil {
+				return err
+			}
+			for _, resource := range resources {
+				targets := relationIds[resource.Id]
+				if targets == nil {
+					targets = []int64{}
+				}
+				resource.Fields[name] = targets
+			}
+		}
+
+		for _, resource := range resources {
+			if err := fn(resource); err != nil {
+				return err
+			}
+		}
+
+		if len(resources) < exportPageSize {
+			return nil
+		}
+		lastId = resources[len(resources)-1].Id
+	}
+}
+
+// ResourceWriter writes exported resources to a file.
+type ResourceWriter interface {
+	Write(resource Resource) error
+	// Flush writes the buffered data, it must be called once every resource is written.
+	Flush() error
+}
+
+// NewResourceWriter returns a writer of the resources of a collection in a format.
+func NewResourceWriter(w io.Writer, format BulkFormat, collection *Collection) (ResourceWriter, error) {
+	if format == BulkFormatNDJSON {
+		return &ndjsonWriter{w: bufio.NewWriter(w)}, nil
+	}
+
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+	return &csvWriter{w: csv.NewWriter(w), columns: csvColumns(fields)}, nil
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+func (n *ndjsonWriter) Write(resource Resource) error {
+	line, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(line); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	header  bool
+}
+
+// csvColumns returns the columns of the exported resources of a collection, the read only columns first.
+// Many-to-one relations are written in their `<field>_id` column.
+func csvColumns(fields mimsy_schema.CollectionFields) []string {
+	columns := []string{"id", "slug", "status", "created_at", "created_by", "updated_at", "updated_by", "published_at", "publish_at", "unpublish_at"}
+	for _, name := range sortedKeys(fields) {
+		if fields[name].Type == "relation" {
+			name = fmt.Sprintf("%s_id", name)
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+func (c *csvWriter) Write(resource Resource) error {
+	if !c.header {
+		if err := c.w.Write(c.columns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	values := map[string]any{
+		"id":           resource.Id,
+		"slug":         resource.Slug,
+		"status":       resource.Status,
+		"created_at":   resource.CreatedAt,
+		"created_by":   resource.CreatedBy,
+		"updated_at":   resource.UpdatedAt,
+		"updated_by":   resource.UpdatedBy,
+		"published_at": resource.PublishedAt,
+		"publish_at":   resource.PublishAt,
+		"unpublish_at": resource.UnpublishAt,
+	}
+
+	record := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		value, ok := values[column]
+		if !ok {
+			value = resource.Fields[column]
+		}
+
+		cell, err := formatCSV(value)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", column, err)
+		}
+		record[i] = cell
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.header {
+		// An empty collection is exported as its header
+		if err := c.w.Write(c.columns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatCSV converts the value of a column to a cell, null values are written as empty cells.
+func formatCSV(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case Status:
+		return string(v), nil
+	case json.Number:
+		return v.String(), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case []int64:
+		ids := make([]string, len(v))
+		for i, id := range v {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		return strings.Join(ids, ","), nil
+	case []byte:
+		return string(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
+
+// ImportRow is a row of an imported file, with the content of a resource identified by its slug.
+type ImportRow struct {
+	// Line is the line of the row in the file, starting at 1, the header of CSV files being line 1.
+	Line    int
+	Content map[string]any
+	// Err is set when the row could not be read, it is reported as failed.
+	Err error
+}
+
+// ReadImportRows reads the rows of an imported file.
+// Rows that cannot be read are returned with their error, an error is only returned when the file cannot be read.
+func ReadImportRows(r io.Reader, format BulkFormat, collection *Collection) ([]ImportRow, error) {
+	if format == BulkFormatCSV {
+		fields := mimsy_schema.CollectionFields{}
+		if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
+		}
+		return readCSVRows(r, fields)
+	}
+	return readNDJSONRows(r)
+}
+
+func readNDJSONRows(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	var rows []ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		// Numbers are kept as json.Number to keep their precision
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+
+		row := ImportRow{Line: line}
+		if err := decoder.Decode(&row.Content); err != nil {
+			row.Err = fmt.Errorf("%w: invalid JSON: %v", ErrInvalidContent, err)
+		} else if row.Content == nil {
+			row.Err = fmt.Errorf("%w: expected a JSON object", ErrInvalidContent)
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// maxImportLineSize is the maximum size of a line of an imported NDJSON file.
+const maxImportLineSize = 1024 * 1024
+
+func readCSVRows(r io.Reader, fields mimsy_schema.CollectionFields) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: invalid CSV header: %v", ErrInvalidContent, err)
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{Line: line}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			row.Line = parseErr.StartLine
+			row.Err = fmt.Errorf("%w: invalid CSV row: %v", ErrInvalidContent, parseErr.Err)
+			rows = append(rows, row)
+			continue
+		}
+
+		if len(record) != len(header) {
+			row.Err = fmt.Errorf("%w: expected %d columns, got %d", ErrInvalidContent, len(header), len(record))
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Content = map[string]any{}
+		for i, column := range header {
+			if row.Content[column], err = parseCSV(fields, column, record[i]); err != nil {
+				row.Err = fmt.Errorf("%w: column %q: %v", ErrInvalidContent, column, err)
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseCSV converts a cell to the value of its column, empty cells are null.
+// Values are left as strings when the validation of their field accepts them.
+func parseCSV(fields mimsy_schema.CollectionFields, column string, cell string) (any, error) {
+	if cell == "" {
+		return nil, nil
+	}
+
+	element, ok := fields[column]
+	if !ok {
+		return cell, nil
+	}
+
+	if element.IsLocalized() {
+		return decodeJSONCell(cell)
+	}
+
+	switch element.Type {
+	case "checkbox":
+		if b, err := strconv.ParseBool(cell); err == nil {
+			return b, nil
+		}
+		// Left to the validation, which reports that it is not a boolean
+		return cell, nil
+	case "multi_relation":
+		var targets []any
+		for _, target := range strings.Split(cell, ",") {
+			target = strings.TrimSpace(target)
+			if id, err := strconv.ParseInt(target, 10, 64); err == nil {
+				targets = append(targets, id)
+			} else {
+				targets = append(targets, target)
+			}
+		}
+		return targets, nil
+	case "select":
+		if !element.IsMultiple() {
+			return cell, nil
+		}
+		// Exported lists are JSON arrays, hand-written ones are comma-separated
+		if strings.HasPrefix(cell, "[") {
+			return decodeJSONCell(cell)
+		}
+
+		var values []any
+		for _, value := range strings.Split(cell, ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return values, nil
+	case "string", "long_string", "email", "number", "date_time", "created_at":
+		return cell, nil
+	default:
+		return decodeJSONCell(cell)
+	}
+}
+
+func decodeJSONCell(cell string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(cell))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return value, nil
+}
+
+// ImportAction is what was done with a row of an imported file.
+type ImportAction string
+
+const (
+	ImportCreated ImportAction = "created"
+	ImportUpdated ImportAction = "updated"
+	ImportFailed  ImportAction = "failed"
+)
+
+// ImportRowReport is the outcome of a row of an imported file.
+type ImportRowReport struct {
+	Line   int          `json:"line"`
+	Slug   string       `json:"slug,omitempty"`
+	Action ImportAction `json:"action"`
+	// Errors are the invalid fields of a row that does not match the collection definition.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Error is the reason why a row failed, when it is not a validation error.
+	Error string `json:"error,omitempty"`
+}
+
+// ImportReport is the outcome of an import.
+type ImportReport struct {
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowReport `json:"rows"`
+}
+
+// ImportResources creates or updates a resource for each row, matching the existing resources by slug.
+//
+// Rows are imported in batches of batchSize rows, each within a transaction, or all together when batchSize is 0.
+// A row that fails is rolled back alone and reported as failed, the other rows of its batch are still imported,
+// unless the transaction itself fails, in which case every row of the batch is reported as failed.
+// The read only columns of the rows are ignored, except the status, which is applied after the content.
+func (s *service) ImportResources(ctx context.Context, collection *Collection, rows []ImportRow, importedBy int64, batchSize int) (*ImportReport, error) {
+	if collection.IsGlobal {
+		return nil, fmt.Errorf("%w: globals cannot be imported", ErrInvalidContent)
+	}
+
+	if batchSize <= 0 {
+		batchSize = max(len(rows), 1)
+	}
+
+	report := &ImportReport{Rows: make([]ImportRowReport, 0, len(rows))}
+	for batch := range slices.Chunk(rows, batchSize) {
+		results := make([]ImportRowReport, len(batch))
+		for i, row := range batch {
+			results[i] = ImportRowReport{Line: row.Line, Action: ImportFailed}
+		}
+
+		err := config.WithinTx(ctx, func(ctx context.Context) error {
+			for i, row := range batch {
+				if row.Err != nil {
+					results[i].Error = row.Err.Error()
+					continue
+				}
+
+				var rowErr error
+				err := config.WithinSavepoint(ctx, func(ctx context.Context) error {
+					results[i].Slug, results[i].Action, rowErr = s.importRow(ctx, collection, row.Content, importedBy)
+					return rowErr
+				})
+				if rowErr != nil {
+					results[i].Action = ImportFailed
+					setImportError(&results[i], rowErr)
+				}
+				if err != nil && err != rowErr {
+					// The savepoint could not be released or rolled back, so the transaction cannot be used anymore
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("Failed to import batch", "collection", collection.Slug, "error", err)
+			for i := range results {
+				if results[i].Error == "" {
+					results[i].Action = ImportFailed
+					results[i].Error = "the batch could not be imported"
+				}
+			}
+		}
+
+		for _, result := range results {
+			switch result.Action {
+			case ImportCreated:
+				report.Created++
+			case ImportUpdated:
+				report.Updated++
+			default:
+				report.Failed++
+			}
+		}
+		report.Rows = append(report.Rows, results...)
+	}
+
+	return report, nil
+}
+
+// importRow creates or updates the resource of a row, returning its slug and whether it was created or updated.
+func (s *service) importRow(ctx context.Context, collection *Collection, content map[string]any, importedBy int64) (string, ImportAction, error) {
+	slug, _ := content["slug"].(string)
+	if slug == "" {
+		return "", ImportFailed, fmt.Errorf("%w: slug is required", ErrInvalidContent)
+	}
+
+	var status Status
+	if value, ok := content["status"]; ok && value != nil {
+		s, _ := value.(string)
+		status = Status(s)
+		if status != StatusDraft && status != StatusPublished {
+			return slug, ImportFailed, fmt.Errorf("%w: status must be %s or %s", ErrInvalidContent, StatusDraft, StatusPublished)
+		}
+	}
+
+	action := ImportUpdated
+	resource, err := s.UpdateResource(ctx, collection, slug, importedBy, content)
+	if errors.Is(err, ErrNotFound) {
+		action = ImportCreated
+		resource, err = s.CreateResource(ctx, collection, slug, importedBy, content)
+	}
+	if err != nil {
+		return slug, ImportFailed, err
+	}
+
+	if status != "" && status != resource.Status {
+		// Through the service, so that the publication is notified like any other
+		if _, err := s.updateResourceStatus(ctx, collection, slug, importedBy, status); err != nil {
+			return slug, ImportFailed, err
+		}
+	}
+
+	return slug, action, nil
+}
+
+// setImportError reports why a row failed, unexpected errors are logged and hidden.
+func setImportError(result *ImportRowReport, err error) {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		result.Errors = validationErr.Errors
+		result.Error = "validation failed"
+	case errors.Is(err, ErrAlreadyExists):
+		result.Error = "a resource with this slug is in the trash"
+	case errors.Is(err, ErrInvalidContent):
+		result.Error = err.Error()
+	default:
+		slog.Error("Failed to import row", "line", result.Line, "slug", result.Slug, "error", err)
+		result.Error = "Internal Server Error"
+	}
+}
@@ -0,0 +1,286 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Import and Export Tests
+// =================================================================================================
+
+func TestService_ImportResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var events []collection.EventType
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo, collection.WithEventHook(func(ctx context.Context, event collection.Event) error {
+		events = append(events, event.Type)
+		return nil
+	}))
+
+	articles := collection.NewTestCollection("articles", bulkFields)
+
+	rows := []collection.ImportRow{
+		{Line: 1, Content: map[string]any{"slug": "existing", "title": "Updated", "status": "published"}},
+		{Line: 2, Content: map[string]any{"slug": "new", "title": "Created"}},
+		{Line: 3, Content: map[string]any{"slug": "invalid", "views": "many"}},
+		{Line: 4, Content: map[string]any{"title": "No slug"}},
+		{Line: 5, Err: fmt.Errorf("%w: invalid JSON", collection.ErrInvalidContent)},
+	}
+
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), articles, "existing", int64(3), rows[0].Content).
+		Return(&collection.Resource{Id: 1, Slug: "existing", Status: collection.StatusDraft, Fields: map[string]any{}}, nil)
+	mockRepo.EXPECT().
+		UpdateResourceStatus(gomock.Any(), articles, "existing", int64(3), collection.StatusPublished).
+		Return(&collection.Resource{Id: 1, Slug: "existing", Status: collection.StatusPublished}, nil)
+
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), articles, "new", int64(3), rows[1].Content).
+		Return(nil, collection.ErrNotFound)
+	mockRepo.EXPECT().
+		CreateResource(gomock.Any(), articles, "new", int64(3), rows[1].Content).
+		Return(&collection.Resource{Id: 2, Slug: "new", Status: collection.StatusDraft, Fields: map[string]any{}}, nil)
+
+	// The update and the publication of the existing resource are both recorded
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	report, err := service.ImportResources(context.Background(), articles, rows, 3, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Created != 1 || report.Updated != 1 || report.Failed != 3 {
+		t.Errorf("expected 1 created, 1 updated and 3 failed rows, got %+v", report)
+	}
+
+	expected := []collection.ImportRowReport{
+		{Line: 1, Slug: "existing", Action: collection.ImportUpdated},
+		{Line: 2, Slug: "new", Action: collection.ImportCreated},
+		{Line: 3, Slug: "invalid", Action: collection.ImportFailed, Error: "validation failed",
+			Errors: []collection.FieldError{{Path: "views", Reason: "must be a number"}}},
+		{Line: 4, Action: collection.ImportFailed, Error: "invalid content: slug is required"},
+		{Line: 5, Action: collection.ImportFailed, Error: "invalid content: invalid JSON"},
+	}
+	if !reflect.DeepEqual(report.Rows, expected) {
+		t.Errorf("expected rows %+v, got %+v", expected, report.Rows)
+	}
+
+	// The imported status is published like any other
+	expectedEvents := []collection.EventType{collection.EventResourceUpdated, collection.EventResourcePublished, collection.EventResourceCreated}
+	if !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("expected events %v, got %v", expectedEvents, events)
+	}
+}
+
+func TestService_ImportResources_Global(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	global := collection.NewTestCollection("articles", bulkFields)
+	global.IsGlobal = true
+
+	if _, err := service.ImportResources(context.Background(), global, nil, 1, 0); !errors.Is(err, collection.ErrInvalidContent) {
+		t.Errorf("expected an invalid content error, got %v", err)
+	}
+}
+
+func TestImportResources_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	articles := collection.NewTestCollection("articles", bulkFields)
+	user := createMockUser()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "articles").
+		Return(articles, nil)
+
+	mockService.EXPECT().
+		ImportResources(gomock.Any(), articles, gomock.Any(), user.ID, 50).
+		DoAndReturn(func(ctx context.Context, c *collection.Collection, rows []collection.ImportRow, importedBy int64, batchSize int) (*collection.ImportReport, error) {
+			expected := []collection.ImportRow{
+				{Line: 2, Content: map[string]any{"slug": "hello", "title": "Hello, world", "views": "12"}},
+				{Line: 3, Content: map[string]any{"slug": "empty", "title": nil, "views": nil}},
+			}
+			if !reflect.DeepEqual(rows, expected) {
+				t.Errorf("expected rows %+v, got %+v", expected, rows)
+			}
+			return &collection.ImportReport{Created: 2}, nil
+		})
+
+	body := "slug,title,views\nhello,\"Hello, world\",12\nempty,,\n"
+	req := httptest.NewRequest("POST", "/collections/articles/import?batchSize=50", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	req.SetPathValue("slug", "articles")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.ImportResources), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+
+	var report collection.ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || report.Created != 2 {
+		t.Errorf("expected the report to be returned, got %s", w.Body.String())
+	}
+}
+
+func TestImportResources_Unauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := collection.NewHandler(mocks.NewMockService(ctrl))
+
+	req := httptest.NewRequest("POST", "/collections/articles/import", strings.NewReader("{}"))
+	req.SetPathValue("slug", "articles")
+
+	w := executeRequest(http.HandlerFunc(handler.ImportResources), req, t)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+}
+
+func TestExportResources_NDJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	articles := collection.NewTestCollection("articles", bulkFields)
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "articles").
+		Return(articles, nil)
+
+	mockService.EXPECT().
+		ExportResources(gomock.Any(), articles, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, c *collection.Collection, fn func(collection.Resource) error) error {
+			for i, slug := range []string{"first", "second"} {
+				if err := fn(collection.Resource{Id: int64(i + 1), Slug: slug, Status: collection.StatusDraft, Fields: map[string]any{"title": slug}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	req := httptest.NewRequest("GET", "/collections/articles/export", nil)
+	req.SetPathValue("slug", "articles")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.ExportResources), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("expected NDJSON, got %q", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="articles.ndjson"` {
+		t.Errorf("unexpected content disposition %q", disposition)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", w.Body.String())
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["slug"] != "first" || first["title"] != "first" {
+		t.Errorf("unexpected first line %q", lines[0])
+	}
+}
+
+func TestExportResources_InvalidFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := collection.NewHandler(mocks.NewMockService(ctrl))
+
+	req := httptest.NewRequest("GET", "/collections/articles/export?format=xml", nil)
+	req.SetPathValue("slug", "articles")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.ExportResources), req, t)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+}
+
+func TestService_ExportResources_Pages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+
+	page := make([]collection.Resource, collection.MaxLimit)
+	for i := range page {
+		page[i] = collection.Resource{Id: int64(i + 1), Fields: map[string]any{}}
+	}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			FindResources(gomock.Any(), posts, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, c *collection.Collection, params *collection.FindResourcesParams) ([]collection.Resource, int64, error) {
+				if params.Filters[0].Value != "0" {
+					t.Errorf("expected the first page to start after 0, got %v", params.Filters)
+				}
+				return page, 0, nil
+			}),
+		mockRepo.EXPECT().
+			FindResources(gomock.Any(), posts, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, c *collection.Collection, params *collection.FindResourcesParams) ([]collection.Resource, int64, error) {
+				if expected := fmt.Sprint(collection.MaxLimit); params.Filters[0].Value != expected {
+					t.Errorf("expected the second page to start after %s, got %v", expected, params.Filters)
+				}
+				return []collection.Resource{{Id: 1000, Fields: map[string]any{}}}, 0, nil
+			}),
+	)
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(map[int64][]int64{1000: {4, 5}}, nil).
+		Times(2)
+
+	var exported []collection.Resource
+	err := service.ExportResources(context.Background(), posts, func(resource collection.Resource) error {
+		exported = append(exported, resource)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(exported) != collection.MaxLimit+1 {
+		t.Fatalf("expected %d resources, got %d", collection.MaxLimit+1, len(exported))
+	}
+	if tags := exported[len(exported)-1].Fields["tags"]; !reflect.DeepEqual(tags, []int64{4, 5}) {
+		t.Errorf("expected the tags to be exported, got %v", tags)
+	}
+	if tags := exported[0].Fields["tags"]; !reflect.DeepEqual(tags, []int64{}) {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
@@ -0,0 +1,115 @@
+package collection
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// testBulkFields have a field of each kind of column written by the exports.
+var testBulkFields = mimsy_schema.CollectionFields{
+	"title":   {Type: "string"},
+	"views":   {Type: "number"},
+	"body":    {Type: "rich_text"},
+	"author":  {Type: "relation", RelatesTo: "<builtins.user>"},
+	"tags":    {Type: "multi_relation", RelatesTo: "tags"},
+	"visible": {Type: "checkbox"},
+	"summary": {Type: "long_string", Options: &mimsy_schema.SchemaElementOptions{Localized: true}},
+}
+
+func TestCSVWriter_RoundTrip(t *testing.T) {
+	collection := NewTestCollection("posts", testBulkFields)
+	createdAt := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	var b strings.Builder
+	writer, err := NewResourceWriter(&b, BulkFormatCSV, collection)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.Write(Resource{
+		Id: 4, Slug: "hello", Status: StatusPublished, CreatedAt: createdAt, CreatedBy: 1, UpdatedAt: createdAt, UpdatedBy: 2,
+		Fields: map[string]any{
+			"title":     "Hello, \"world\"",
+			"views":     json.Number("12.5"),
+			"body":      json.RawMessage(`{"type":"doc"}`),
+			"author_id": int64(3),
+			"tags":      []int64{7, 8},
+			"visible":   true,
+			"summary":   map[string]any{"en": "Hi", "fr": "Salut"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "id,slug,status,created_at,created_by,updated_at,updated_by,published_at,publish_at,unpublish_at,author_id,body,summary,tags,title,views,visible\n" +
+		`4,hello,published,2025-03-01T12:30:00Z,1,2025-03-01T12:30:00Z,2,,,,3,"{""type"":""doc""}","{""en"":""Hi"",""fr"":""Salut""}","7,8","Hello, ""world""",12.5,true` + "\n"
+	if b.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	rows, err := ReadImportRows(strings.NewReader(b.String()), BulkFormatCSV, collection)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Err != nil || rows[0].Line != 2 {
+		t.Fatalf("expected one valid row on line 2, got %+v", rows)
+	}
+
+	content := rows[0].Content
+	if content["title"] != "Hello, \"world\"" || content["views"] != "12.5" || content["author_id"] != "3" || content["visible"] != true {
+		t.Errorf("unexpected content %v", content)
+	}
+	if tags := content["tags"]; !reflect.DeepEqual(tags, []any{int64(7), int64(8)}) {
+		t.Errorf("expected tags to be identifiers, got %#v", tags)
+	}
+	if summary := content["summary"]; !reflect.DeepEqual(summary, map[string]any{"en": "Hi", "fr": "Salut"}) {
+		t.Errorf("expected summary to be values by locale, got %#v", summary)
+	}
+	if body := content["body"]; !reflect.DeepEqual(body, map[string]any{"type": "doc"}) {
+		t.Errorf("expected body to be decoded, got %#v", body)
+	}
+}
+
+func TestCSVWriter_EmptyCollection(t *testing.T) {
+	var b strings.Builder
+	writer, _ := NewResourceWriter(&b, BulkFormatCSV, NewTestCollection("posts", mimsy_schema.CollectionFields{"title": {Type: "string"}}))
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := "id,slug,status,created_at,created_by,updated_at,updated_by,published_at,publish_at,unpublish_at,title\n"; b.String() != expected {
+		t.Errorf("expected only the header, got %q", b.String())
+	}
+}
+
+func TestReadImportRows_InvalidRows(t *testing.T) {
+	collection := NewTestCollection("posts", testBulkFields)
+
+	rows, err := ReadImportRows(strings.NewReader("slug,body\nok,\nshort\nbad,{not json\n"), BulkFormatCSV, collection)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 || rows[0].Err != nil || rows[1].Err == nil || rows[2].Err == nil {
+		t.Errorf("expected the last two rows to be invalid, got %+v", rows)
+	}
+	if rows[2].Line != 4 {
+		t.Errorf("expected the invalid JSON on line 4, got %d", rows[2].Line)
+	}
+
+	rows, err = ReadImportRows(strings.NewReader("{\"slug\":\"a\",\"views\":1.5}\n\n[1]\nnot json\n"), BulkFormatNDJSON, collection)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 || rows[0].Content["views"] != json.Number("1.5") || rows[1].Err == nil || rows[2].Line != 4 || rows[2].Err == nil {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
@@ -0,0 +1,361 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// Codec converts the values of a field between their JSON and Postgres representations.
+// Nil values are passed through by every codec.
+type Codec interface {
+	// Decode converts a value scanned from Postgres to the value returned to clients.
+	Decode(value any) (any, error)
+	// Encode converts a value decoded from a JSON payload to a Postgres parameter.
+	Encode(value any) (any, error)
+}
+
+// CodecFor returns the codec of a field type, see pkg/schema_generator for the matching column types.
+func CodecFor(fieldType string) Codec {
+	switch fieldType {
+	case "string", "long_string", "email", "select":
+		return textCodec{}
+	case "number":
+		return numberCodec{}
+	case "checkbox":
+		return booleanCodec{}
+	case "date_time", "created_at":
+		return timeCodec{}
+	case "rich_text":
+		return jsonCodec{}
+	case "relation":
+		return referenceCodec{}
+	default:
+		return rawCodec{}
+	}
+}
+
+// elementCodec returns the codec of a field, localized fields are stored as jsonb objects indexed by locale.
+// Multiple select fields are stored in text[] columns.
+func elementCodec(element mimsy_schema.SchemaElement) Codec {
+	codec := CodecFor(element.Type)
+	if element.Type == "select" && element.IsMultiple() {
+		codec = textListCodec{}
+	}
+
+	if element.IsLocalized() {
+		return localizedCodec{values: codec}
+	}
+	return codec
+}
+
+// columnCodecs returns the codec of each column storing the fields of a collection.
+// Many-to-one relations are stored in their `<field>_id` column, many-to-many relations and blocks have no column.
+func columnCodecs(fields mimsy_schema.CollectionFields) map[string]Codec {
+	codecs := make(map[string]Codec, len(fields))
+	for name, element := range fields {
+		switch element.Type {
+		case "relation":
+			codecs[fmt.Sprintf("%s_id", name)] = CodecFor(element.Type)
+		case "multi_relation", "blocks":
+			continue
+		default:
+			codecs[name] = elementCodec(element)
+		}
+	}
+	return codecs
+}
+
+// textCodec handles varchar columns.
+type textCodec struct{}
+
+func (textCodec) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return nil, fmt.Errorf("unexpected text value of type %T", value)
+	}
+}
+
+func (textCodec) Encode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: expected a string, got %T", ErrInvalidContent, value)
+	}
+}
+
+// textListCodec handles text[] columns, exchanged as lists of strings.
+type textListCodec struct{}
+
+func (textListCodec) Decode(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var values pq.StringArray
+	if err := values.Scan(value); err != nil {
+		return nil, fmt.Errorf("unexpected text list value: %w", err)
+	}
+
+	decoded := make([]any, len(values))
+	for i, v := range values {
+		decoded[i] = v
+	}
+	return decoded, nil
+}
+
+func (textListCodec) Encode(value any) (any, error) {
+	var values pq.StringArray
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		values = v
+	case []any:
+		values = make(pq.StringArray, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: expected a list of strings, got %T at index %d", ErrInvalidContent, item, i)
+			}
+			values[i] = s
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected a list of strings, got %T", ErrInvalidContent, value)
+	}
+	return values, nil
+}
+
+// numberCodec handles numeric columns, which lib/pq scans as text to keep their precision.
+// They are returned as json.Number so that they are written as JSON numbers without rounding.
+type numberCodec struct{}
+
+func (numberCodec) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return json.Number(v), nil
+	case string:
+		return json.Number(v), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	default:
+		return nil, fmt.Errorf("unexpected numeric value of type %T", value)
+	}
+}
+
+func (numberCodec) Encode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("%w: %v is not a valid number", ErrInvalidContent, v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		if _, err := v.Float64(); err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid number", ErrInvalidContent, v)
+		}
+		return v.String(), nil
+	case string:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid number", ErrInvalidContent, v)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: expected a number, got %T", ErrInvalidContent, value)
+	}
+}
+
+// booleanCodec handles boolean columns.
+type booleanCodec struct{}
+
+func (booleanCodec) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, bool:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unexpected boolean value of type %T", value)
+	}
+}
+
+func (booleanCodec) Encode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, bool:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: expected a boolean, got %T", ErrInvalidContent, value)
+	}
+}
+
+// timeCodec handles timestamptz columns, exchanged as RFC3339 strings.
+type timeCodec struct{}
+
+func (timeCodec) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return v.UTC(), nil
+	default:
+		return nil, fmt.Errorf("unexpected timestamp value of type %T", value)
+	}
+}
+
+func (timeCodec) Encode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a RFC3339 date", ErrInvalidContent, v)
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("%w: expected a RFC3339 date, got %T", ErrInvalidContent, value)
+	}
+}
+
+// jsonCodec handles jsonb columns, such as the rich text documents.
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(value any) (any, error) {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return nil, fmt.Errorf("unexpected json value of type %T", value)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode json value: %w", err)
+	}
+	return decoded, nil
+}
+
+func (jsonCodec) Encode(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode json value: %v", ErrInvalidContent, err)
+	}
+	return string(encoded), nil
+}
+
+// referenceCodec handles the bigint columns referencing another resource.
+type referenceCodec struct{}
+
+func (referenceCodec) Decode(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, int64:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unexpected reference value of type %T", value)
+	}
+}
+
+func (referenceCodec) Encode(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	id, ok := toId(value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v is not a valid identifier", ErrInvalidContent, value)
+	}
+	return id, nil
+}
+
+// localizedCodec handles the jsonb columns of localized fields, holding an object of values indexed by locale.
+// The values are checked by the codec of the field type, but stored as given so that they remain JSON values.
+type localizedCodec struct {
+	values Codec
+}
+
+func (c localizedCodec) Decode(value any) (any, error) {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return nil, fmt.Errorf("unexpected localized value of type %T", value)
+	}
+
+	// Numbers are kept as json.Number, as they are returned by the number codec
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var decoded map[string]any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode localized value: %w", err)
+	}
+	return decoded, nil
+}
+
+func (c localizedCodec) Encode(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	values, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected an object of values by locale, got %T", ErrInvalidContent, value)
+	}
+
+	for locale, v := range values {
+		if _, err := c.values.Encode(v); err != nil {
+			return nil, fmt.Errorf("locale %q: %w", locale, err)
+		}
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode localized value: %v", ErrInvalidContent, err)
+	}
+	return string(encoded), nil
+}
+
+// rawCodec passes the values of unknown field types through, only converting byte slices to strings.
+type rawCodec struct{}
+
+func (rawCodec) Decode(value any) (any, error) {
+	if v, ok := value.([]byte); ok {
+		return string(v), nil
+	}
+	return value, nil
+}
+
+func (rawCodec) Encode(value any) (any, error) {
+	return value, nil
+}
@@ -0,0 +1,155 @@
+package collection
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func TestCodec_Decode(t *testing.T) {
+	date := time.Date(2024, 1, 2, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		fieldType string
+		value     any
+		expected  any
+	}{
+		{fieldType: "string", value: []byte("hello"), expected: "hello"},
+		{fieldType: "string", value: `{"not":"parsed"}`, expected: `{"not":"parsed"}`},
+		{fieldType: "number", value: []byte("12.50"), expected: json.Number("12.50")},
+		{fieldType: "checkbox", value: true, expected: true},
+		{fieldType: "date_time", value: date, expected: date.UTC()},
+		{fieldType: "rich_text", value: []byte(`{"type":"doc"}`), expected: map[string]any{"type": "doc"}},
+		{fieldType: "relation", value: int64(3), expected: int64(3)},
+		{fieldType: "number", value: nil, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fieldType, func(t *testing.T) {
+			decoded, err := CodecFor(tt.fieldType).Decode(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestCodec_Encode(t *testing.T) {
+	tests := []struct {
+		fieldType string
+		value     any
+		expected  any
+	}{
+		{fieldType: "string", value: "hello", expected: "hello"},
+		{fieldType: "number", value: float64(12.5), expected: "12.5"},
+		{fieldType: "number", value: "7", expected: "7"},
+		{fieldType: "checkbox", value: false, expected: false},
+		{fieldType: "date_time", value: "2024-01-02T10:00:00Z", expected: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{fieldType: "rich_text", value: map[string]any{"type": "doc"}, expected: `{"type":"doc"}`},
+		{fieldType: "relation", value: float64(3), expected: int64(3)},
+		{fieldType: "email", value: nil, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fieldType, func(t *testing.T) {
+			encoded, err := CodecFor(tt.fieldType).Encode(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, encoded)
+			}
+		})
+	}
+}
+
+func TestCodec_EncodeInvalid(t *testing.T) {
+	tests := []struct {
+		fieldType string
+		value     any
+	}{
+		{fieldType: "string", value: float64(1)},
+		{fieldType: "number", value: "many"},
+		{fieldType: "checkbox", value: "true"},
+		{fieldType: "date_time", value: "2024-01-02"},
+		{fieldType: "relation", value: 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fieldType, func(t *testing.T) {
+			if _, err := CodecFor(tt.fieldType).Encode(tt.value); !errors.Is(err, ErrInvalidContent) {
+				t.Errorf("expected ErrInvalidContent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTextListCodec(t *testing.T) {
+	codec := elementCodec(mimsy_schema.SchemaElement{
+		Type:    "select",
+		Options: &mimsy_schema.SchemaElementOptions{Values: []string{"a", "b,c"}, Multiple: true},
+	})
+
+	encoded, err := codec.Encode([]any{"a", "b,c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	value, err := encoded.(driver.Valuer).Value()
+	if err != nil || value != `{"a","b,c"}` {
+		t.Errorf("expected the values to be stored as an array, got %#v, %v", value, err)
+	}
+
+	decoded, err := codec.Decode([]byte(`{a,"b,c"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []any{"a", "b,c"}; !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("expected %#v, got %#v", expected, decoded)
+	}
+
+	if _, err := codec.Encode([]any{"a", float64(1)}); !errors.Is(err, ErrInvalidContent) {
+		t.Errorf("expected ErrInvalidContent, got %v", err)
+	}
+	if _, err := codec.Encode("a"); !errors.Is(err, ErrInvalidContent) {
+		t.Errorf("expected ErrInvalidContent, got %v", err)
+	}
+}
+
+func TestLocalizedCodec(t *testing.T) {
+	codec := elementCodec(mimsy_schema.SchemaElement{
+		Type:    "number",
+		Options: &mimsy_schema.SchemaElementOptions{Localized: true},
+	})
+
+	encoded, err := codec.Encode(map[string]any{"en": float64(1), "fr": float64(2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if encoded != `{"en":1,"fr":2}` {
+		t.Errorf("expected the values to be stored as JSON, got %#v", encoded)
+	}
+
+	decoded, err := codec.Decode([]byte(encoded.(string)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]any{"en": json.Number("1"), "fr": json.Number("2")}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("expected %#v, got %#v", expected, decoded)
+	}
+
+	if _, err := codec.Encode(float64(1)); err == nil {
+		t.Error("expected an error for a value that is not indexed by locale")
+	}
+	if _, err := codec.Encode(map[string]any{"en": "one"}); err == nil {
+		t.Error("expected an error for an invalid value in a locale")
+	}
+}
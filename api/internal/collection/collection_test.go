@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -70,6 +71,7 @@ func createMockResource() *collection.Resource {
 		CreatedBy:  1,
 		UpdatedAt:  time.Now(),
 		UpdatedBy:  1,
+		Status:     collection.StatusPublished,
 		Collection: "test-collection",
 		Fields: map[string]any{
 			"title":   "Test Title",
@@ -91,6 +93,39 @@ func addUserToContext(req *http.Request, user *auth.User) *http.Request {
 	return req.WithContext(ctx)
 }
 
+// The fields of the collections shared by the tests, see collection.NewTestCollection.
+var (
+	// postsFields have a relation to each kind of target.
+	postsFields = mimsy_schema.CollectionFields{
+		"title":  {Type: "string"},
+		"author": {Type: "relation", RelatesTo: "authors"},
+		"tags":   {Type: "multi_relation", RelatesTo: "tags"},
+		"cover":  {Type: "relation", RelatesTo: "<builtins.media>"},
+	}
+	// articlesFields have a field of each validated type.
+	articlesFields = mimsy_schema.CollectionFields{
+		"title": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{
+			Constraints: &mimsy_schema.SchemaElementConstraints{Required: true, MinLength: 3, MaxLength: 10},
+		}},
+		"contact":   {Type: "email"},
+		"views":     {Type: "number"},
+		"published": {Type: "checkbox"},
+		"date":      {Type: "date_time"},
+		"author":    {Type: "relation", RelatesTo: "authors"},
+		"tags":      {Type: "multi_relation", RelatesTo: "tags"},
+	}
+	// bulkFields are the fields of the imported and batched resources.
+	bulkFields = mimsy_schema.CollectionFields{
+		"title": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{
+			Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+		}},
+		"views": {Type: "number"},
+	}
+	settingsFields = mimsy_schema.CollectionFields{
+		"title": {Type: "string"},
+	}
+)
+
 // =================================================================================================
 // Handler Tests - Definition
 // =================================================================================================
@@ -188,8 +223,8 @@ func TestGetResources_Success(t *testing.T) {
 		Return(mockCollection, nil)
 
 	mockService.EXPECT().
-		FindResources(gomock.Any(), mockCollection).
-		Return(mockResources, nil)
+		FindResources(gomock.Any(), mockCollection, &collection.FindResourcesParams{PublishedOnly: true}).
+		Return(mockResources, int64(1), nil)
 
 	req := httptest.NewRequest("GET", "/collections/test-collection/resources", nil)
 	req.SetPathValue("slug", "test-collection")
@@ -200,6 +235,10 @@ func TestGetResources_Success(t *testing.T) {
 		t.Fatalf("expected status OK, got %v", w.Code)
 	}
 
+	if total := w.Header().Get("X-Total-Count"); total != "1" {
+		t.Errorf("expected X-Total-Count '1', got %q", total)
+	}
+
 	var response []collection.Resource
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
@@ -245,8 +284,8 @@ func TestGetResources_FindResourcesError(t *testing.T) {
 		Return(mockCollection, nil)
 
 	mockService.EXPECT().
-		FindResources(gomock.Any(), mockCollection).
-		Return(nil, errors.New("database error"))
+		FindResources(gomock.Any(), mockCollection, gomock.Any()).
+		Return(nil, int64(0), errors.New("database error"))
 
 	req := httptest.NewRequest("GET", "/collections/test-collection/resources", nil)
 	req.SetPathValue("slug", "test-collection")
@@ -258,6 +297,91 @@ func TestGetResources_FindResourcesError(t *testing.T) {
 	}
 }
 
+func TestGetResources_WithQueryParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+
+	expectedParams := &collection.FindResourcesParams{
+		Filters: []collection.Filter{
+			{Field: "title", Operator: collection.OperatorContains, Value: "foo"},
+		},
+		Sort:          []collection.Sort{{Field: "created_at", Descending: true}},
+		Limit:         20,
+		Offset:        40,
+		PublishedOnly: true,
+	}
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResources(gomock.Any(), mockCollection, expectedParams).
+		Return([]collection.Resource{}, int64(42), nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection?where[title][contains]=foo&sort=-created_at&limit=20&offset=40", nil)
+	req.SetPathValue("slug", "test-collection")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResources), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	if total := w.Header().Get("X-Total-Count"); total != "42" {
+		t.Errorf("expected X-Total-Count '42', got %q", total)
+	}
+}
+
+func TestGetResources_InvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection?limit=1000", nil)
+	req.SetPathValue("slug", "test-collection")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResources), req, t)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status Bad Request, got %v", w.Code)
+	}
+}
+
+func TestGetResources_InvalidFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResources(gomock.Any(), mockCollection, gomock.Any()).
+		Return(nil, int64(0), fmt.Errorf("%w: unknown field %q", collection.ErrInvalidQuery, "unknown"))
+
+	req := httptest.NewRequest("GET", "/collections/test-collection?where[unknown]=foo", nil)
+	req.SetPathValue("slug", "test-collection")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResources), req, t)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status Bad Request, got %v", w.Code)
+	}
+}
+
 // =================================================================================================
 // Handler Tests - GetResource
 // =================================================================================================
@@ -496,7 +620,7 @@ func TestDeleteResource_Success(t *testing.T) {
 		Return(mockResource, nil)
 
 	mockService.EXPECT().
-		DeleteResource(gomock.Any(), mockResource).
+		DeleteResource(gomock.Any(), mockCollection, mockResource, user.ID).
 		Return(nil)
 
 	req := httptest.NewRequest("DELETE", "/collections/test-collection/resources/test-resource", nil)
@@ -565,7 +689,7 @@ func TestDeleteResource_DeleteError(t *testing.T) {
 		Return(mockResource, nil)
 
 	mockService.EXPECT().
-		DeleteResource(gomock.Any(), mockResource).
+		DeleteResource(gomock.Any(), mockCollection, mockResource, user.ID).
 		Return(errors.New("database error"))
 
 	req := httptest.NewRequest("DELETE", "/collections/test-collection/resources/test-resource", nil)
@@ -755,9 +879,10 @@ func TestService_FindResource(t *testing.T) {
 func TestResource_MarshalJSON(t *testing.T) {
 	resource := createMockResource()
 
-	// Test with byte slice field (simulating JSON data from DB)
-	jsonData := `{"nested":"value"}`
-	resource.Fields["json_field"] = []byte(jsonData)
+	// Decoded values are written as is, strings that look like JSON are not parsed
+	resource.Fields["json_field"] = map[string]any{"nested": "value"}
+	resource.Fields["text_field"] = `{"nested":"value"}`
+	resource.Fields["number_field"] = json.Number("12.50")
 
 	data, err := json.Marshal(resource)
 	if err != nil {
@@ -769,15 +894,319 @@ func TestResource_MarshalJSON(t *testing.T) {
 		t.Fatalf("failed to unmarshal result: %v", err)
 	}
 
-	// Check that byte slice was converted to JSON object
 	if jsonField, ok := result["json_field"].(map[string]interface{}); !ok {
 		t.Errorf("expected json_field to be object, got %T", result["json_field"])
 	} else if jsonField["nested"] != "value" {
 		t.Errorf("expected nested value 'value', got %v", jsonField["nested"])
 	}
 
+	if result["text_field"] != `{"nested":"value"}` {
+		t.Errorf("expected text_field to be kept as a string, got %v", result["text_field"])
+	}
+
+	if result["number_field"] != 12.5 {
+		t.Errorf("expected number_field to be a number, got %v", result["number_field"])
+	}
+
 	// Check other fields are preserved
 	if result["slug"] != "test-resource" {
 		t.Errorf("expected slug 'test-resource', got %v", result["slug"])
 	}
 }
+
+// =================================================================================================
+// Handler Tests - Drafts
+// =================================================================================================
+
+func TestGetResource_DraftHiddenFromAnonymous(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+	mockResource.Status = collection.StatusDraft
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResource(gomock.Any(), mockCollection, "test-resource").
+		Return(mockResource, nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResource), req, t)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status Not Found, got %v", w.Code)
+	}
+}
+
+func TestGetResource_DraftWithAuthentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+	mockResource.Status = collection.StatusDraft
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResource(gomock.Any(), mockCollection, "test-resource").
+		Return(mockResource, nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource?draft=true", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.GetResource), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status OK, got %v", w.Code)
+	}
+}
+
+func TestGetResources_DraftRequiresAuthentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := collection.NewHandler(mocks.NewMockService(ctrl))
+
+	req := httptest.NewRequest("GET", "/collections/test-collection?draft=true", nil)
+	req.SetPathValue("slug", "test-collection")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResources), req, t)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+}
+
+func TestPublishResource_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	user := createMockUser()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		PublishResource(gomock.Any(), mockCollection, "test-resource", user.ID).
+		Return(createMockResource(), nil)
+
+	req := httptest.NewRequest("POST", "/collections/test-collection/test-resource/publish", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.PublishResource), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if response["status"] != "published" {
+		t.Errorf("expected status 'published', got %v", response["status"])
+	}
+}
+
+func TestUnpublishResource_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	user := createMockUser()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		UnpublishResource(gomock.Any(), mockCollection, "nonexistent", user.ID).
+		Return(nil, collection.ErrNotFound)
+
+	req := httptest.NewRequest("POST", "/collections/test-collection/nonexistent/unpublish", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "nonexistent")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.UnpublishResource), req, t)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status Not Found, got %v", w.Code)
+	}
+}
+
+// =================================================================================================
+// Globals Tests
+// =================================================================================================
+
+func TestGetGlobal_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	settings := collection.NewTestGlobal("settings", settingsFields)
+	resource := &collection.Resource{Id: 1, Slug: "settings", Status: collection.StatusPublished, Fields: map[string]any{"title": "Mimsy"}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "settings").Return(settings, nil)
+	mockService.EXPECT().FindResource(gomock.Any(), settings, "settings").Return(resource, nil)
+
+	req := httptest.NewRequest("GET", "/globals/settings", nil)
+	req.SetPathValue("slug", "settings")
+
+	w := executeRequest(http.HandlerFunc(handler.GetGlobal), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+	if w.Header().Get("ETag") != resource.ETag() {
+		t.Errorf("expected ETag %s, got %s", resource.ETag(), w.Header().Get("ETag"))
+	}
+}
+
+func TestGetGlobal_NotGlobal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(collection.NewTestCollection("posts", postsFields), nil)
+
+	req := httptest.NewRequest("GET", "/globals/posts", nil)
+	req.SetPathValue("slug", "posts")
+
+	w := executeRequest(http.HandlerFunc(handler.GetGlobal), req, t)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status Not Found, got %v", w.Code)
+	}
+}
+
+func TestUpdateGlobal_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	settings := collection.NewTestGlobal("settings", settingsFields)
+	user := createMockUser()
+	content := map[string]any{"title": "Mimsy"}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "settings").Return(settings, nil)
+	mockService.EXPECT().
+		UpdateResource(gomock.Any(), settings, "settings", user.ID, content).
+		Return(&collection.Resource{Id: 1, Slug: "settings", Fields: content}, nil)
+
+	req := newJSONRequest(t, "PUT", "/globals/settings", `{"title":"Mimsy"}`)
+	req.SetPathValue("slug", "settings")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.UpdateGlobal), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+}
+
+func TestUpdateGlobal_CreatesMissingResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	settings := collection.NewTestGlobal("settings", settingsFields)
+	user := createMockUser()
+	content := map[string]any{"title": "Mimsy"}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "settings").Return(settings, nil)
+	mockService.EXPECT().
+		UpdateResource(gomock.Any(), settings, "settings", user.ID, content).
+		Return(nil, collection.ErrNotFound)
+	mockService.EXPECT().
+		CreateResource(gomock.Any(), settings, "settings", user.ID, content).
+		Return(&collection.Resource{Id: 1, Slug: "settings", Fields: content}, nil)
+
+	req := newJSONRequest(t, "PUT", "/globals/settings", `{"title":"Mimsy"}`)
+	req.SetPathValue("slug", "settings")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.UpdateGlobal), req, t)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status Created, got %v", w.Code)
+	}
+}
+
+func TestUpdateGlobal_Unauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := collection.NewHandler(mocks.NewMockService(ctrl))
+
+	req := newJSONRequest(t, "PUT", "/globals/settings", `{}`)
+	req.SetPathValue("slug", "settings")
+
+	w := executeRequest(http.HandlerFunc(handler.UpdateGlobal), req, t)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+}
+
+func TestService_CreateResource_Global(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No repository call is expected, the extra resource is rejected upfront
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	_, err := service.CreateResource(context.Background(), collection.NewTestGlobal("settings", settingsFields), "other", 1, map[string]any{})
+	if !errors.Is(err, collection.ErrInvalidContent) {
+		t.Errorf("expected an invalid content error, got %v", err)
+	}
+}
+
+func TestService_DeleteResource_Global(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	err := service.DeleteResource(context.Background(), collection.NewTestGlobal("settings", settingsFields), &collection.Resource{Id: 1, Slug: "settings"}, 1)
+	if !errors.Is(err, collection.ErrInvalidContent) {
+		t.Errorf("expected an invalid content error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ErrPreconditionFailed is returned when a resource was changed since the version expected by the client.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ETag returns the version of a resource, derived from the time of its last change.
+// The timestamp is stored with a microsecond precision, so the version is the number of microseconds since the epoch.
+func (r *Resource) ETag() string {
+	return strconv.Quote(strconv.FormatInt(r.UpdatedAt.UnixMicro(), 10))
+}
+
+// Precondition restricts a change to the versions of a resource listed in an `If-Match` header.
+type Precondition struct {
+	// Any matches every version of an existing resource, for `If-Match: *`.
+	Any bool
+	// Versions are the update timestamps of the expected versions.
+	Versions []time.Time
+}
+
+// ParseIfMatch parses the value of an `If-Match` header, it returns nil when the header is empty.
+// Weak and unknown entity tags are ignored, so that they never match as required for `If-Match`.
+func ParseIfMatch(header string) *Precondition {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+
+	if header == "*" {
+		return &Precondition{Any: true}
+	}
+
+	precondition := &Precondition{Versions: []time.Time{}}
+	for _, tag := range strings.Split(header, ",") {
+		value, err := strconv.Unquote(strings.TrimSpace(tag))
+		if err != nil {
+			continue
+		}
+
+		micros, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		precondition.Versions = append(precondition.Versions, time.UnixMicro(micros).UTC())
+	}
+
+	return precondition
+}
+
+type preconditionKey struct{}
+
+// ContextWithPrecondition returns a context in which resources are only updated or deleted
+// when their current version matches the precondition.
+func ContextWithPrecondition(ctx context.Context, precondition *Precondition) context.Context {
+	if precondition == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, preconditionKey{}, precondition)
+}
+
+func preconditionFromContext(ctx context.Context) *Precondition {
+	precondition, _ := ctx.Value(preconditionKey{}).(*Precondition)
+	return precondition
+}
+
+// where returns the condition on the `updated_at` column, nil when every version matches.
+// An empty list of versions generates a condition that is always false.
+func (p *Precondition) where() sq.Sqlizer {
+	if p.Any {
+		return nil
+	}
+	return sq.Eq{"updated_at": p.Versions}
+}
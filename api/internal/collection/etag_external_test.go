@@ -0,0 +1,126 @@
+package collection_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// ETag Tests
+// =================================================================================================
+
+func TestGetResource_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResource(gomock.Any(), mockCollection, "test-resource").
+		Return(mockResource, nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResource), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	if etag := w.Header().Get("ETag"); etag != mockResource.ETag() {
+		t.Errorf("expected ETag %s, got %q", mockResource.ETag(), etag)
+	}
+}
+
+func TestUpdateResource_StaleVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	user := createMockUser()
+	mockCollection := createMockCollection()
+	currentResource := createMockResource()
+	staleResource := createMockResource()
+	staleResource.UpdatedAt = currentResource.UpdatedAt.Add(-time.Minute)
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		UpdateResource(gomock.Any(), mockCollection, "test-resource", user.ID, gomock.Any()).
+		Return(nil, collection.ErrPreconditionFailed)
+
+	mockService.EXPECT().
+		FindResource(gomock.Any(), mockCollection, "test-resource").
+		Return(currentResource, nil)
+
+	req := newJSONRequest(t, "PUT", "/collections/test-collection/test-resource", `{"title": "Stale"}`)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req.Header.Set("If-Match", staleResource.ETag())
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.UpdateResource), req, t)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status Precondition Failed, got %v", w.Code)
+	}
+
+	if etag := w.Header().Get("ETag"); etag != currentResource.ETag() {
+		t.Errorf("expected the current ETag %s, got %q", currentResource.ETag(), etag)
+	}
+}
+
+func TestUpdateResource_WithoutIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	user := createMockUser()
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		UpdateResource(gomock.Any(), mockCollection, "test-resource", user.ID, gomock.Any()).
+		Return(mockResource, nil)
+
+	req := newJSONRequest(t, "PUT", "/collections/test-collection/test-resource", `{"title": "Updated"}`)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.UpdateResource), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	if etag := w.Header().Get("ETag"); etag != mockResource.ETag() {
+		t.Errorf("expected ETag %s, got %q", mockResource.ETag(), etag)
+	}
+}
@@ -0,0 +1,58 @@
+package collection
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+	resource := &Resource{UpdatedAt: updatedAt}
+
+	if precondition := ParseIfMatch(""); precondition != nil {
+		t.Errorf("expected no precondition for an empty header, got %+v", precondition)
+	}
+
+	if precondition := ParseIfMatch("*"); precondition == nil || !precondition.Any {
+		t.Errorf("expected a wildcard precondition, got %+v", precondition)
+	}
+
+	precondition := ParseIfMatch(`W/"1", ` + resource.ETag() + `, "unknown"`)
+	if precondition == nil || precondition.Any {
+		t.Fatalf("expected a precondition on versions, got %+v", precondition)
+	}
+	if len(precondition.Versions) != 1 || !precondition.Versions[0].Equal(updatedAt) {
+		t.Errorf("expected the version %v, got %v", updatedAt, precondition.Versions)
+	}
+}
+
+func TestDeleteResource_StaleVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	updatedAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	resource := &Resource{Slug: "hello", Collection: "posts", UpdatedAt: updatedAt}
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	ctx = ContextWithPrecondition(ctx, ParseIfMatch(resource.ETag()))
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "posts" SET deleted_at = NOW() WHERE deleted_at IS NULL AND slug = $1 AND updated_at IN ($2)`)).
+		WithArgs("hello", updatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := NewRepository().DeleteResource(ctx, resource); err != ErrPreconditionFailed {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
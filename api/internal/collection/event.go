@@ -0,0 +1,46 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+)
+
+// EventType is the kind of change of a resource notified to the event hooks.
+type EventType string
+
+const (
+	EventResourceCreated   EventType = "resource.created"
+	EventResourceUpdated   EventType = "resource.updated"
+	EventResourceDeleted   EventType = "resource.deleted"
+	EventResourcePublished EventType = "resource.published"
+)
+
+// Event is a change of a resource.
+type Event struct {
+	Type       EventType `json:"type"`
+	Collection string    `json:"collection"`
+	// Resource is the resource after the change, or before it for a deletion.
+	Resource *Resource `json:"resource"`
+}
+
+// EventHook is notified of the changes of the resources within their transaction,
+// the change is rolled back when the hook fails.
+type EventHook func(ctx context.Context, event Event) error
+
+// WithEventHook adds a hook notified of the changes of the resources.
+func WithEventHook(hook EventHook) ServiceOption {
+	return func(s *service) {
+		s.eventHooks = append(s.eventHooks, hook)
+	}
+}
+
+// emit notifies the event hooks of a change, it must be called in the transaction of the change.
+func (s *service) emit(ctx context.Context, eventType EventType, collection *Collection, resource *Resource) error {
+	event := Event{Type: eventType, Collection: collection.Slug, Resource: resource}
+	for _, hook := range s.eventHooks {
+		if err := hook(ctx, event); err != nil {
+			return fmt.Errorf("failed to notify %s event: %w", eventType, err)
+		}
+	}
+	return nil
+}
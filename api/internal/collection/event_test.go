@@ -0,0 +1,96 @@
+package collection_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Event Tests
+// =================================================================================================
+
+func TestService_PublishResource_EmitsEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var events []collection.Event
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo, collection.WithEventHook(func(ctx context.Context, event collection.Event) error {
+		events = append(events, event)
+		return nil
+	}))
+	articles := collection.NewTestCollection("articles", articlesFields)
+
+	published := &collection.Resource{Id: 1, Slug: "article", Status: collection.StatusPublished, Fields: map[string]any{}}
+	mockRepo.EXPECT().
+		UpdateResourceStatus(gomock.Any(), articles, "article", int64(1), collection.StatusPublished).
+		Return(published, nil)
+	mockRepo.EXPECT().
+		UpdateResourceStatus(gomock.Any(), articles, "article", int64(1), collection.StatusDraft).
+		Return(&collection.Resource{Id: 1, Slug: "article", Status: collection.StatusDraft, Fields: map[string]any{}}, nil)
+
+	mockRepo.EXPECT().FindRelationIds(gomock.Any(), gomock.Any(), gomock.Any()).Return(map[int64][]int64{}, nil).Times(2)
+
+	var actions []collection.RevisionAction
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, revision *collection.Revision) error {
+			actions = append(actions, revision.Action)
+			return nil
+		}).
+		Times(2)
+
+	if _, err := service.PublishResource(context.Background(), articles, "article", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UnpublishResource(context.Background(), articles, "article", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != collection.EventResourcePublished || events[0].Collection != articles.Slug || events[0].Resource != published {
+		t.Errorf("unexpected publish event %+v", events[0])
+	}
+	// Unpublishing is notified as an update
+	if events[1].Type != collection.EventResourceUpdated {
+		t.Errorf("expected an update event, got %s", events[1].Type)
+	}
+
+	// The changes of status are recorded like the scheduled ones
+	if !slices.Equal(actions, []collection.RevisionAction{collection.RevisionPublish, collection.RevisionUnpublish}) {
+		t.Errorf("expected a publish then an unpublish revision, got %v", actions)
+	}
+}
+
+func TestService_CreateResource_HookFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hookErr := errors.New("queue unavailable")
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo, collection.WithEventHook(func(ctx context.Context, event collection.Event) error {
+		return hookErr
+	}))
+	articles := collection.NewTestCollection("articles", articlesFields)
+
+	content := map[string]any{"slug": "article", "title": "Hello"}
+	mockRepo.EXPECT().
+		CreateResource(gomock.Any(), articles, "article", int64(1), content).
+		Return(createMockResource(), nil)
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return(map[int64][]int64{}, nil)
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil)
+
+	if _, err := service.CreateResource(context.Background(), articles, "article", 1, content); !errors.Is(err, hookErr) {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+}
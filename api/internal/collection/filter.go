@@ -0,0 +1,352 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// ErrInvalidQuery is returned when the filters, sorting or pagination requested
+// on a collection do not match its definition.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Operator is a comparison operator that can be used to filter resources.
+type Operator string
+
+const (
+	OperatorEquals    Operator = "equals"
+	OperatorNotEquals Operator = "not_equals"
+	OperatorContains  Operator = "contains"
+	OperatorIn        Operator = "in"
+	OperatorGt        Operator = "gt"
+	OperatorGte       Operator = "gte"
+	OperatorLt        Operator = "lt"
+	OperatorLte       Operator = "lte"
+	OperatorExists    Operator = "exists"
+)
+
+const (
+	// MaxLimit is the maximum number of resources that can be requested in a single page.
+	MaxLimit = 100
+)
+
+// Filter is a single condition applied on a field of a collection.
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    string
+}
+
+// Sort orders the resources of a collection by one of its fields.
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// FindResourcesParams are the parameters used to list the resources of a collection.
+type FindResourcesParams struct {
+	Filters []Filter
+	Sort    []Sort
+	// Limit is the maximum number of resources to return, 0 means no limit.
+	Limit  uint64
+	Offset uint64
+	// PublishedOnly excludes the draft resources.
+	PublishedOnly bool
+	// Trashed selects the resources in the trash instead of the other ones.
+	Trashed bool
+
+	// ids restricts the resources to the given identifiers.
+	ids []int64
+}
+
+var wherePattern = regexp.MustCompile(`^where\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// ParseFindResourcesParams parses the query string of a request listing resources.
+//
+// Filters are given as `where[field][operator]=value` (or `where[field]=value` for equality),
+// sorting as a comma separated list of fields, optionally prefixed by `-` for a descending
+// order (`sort=-created_at,title`), and pagination with `limit` and `offset`.
+//
+// The fields and operators are only checked against the collection definition when the
+// query is built.
+func ParseFindResourcesParams(values url.Values) (*FindResourcesParams, error) {
+	params := &FindResourcesParams{}
+
+	for key, vals := range values {
+		matches := wherePattern.FindStringSubmatch(key)
+		if matches == nil {
+			continue
+		}
+
+		operator := Operator(matches[2])
+		if operator == "" {
+			operator = OperatorEquals
+		}
+
+		for _, value := range vals {
+			params.Filters = append(params.Filters, Filter{
+				Field:    matches[1],
+				Operator: operator,
+				Value:    value,
+			})
+		}
+	}
+
+	// Map iteration order is random, keep the generated queries stable.
+	slices.SortStableFunc(params.Filters, func(a, b Filter) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			descending := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return nil, fmt.Errorf("%w: empty sort field", ErrInvalidQuery)
+			}
+			params.Sort = append(params.Sort, Sort{Field: field, Descending: descending})
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil || value == 0 || value > MaxLimit {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+		}
+		params.Limit = value
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		value, err := strconv.ParseUint(offset, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: offset must be a positive integer", ErrInvalidQuery)
+		}
+		params.Offset = value
+	}
+
+	return params, nil
+}
+
+// fieldKind groups field types that support the same operators.
+type fieldKind int
+
+const (
+	kindUnsupported fieldKind = iota
+	kindText
+	kindNumber
+	kindDate
+	kindBoolean
+	kindReference
+)
+
+var operatorsByKind = map[fieldKind][]Operator{
+	kindText:      {OperatorEquals, OperatorNotEquals, OperatorContains, OperatorIn, OperatorExists},
+	kindNumber:    {OperatorEquals, OperatorNotEquals, OperatorGt, OperatorGte, OperatorLt, OperatorLte, OperatorIn, OperatorExists},
+	kindDate:      {OperatorEquals, OperatorNotEquals, OperatorGt, OperatorGte, OperatorLt, OperatorLte, OperatorExists},
+	kindBoolean:   {OperatorEquals, OperatorNotEquals, OperatorExists},
+	kindReference: {OperatorEquals, OperatorNotEquals, OperatorIn, OperatorExists},
+}
+
+// queryableField is a field of a collection that can be used to filter or sort resources.
+type queryableField struct {
+	column string
+	kind   fieldKind
+}
+
+// defaultQueryableFields are the columns every collection table has.
+var defaultQueryableFields = map[string]queryableField{
+	"id":           {column: "id", kind: kindNumber},
+	"slug":         {column: "slug", kind: kindText},
+	"created_at":   {column: "created_at", kind: kindDate},
+	"created_by":   {column: "created_by", kind: kindReference},
+	"updated_at":   {column: "updated_at", kind: kindDate},
+	"updated_by":   {column: "updated_by", kind: kindReference},
+	"status":       {column: "status", kind: kindText},
+	"published_at": {column: "published_at", kind: kindDate},
+	"deleted_at":   {column: "deleted_at", kind: kindDate},
+	"publish_at":   {column: "publish_at", kind: kindDate},
+	"unpublish_at": {column: "unpublish_at", kind: kindDate},
+}
+
+func kindOf(element mimsy_schema.SchemaElement) fieldKind {
+	// Localized values are stored in a jsonb object
+	if element.IsLocalized() {
+		return kindUnsupported
+	}
+
+	// Multiple select values are stored in a text[] column
+	if element.Type == "select" && element.IsMultiple() {
+		return kindUnsupported
+	}
+
+	switch element.Type {
+	case "string", "long_string", "email", "select":
+		return kindText
+	case "number":
+		return kindNumber
+	case "date_time", "created_at":
+		return kindDate
+	case "checkbox":
+		return kindBoolean
+	case "relation":
+		return kindReference
+	default:
+		return kindUnsupported
+	}
+}
+
+// lookupQueryableField returns the column and kind of a field that can be used in a query.
+func lookupQueryableField(fields mimsy_schema.CollectionFields, name string) (queryableField, error) {
+	if field, ok := defaultQueryableFields[name]; ok {
+		return field, nil
+	}
+
+	element, ok := fields[name]
+	if !ok {
+		return queryableField{}, fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, name)
+	}
+
+	kind := kindOf(element)
+	if kind == kindUnsupported {
+		return queryableField{}, fmt.Errorf("%w: field %q of type %q cannot be queried", ErrInvalidQuery, name, element.Type)
+	}
+
+	column := name
+	if element.Type == "relation" {
+		column = fmt.Sprintf("%s_id", name)
+	}
+
+	return queryableField{column: column, kind: kind}, nil
+}
+
+// FilterOperators returns the operators that can be used to filter the resources of a collection on a field,
+// nil when the field cannot be queried.
+func FilterOperators(fields mimsy_schema.CollectionFields, name string) []Operator {
+	field, err := lookupQueryableField(fields, name)
+	if err != nil {
+		return nil
+	}
+	return operatorsByKind[field.kind]
+}
+
+// toSql converts the filter to a squirrel condition, checking it against the collection fields.
+func (f Filter) toSql(fields mimsy_schema.CollectionFields) (sq.Sqlizer, error) {
+	field, err := lookupQueryableField(fields, f.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(operatorsByKind[field.kind], f.Operator) {
+		return nil, fmt.Errorf("%w: operator %q is not supported on field %q", ErrInvalidQuery, f.Operator, f.Field)
+	}
+
+	column := pq.QuoteIdentifier(field.column)
+
+	if f.Operator == OperatorExists {
+		exists, err := strconv.ParseBool(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of %q must be a boolean", ErrInvalidQuery, f.Field)
+		}
+		if exists {
+			return sq.NotEq{column: nil}, nil
+		}
+		return sq.Eq{column: nil}, nil
+	}
+
+	if f.Operator == OperatorIn {
+		rawValues := strings.Split(f.Value, ",")
+		values := make([]any, len(rawValues))
+		for i, raw := range rawValues {
+			if values[i], err = parseFilterValue(field.kind, f.Field, raw); err != nil {
+				return nil, err
+			}
+		}
+		return sq.Eq{column: values}, nil
+	}
+
+	if f.Operator == OperatorContains {
+		return sq.ILike{column: "%" + escapeLike(f.Value) + "%"}, nil
+	}
+
+	value, err := parseFilterValue(field.kind, f.Field, f.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f.Operator {
+	case OperatorEquals:
+		return sq.Eq{column: value}, nil
+	case OperatorNotEquals:
+		return sq.NotEq{column: value}, nil
+	case OperatorGt:
+		return sq.Gt{column: value}, nil
+	case OperatorGte:
+		return sq.GtOrEq{column: value}, nil
+	case OperatorLt:
+		return sq.Lt{column: value}, nil
+	case OperatorLte:
+		return sq.LtOrEq{column: value}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidQuery, f.Operator)
+	}
+}
+
+// parseFilterValue converts the raw query string value to the type of the field.
+func parseFilterValue(kind fieldKind, name string, raw string) (any, error) {
+	switch kind {
+	case kindNumber:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of %q must be a number", ErrInvalidQuery, name)
+		}
+		return value, nil
+	case kindDate:
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of %q must be a RFC3339 date", ErrInvalidQuery, name)
+		}
+		return value, nil
+	case kindBoolean:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of %q must be a boolean", ErrInvalidQuery, name)
+		}
+		return value, nil
+	case kindReference:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of %q must be an identifier", ErrInvalidQuery, name)
+		}
+		return value, nil
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// toSql converts the sort to an ORDER BY clause, checking it against the collection fields.
+func (s Sort) toSql(fields mimsy_schema.CollectionFields) (string, error) {
+	field, err := lookupQueryableField(fields, s.Field)
+	if err != nil {
+		return "", err
+	}
+
+	if s.Descending {
+		return pq.QuoteIdentifier(field.column) + " DESC", nil
+	}
+	return pq.QuoteIdentifier(field.column) + " ASC", nil
+}
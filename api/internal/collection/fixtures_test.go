@@ -0,0 +1,24 @@
+package collection
+
+import (
+	"encoding/json"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// NewTestCollection returns a collection with the given fields, named after its slug.
+// It is exported for the tests of the collection_test package.
+func NewTestCollection(slug string, fields mimsy_schema.CollectionFields) *Collection {
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		panic(err)
+	}
+	return &Collection{Slug: slug, Name: slug, Fields: fieldsJSON}
+}
+
+// NewTestGlobal returns a global with the given fields, see NewTestCollection.
+func NewTestGlobal(slug string, fields mimsy_schema.CollectionFields) *Collection {
+	global := NewTestCollection(slug, fields)
+	global.IsGlobal = true
+	return global
+}
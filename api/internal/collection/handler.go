@@ -2,11 +2,16 @@ package collection
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/mimsy-cms/mimsy/internal/auth"
 	"github.com/mimsy-cms/mimsy/internal/util"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
 )
 
 type Handler struct {
@@ -34,9 +39,29 @@ func (h *Handler) Definition(w http.ResponseWriter, r *http.Request) {
 	util.JSON(w, http.StatusOK, NewCollectionResponse(collection))
 }
 
+// wantsDrafts returns whether the request opted into draft resources with `?draft=true`.
+func wantsDrafts(r *http.Request) bool {
+	draft, _ := strconv.ParseBool(r.URL.Query().Get("draft"))
+	return draft
+}
+
 func (h *Handler) GetResources(w http.ResponseWriter, r *http.Request) {
 	slug := r.PathValue("slug")
 
+	draft := wantsDrafts(r)
+	if draft && auth.RequestUser(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	params, err := ParseFindResourcesParams(r.URL.Query())
+	if err != nil {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params.PublishedOnly = !draft
+
 	collection, err := h.Service.FindBySlug(r.Context(), slug)
 	if err != nil {
 		if err == ErrNotFound {
@@ -47,7 +72,12 @@ func (h *Handler) GetResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resources, err := h.Service.FindResources(r.Context(), collection)
+	resources, total, err := h.Service.FindResources(r.Context(), collection, params)
+	if errors.Is(err, ErrInvalidQuery) {
+		slog.Warn("Invalid resources query", "slug", slug, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to get resources", "slug", slug, "error", err)
 		if err == ErrNotFound {
@@ -58,9 +88,75 @@ func (h *Handler) GetResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if populate := ParsePopulate(r.URL.Query().Get("populate")); len(populate) > 0 {
+		if err := h.Service.PopulateResources(r.Context(), collection, resources, populate, !draft); err != nil {
+			if errors.Is(err, ErrInvalidQuery) {
+				slog.Warn("Failed to populate resources", "slug", slug, "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				slog.Error("Failed to populate resources", "slug", slug, "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	if !h.localizeResources(w, r, collection, resources) {
+		return
+	}
+	if !h.renderRichText(w, r, collection, resources) {
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
 	util.JSON(w, http.StatusOK, resources)
 }
 
+// AggregateResources returns the counts and aggregated values of the resources of a collection,
+// optionally grouped by some of their fields, see ParseAggregateParams.
+func (h *Handler) AggregateResources(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	draft := wantsDrafts(r)
+	if draft && auth.RequestUser(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	params, err := ParseAggregateParams(r.URL.Query())
+	if err != nil {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params.PublishedOnly = !draft
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	groups, err := h.Service.AggregateResources(r.Context(), collection, params)
+	if errors.Is(err, ErrInvalidQuery) {
+		slog.Warn("Invalid aggregate query", "slug", slug, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to aggregate resources", "slug", slug, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, groups)
+}
+
 func (h *Handler) UpdateResource(w http.ResponseWriter, r *http.Request) {
 	user := auth.RequestUser(r.Context())
 	if user == nil {
@@ -88,20 +184,62 @@ func (h *Handler) UpdateResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedResource, err := h.Service.UpdateResource(r.Context(), collection, resourceSlug, user.ID, contentData)
+	if collection.IsGlobal {
+		resourceSlug = slug
+	}
+
+	h.updateResource(w, r, collection, resourceSlug, user.ID, contentData)
+}
+
+// updateResource updates a resource with the content of a request, creating it when it does not exist.
+func (h *Handler) updateResource(w http.ResponseWriter, r *http.Request, collection *Collection, resourceSlug string, userID int64, contentData map[string]any) {
+	slug := collection.Slug
+
+	if contentData = h.localizeContent(w, r, collection, resourceSlug, contentData); contentData == nil {
+		return
+	}
+
+	// Without an If-Match header, the resource is overwritten whatever its version
+	ctx := ContextWithPrecondition(r.Context(), ParseIfMatch(r.Header.Get("If-Match")))
+
+	updatedResource, err := h.Service.UpdateResource(ctx, collection, resourceSlug, userID, contentData)
 	if err != nil {
 		if err == ErrNotFound {
-			createdResource, createErr := h.Service.CreateResource(r.Context(), collection, resourceSlug, user.ID, contentData)
+			createdResource, createErr := h.Service.CreateResource(r.Context(), collection, resourceSlug, userID, contentData)
 			if createErr != nil {
 				slog.Error("Failed to create resource", "slug", slug, "resourceSlug", resourceSlug, "error", createErr)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				var validationErr *ValidationError
+				if errors.As(createErr, &validationErr) {
+					util.JSON(w, http.StatusUnprocessableEntity, validationErr)
+				} else if createErr == ErrAlreadyExists {
+					http.Error(w, "Resource with this slug is in the trash", http.StatusConflict)
+				} else if errors.Is(createErr, ErrInvalidContent) {
+					http.Error(w, createErr.Error(), http.StatusBadRequest)
+				} else {
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				}
 				return
 			}
+			w.Header().Set("ETag", createdResource.ETag())
 			util.JSON(w, http.StatusCreated, createdResource)
 			return
 		}
+
+		slog.Error("Failed to update resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		var validationErr *ValidationError
+		if err == ErrPreconditionFailed {
+			h.preconditionFailed(w, r, collection, resourceSlug)
+		} else if errors.As(err, &validationErr) {
+			util.JSON(w, http.StatusUnprocessableEntity, validationErr)
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
 	}
 
+	w.Header().Set("ETag", updatedResource.ETag())
 	util.JSON(w, http.StatusOK, updatedResource)
 }
 
@@ -143,17 +281,27 @@ func (h *Handler) CreateResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if fields = h.localizeContent(w, r, collection, slug, fields); fields == nil {
+		return
+	}
+
 	createdResource, err := h.Service.CreateResource(r.Context(), collection, slug, user.ID, fields)
 	if err != nil {
 		slog.Error("Failed to create resource", "collectionSlug", collectionSlug, "resourceSlug", slug, "error", err)
-		if err == ErrAlreadyExists {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			util.JSON(w, http.StatusUnprocessableEntity, validationErr)
+		} else if err == ErrAlreadyExists {
 			http.Error(w, "Resource with this slug already exists", http.StatusConflict)
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		} else {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	w.Header().Set("ETag", createdResource.ETag())
 	util.JSON(w, http.StatusCreated, createdResource)
 }
 
@@ -161,6 +309,12 @@ func (h *Handler) GetResource(w http.ResponseWriter, r *http.Request) {
 	slug := r.PathValue("slug")
 	resourceSlug := r.PathValue("resourceSlug")
 
+	draft := wantsDrafts(r)
+	if draft && auth.RequestUser(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	collection, err := h.Service.FindBySlug(r.Context(), slug)
 	if err != nil {
 		slog.Error("Failed to get collection", "slug", slug, "error", err)
@@ -176,6 +330,13 @@ func (h *Handler) GetResource(w http.ResponseWriter, r *http.Request) {
 		resourceSlug = slug
 	}
 
+	h.getResource(w, r, collection, resourceSlug, draft)
+}
+
+// getResource writes a resource of a collection, drafts are only returned when draft is true.
+func (h *Handler) getResource(w http.ResponseWriter, r *http.Request, collection *Collection, resourceSlug string, draft bool) {
+	slug := collection.Slug
+
 	resource, err := h.Service.FindResource(r.Context(), collection, resourceSlug)
 	if err != nil {
 		slog.Error("Failed to get resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
@@ -187,9 +348,120 @@ func (h *Handler) GetResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Drafts are hidden as if they did not exist
+	if !draft && resource.Status != StatusPublished {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+
+	if populate := ParsePopulate(r.URL.Query().Get("populate")); len(populate) > 0 {
+		if err := h.Service.PopulateResources(r.Context(), collection, []Resource{*resource}, populate, !draft); err != nil {
+			if errors.Is(err, ErrInvalidQuery) {
+				slog.Warn("Failed to populate resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				slog.Error("Failed to populate resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	resources := []Resource{*resource}
+	if !h.localizeResources(w, r, collection, resources) {
+		return
+	}
+	if !h.renderRichText(w, r, collection, resources) {
+		return
+	}
+	resource = &resources[0]
+
+	w.Header().Set("ETag", resource.ETag())
 	util.JSON(w, http.StatusOK, resource)
 }
 
+// localizeResources picks the values of the locale requested with `?locale=` in the localized fields,
+// falling back to `?fallbackLocale=`. Without a locale, the values of every locale are returned.
+// It returns false when an error response was written.
+func (h *Handler) localizeResources(w http.ResponseWriter, r *http.Request, collection *Collection, resources []Resource) bool {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		return true
+	}
+
+	if err := h.Service.LocalizeResources(r.Context(), collection, resources, locale, r.URL.Query().Get("fallbackLocale")); err != nil {
+		slog.Error("Failed to localize resources", "slug", collection.Slug, "locale", locale, "error", err)
+		if errors.Is(err, ErrInvalidQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return false
+	}
+
+	return true
+}
+
+// renderRichText renders the rich text fields to the format requested with `?richText=`,
+// see RenderRichText. It returns false when an error response was written.
+func (h *Handler) renderRichText(w http.ResponseWriter, r *http.Request, collection *Collection, resources []Resource) bool {
+	format, err := ParseRichTextFormat(r.URL.Query().Get("richText"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if format == "" {
+		return true
+	}
+
+	if err := h.Service.RenderRichText(r.Context(), collection, resources, format); err != nil {
+		slog.Error("Failed to render rich text", "slug", collection.Slug, "format", format, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// localizeContent converts the values of the localized fields written in the locale requested with `?locale=`
+// to values by locale. Without a locale, the localized fields are given as objects of values by locale.
+// It returns nil when an error response was written.
+func (h *Handler) localizeContent(w http.ResponseWriter, r *http.Request, collection *Collection, resourceSlug string, content map[string]any) map[string]any {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		return content
+	}
+
+	localized, err := h.Service.LocalizeContent(r.Context(), collection, resourceSlug, content, locale)
+	if err != nil {
+		slog.Error("Failed to localize content", "slug", collection.Slug, "resourceSlug", resourceSlug, "locale", locale, "error", err)
+		if errors.Is(err, ErrInvalidQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return nil
+	}
+
+	return localized
+}
+
+// preconditionFailed responds with the current version of a resource that was changed
+// since the version given in the If-Match header.
+func (h *Handler) preconditionFailed(w http.ResponseWriter, r *http.Request, collection *Collection, resourceSlug string) {
+	current, err := h.Service.FindResource(r.Context(), collection, resourceSlug)
+	if err != nil {
+		if err != ErrNotFound {
+			slog.Error("Failed to get resource", "slug", collection.Slug, "resourceSlug", resourceSlug, "error", err)
+		}
+		http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+		return
+	}
+
+	w.Header().Set("ETag", current.ETag())
+	util.JSON(w, http.StatusPreconditionFailed, current)
+}
+
 type FindAllQueryString struct {
 	Search string `query:"q"`
 }
@@ -197,7 +469,7 @@ type FindAllQueryString struct {
 func (h *Handler) FindAll(w http.ResponseWriter, r *http.Request) {
 	query, err := util.QueryString[FindAllQueryString](r)
 	if err != nil {
-		slog.Error("Failed to decode query parameters", "error", err)
+		slog.Warn("Failed to decode query parameters", "error", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -223,7 +495,7 @@ type FindAllGlobalsQueryString struct {
 func (h *Handler) FindAllGlobals(w http.ResponseWriter, r *http.Request) {
 	query, err := util.QueryString[FindAllQueryString](r)
 	if err != nil {
-		slog.Error("Failed to decode query parameters", "error", err)
+		slog.Warn("Failed to decode query parameters", "error", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -242,6 +514,66 @@ func (h *Handler) FindAllGlobals(w http.ResponseWriter, r *http.Request) {
 	util.JSON(w, http.StatusOK, response)
 }
 
+// GetGlobal returns the single resource of a global.
+func (h *Handler) GetGlobal(w http.ResponseWriter, r *http.Request) {
+	draft := wantsDrafts(r)
+	if draft && auth.RequestUser(r.Context()) == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collection, ok := h.findGlobal(w, r)
+	if !ok {
+		return
+	}
+
+	h.getResource(w, r, collection, collection.Slug, draft)
+}
+
+// UpdateGlobal updates the single resource of a global, creating it when it does not exist.
+func (h *Handler) UpdateGlobal(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var contentData map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&contentData); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	collection, ok := h.findGlobal(w, r)
+	if !ok {
+		return
+	}
+
+	h.updateResource(w, r, collection, collection.Slug, user.ID, contentData)
+}
+
+// findGlobal returns the global of the slug of a request, collections are not found.
+// It returns false when an error response was written.
+func (h *Handler) findGlobal(w http.ResponseWriter, r *http.Request) (*Collection, bool) {
+	slug := r.PathValue("slug")
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err == nil && !collection.IsGlobal {
+		err = ErrNotFound
+	}
+	if err != nil {
+		slog.Error("Failed to get global", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Global not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return collection, true
+}
+
 func (h *Handler) DeleteResource(w http.ResponseWriter, r *http.Request) {
 	user := auth.RequestUser(r.Context())
 	if user == nil {
@@ -274,11 +606,630 @@ func (h *Handler) DeleteResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.DeleteResource(r.Context(), resource); err != nil {
+	ctx := ContextWithPrecondition(r.Context(), ParseIfMatch(r.Header.Get("If-Match")))
+
+	if err := h.Service.DeleteResource(ctx, collection, resource, user.ID); err != nil {
 		slog.Error("Failed to delete resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		var referencedErr *ReferencedError
+		if err == ErrPreconditionFailed {
+			h.preconditionFailed(w, r, collection, resourceSlug)
+		} else if errors.As(err, &referencedErr) {
+			util.JSON(w, http.StatusConflict, referencedErr)
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *Handler) PublishResource(w http.ResponseWriter, r *http.Request) {
+	h.updateResourceStatus(w, r, StatusPublished)
+}
+
+func (h *Handler) UnpublishResource(w http.ResponseWriter, r *http.Request) {
+	h.updateResourceStatus(w, r, StatusDraft)
+}
+
+func (h *Handler) updateResourceStatus(w http.ResponseWriter, r *http.Request, status Status) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if collection.IsGlobal {
+		resourceSlug = slug
+	}
+
+	var resource *Resource
+	if status == StatusPublished {
+		resource, err = h.Service.PublishResource(r.Context(), collection, resourceSlug, user.ID)
+	} else {
+		resource, err = h.Service.UnpublishResource(r.Context(), collection, resourceSlug, user.ID)
+	}
+	if err != nil {
+		slog.Error("Failed to update resource status", "slug", slug, "resourceSlug", resourceSlug, "status", status, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", resource.ETag())
+	util.JSON(w, http.StatusOK, resource)
+}
+
+func (h *Handler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	revisions, err := h.Service.FindRevisions(r.Context(), collection, resourceSlug)
+	if err != nil {
+		slog.Error("Failed to get revisions", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, revisions)
+}
+
+type DiffRevisionsQueryString struct {
+	From int64 `query:"from"`
+	To   int64 `query:"to"`
+}
+
+type DiffRevisionsResponse struct {
+	From    int64         `json:"from"`
+	To      int64         `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	query, err := util.QueryString[DiffRevisionsQueryString](r)
+	if err != nil || query.From == 0 || query.To == 0 {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, "Both from and to revisions are required", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	revisions := make([]*Revision, 2)
+	for i, id := range []int64{query.From, query.To} {
+		if revisions[i], err = h.Service.FindRevision(r.Context(), collection, resourceSlug, id); err != nil {
+			slog.Error("Failed to get revision", "slug", slug, "resourceSlug", resourceSlug, "revision", id, "error", err)
+			if err == ErrNotFound {
+				http.Error(w, "Revision not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	changes, err := DiffRevisions(revisions[0], revisions[1])
+	if err != nil {
+		slog.Error("Failed to diff revisions", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, DiffRevisionsResponse{From: query.From, To: query.To, Changes: changes})
+}
+
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	id, err := util.PathInt(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid revision id", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resource, err := h.Service.RestoreRevision(r.Context(), collection, resourceSlug, id, user.ID)
+	if err != nil {
+		slog.Error("Failed to restore revision", "slug", slug, "resourceSlug", resourceSlug, "revision", id, "error", err)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			util.JSON(w, http.StatusUnprocessableEntity, validationErr)
+		} else if err == ErrNotFound {
+			http.Error(w, "Revision not found", http.StatusNotFound)
+		} else if err == ErrAlreadyExists {
+			http.Error(w, "Resource with this slug already exists", http.StatusConflict)
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", resource.ETag())
+	util.JSON(w, http.StatusOK, resource)
+}
+
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+
+	params, err := ParseFindResourcesParams(r.URL.Query())
+	if err != nil {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params.Trashed = true
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resources, total, err := h.Service.FindResources(r.Context(), collection, params)
+	if err != nil {
+		slog.Error("Failed to get trashed resources", "slug", slug, "error", err)
+		if errors.Is(err, ErrInvalidQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	util.JSON(w, http.StatusOK, resources)
+}
+
+func (h *Handler) RestoreResource(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resource, err := h.Service.RestoreResource(r.Context(), collection, resourceSlug, user.ID)
+	if err != nil {
+		slog.Error("Failed to restore resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Resource not found in trash", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", resource.ETag())
+	util.JSON(w, http.StatusOK, resource)
+}
+
+func (h *Handler) PurgeResource(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.Service.PurgeResource(r.Context(), collection, resourceSlug); err != nil {
+		slog.Error("Failed to purge resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		var referencedErr *ReferencedError
+		if err == ErrNotFound {
+			http.Error(w, "Resource not found in trash", http.StatusNotFound)
+		} else if errors.As(err, &referencedErr) {
+			util.JSON(w, http.StatusConflict, referencedErr)
+		} else if err == ErrReferenced {
+			http.Error(w, "Resource is referenced by other resources", http.StatusConflict)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ScheduleResource(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	var schedule Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if collection.IsGlobal {
+		resourceSlug = slug
+	}
+
+	resource, err := h.Service.ScheduleResource(r.Context(), collection, resourceSlug, user.ID, schedule)
+	if err != nil {
+		slog.Error("Failed to schedule resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			util.JSON(w, http.StatusUnprocessableEntity, validationErr)
+		} else if err == ErrNotFound {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", resource.ETag())
+	util.JSON(w, http.StatusOK, resource)
+}
+
+func (h *Handler) FindLocales(w http.ResponseWriter, r *http.Request) {
+	locales, err := h.Service.FindLocales(r.Context())
+	if err != nil {
+		slog.Error("Failed to get locales", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, locales)
+}
+
+type ExportQueryString struct {
+	Format string `query:"format"`
+}
+
+func (h *Handler) ExportResources(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+
+	query, err := util.QueryString[ExportQueryString](r)
+	if err != nil {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	format, err := ParseBulkFormat(query.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writer, err := NewResourceWriter(w, format, collection)
+	if err != nil {
+		slog.Error("Failed to export resources", "slug", slug, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s.%s", slug, format)))
+
+	// The resources are streamed, so the status cannot be changed once the first one is written
+	if err := h.Service.ExportResources(r.Context(), collection, writer.Write); err != nil {
+		slog.Error("Failed to export resources", "slug", slug, "error", err)
+		return
+	}
+	if err := writer.Flush(); err != nil {
+		slog.Error("Failed to export resources", "slug", slug, "error", err)
+	}
+}
+
+// maxImportSize is the maximum size of an imported file.
+const maxImportSize = 32 << 20
+
+type ImportQueryString struct {
+	Format    string `query:"format"`
+	BatchSize int    `query:"batchSize"`
+}
+
+func (h *Handler) ImportResources(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+
+	query, err := util.QueryString[ImportQueryString](r)
+	if err != nil || query.BatchSize < 0 {
+		slog.Warn("Failed to decode query parameters", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	// Without a format parameter, the format is the one of the content type
+	if query.Format == "" && strings.HasPrefix(r.Header.Get("Content-Type"), BulkFormatCSV.ContentType()) {
+		query.Format = string(BulkFormatCSV)
+	}
+	format, err := ParseBulkFormat(query.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.FindBySlug(r.Context(), slug)
+	if err != nil {
+		slog.Error("Failed to get collection", "slug", slug, "error", err)
+		if err == ErrNotFound {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if collection.IsGlobal {
+		http.Error(w, "Globals cannot be imported", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := ReadImportRows(http.MaxBytesReader(w, r.Body, maxImportSize), format, collection)
+	if err != nil {
+		slog.Error("Failed to read imported file", "slug", slug, "error", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+		}
+		return
+	}
+
+	report, err := h.Service.ImportResources(r.Context(), collection, rows, user.ID, query.BatchSize)
+	if err != nil {
+		slog.Error("Failed to import resources", "slug", slug, "error", err)
+		if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	util.JSON(w, http.StatusOK, report)
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	request, err := util.DecodeJSON[BatchRequest](r)
+	if err != nil {
+		slog.Error("Failed to decode batch request", "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.Service.ExecuteBatch(r.Context(), request.Operations, user.ID)
+	if err != nil {
+		slog.Error("Failed to execute batch", "error", err)
+
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			// The results tell which operation failed, and that the others were not applied
+			util.JSON(w, batchErrorStatus(batchErr.Err), BatchResponse{Results: results})
+		} else if errors.Is(err, ErrInvalidContent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	util.JSON(w, http.StatusOK, BatchResponse{Results: results})
+}
+
+// GetReferences lists the resources referencing a resource. The collection can also be a builtin reference
+// such as `<builtins.media>`, in which case the resource is given by its identifier.
+func (h *Handler) GetReferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	slug := r.PathValue("slug")
+	resourceSlug := r.PathValue("resourceSlug")
+
+	var id int64
+	if schema_generator.IsBuiltin(slug) {
+		if _, err := schema_generator.GetSimpleTableName(slug); err != nil {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+
+		var err error
+		if id, err = strconv.ParseInt(resourceSlug, 10, 64); err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+	} else {
+		collection, err := h.Service.FindBySlug(r.Context(), slug)
+		if err != nil {
+			slog.Error("Failed to get collection", "slug", slug, "error", err)
+			if err == ErrNotFound {
+				http.Error(w, "Collection not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		resource, err := h.Service.FindResource(r.Context(), collection, resourceSlug)
+		if err != nil {
+			slog.Error("Failed to get resource", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+			if err == ErrNotFound {
+				http.Error(w, "Resource not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+		id = resource.Id
+	}
+
+	references, err := h.Service.FindReferences(r.Context(), slug, id)
+	if err != nil {
+		slog.Error("Failed to get references", "slug", slug, "resourceSlug", resourceSlug, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, references)
+}
@@ -0,0 +1,240 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// Locale is a language in which the localized fields can be written, declared in `mimsy.config.json`.
+type Locale struct {
+	Code string `json:"code"`
+	// IsDefault is true for the locale in which the required localized fields must be given.
+	IsDefault bool `json:"is_default"`
+}
+
+// NewLocales returns the locales declared in the configuration of a project, in their declaration order.
+func NewLocales(config *mimsy_schema.MimsyConfig) []Locale {
+	defaultLocale := config.GetDefaultLocale()
+
+	locales := make([]Locale, 0, len(config.Locales))
+	for _, code := range config.Locales {
+		locales = append(locales, Locale{Code: code, IsDefault: code == defaultLocale})
+	}
+	return locales
+}
+
+// FindLocales returns the locales of the project, in their declaration order.
+func (r *repository) FindLocales(ctx context.Context) ([]Locale, error) {
+	rows, err := config.GetDB(ctx).QueryContext(ctx, `SELECT code, is_default FROM "locale" ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locales: %w", err)
+	}
+	defer rows.Close()
+
+	locales := []Locale{}
+	for rows.Next() {
+		var locale Locale
+		if err := rows.Scan(&locale.Code, &locale.IsDefault); err != nil {
+			return nil, fmt.Errorf("failed to scan locale: %w", err)
+		}
+		locales = append(locales, locale)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over locales: %w", err)
+	}
+
+	return locales, nil
+}
+
+// SetLocales replaces the locales of the project.
+// The values of the removed locales are kept in the resources, but are no longer returned.
+func (r *repository) SetLocales(ctx context.Context, locales []Locale) error {
+	return config.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := config.GetDB(ctx).ExecContext(ctx, `DELETE FROM "locale"`); err != nil {
+			return fmt.Errorf("failed to delete locales: %w", err)
+		}
+
+		if len(locales) == 0 {
+			return nil
+		}
+
+		b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+			Insert("locale").
+			Columns("code", "is_default", "position")
+		for i, locale := range locales {
+			b = b.Values(locale.Code, locale.IsDefault, i)
+		}
+
+		query, args, err := b.ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build locale insert SQL query: %w", err)
+		}
+
+		if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to insert locales: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *service) FindLocales(ctx context.Context) ([]Locale, error) {
+	return s.collectionRepository.FindLocales(ctx)
+}
+
+// checkLocales returns an ErrInvalidQuery when one of the codes is not a locale of the project, empty codes are ignored.
+func (s *service) checkLocales(ctx context.Context, codes ...string) error {
+	locales, err := s.collectionRepository.FindLocales(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if code != "" && !slices.ContainsFunc(locales, func(locale Locale) bool { return locale.Code == code }) {
+			return fmt.Errorf("%w: unsupported locale %q", ErrInvalidQuery, code)
+		}
+	}
+	return nil
+}
+
+// LocalizeContent converts the values of the localized fields given in a locale to their values by locale,
+// keeping the values of the other locales of the resource when it exists.
+func (s *service) LocalizeContent(ctx context.Context, collection *Collection, resourceSlug string, content map[string]any, locale string) (map[string]any, error) {
+	if err := s.checkLocales(ctx, locale); err != nil {
+		return nil, err
+	}
+
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	var existing *Resource
+	localized := maps.Clone(content)
+	for name, element := range fields {
+		value, ok := content[name]
+		if !ok || !element.IsLocalized() {
+			continue
+		}
+
+		if existing == nil {
+			var err error
+			if existing, err = s.collectionRepository.FindResource(ctx, collection, resourceSlug); err == ErrNotFound {
+				existing = &Resource{Fields: map[string]any{}}
+			} else if err != nil {
+				return nil, err
+			}
+		}
+
+		values := map[string]any{}
+		if current, ok := existing.Fields[name].(map[string]any); ok {
+			values = maps.Clone(current)
+		}
+		values[locale] = value
+		localized[name] = values
+	}
+
+	return localized, nil
+}
+
+// LocalizeResources replaces the values by locale of the localized fields by their value in the locale,
+// or in the fallback locale when it is missing. Populated resources are localized as well.
+func (s *service) LocalizeResources(ctx context.Context, collection *Collection, resources []Resource, locale string, fallbackLocale string) error {
+	if err := s.checkLocales(ctx, locale, fallbackLocale); err != nil {
+		return err
+	}
+
+	l := &localizer{
+		repository:     s.collectionRepository,
+		locale:         locale,
+		fallbackLocale: fallbackLocale,
+		fields:         map[string]mimsy_schema.CollectionFields{},
+	}
+
+	if err := l.addCollection(collection); err != nil {
+		return err
+	}
+
+	for i := range resources {
+		if err := l.localize(ctx, &resources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localizer picks the values of a locale in resources, loading the fields of their collections once.
+type localizer struct {
+	repository     Repository
+	locale         string
+	fallbackLocale string
+	fields         map[string]mimsy_schema.CollectionFields
+}
+
+func (l *localizer) addCollection(collection *Collection) error {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+	l.fields[collection.Slug] = fields
+	return nil
+}
+
+func (l *localizer) localize(ctx context.Context, resource *Resource) error {
+	if _, ok := l.fields[resource.Collection]; !ok {
+		collection, err := l.repository.FindBySlug(ctx, resource.Collection)
+		if err != nil {
+			return fmt.Errorf("failed to find collection %q: %w", resource.Collection, err)
+		}
+		if err := l.addCollection(collection); err != nil {
+			return err
+		}
+	}
+	fields := l.fields[resource.Collection]
+
+	for name, value := range resource.Fields {
+		if element, ok := fields[name]; ok && element.IsLocalized() {
+			resource.Fields[name] = l.pick(value)
+			continue
+		}
+
+		// Populated relations hold resources of other collections
+		switch v := value.(type) {
+		case Resource:
+			if err := l.localize(ctx, &v); err != nil {
+				return err
+			}
+			resource.Fields[name] = v
+		case []any:
+			for i, item := range v {
+				if related, ok := item.(Resource); ok {
+					if err := l.localize(ctx, &related); err != nil {
+						return err
+					}
+					v[i] = related
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// pick returns the value of the locale, or of the fallback locale when it is empty.
+func (l *localizer) pick(value any) any {
+	values, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if localized := values[l.locale]; !isEmpty(localized) || l.fallbackLocale == "" {
+		return localized
+	}
+	return values[l.fallbackLocale]
+}
@@ -0,0 +1,162 @@
+package collection_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// =================================================================================================
+// Localization Tests
+// =================================================================================================
+
+// localizedFields are the fields of a collection with a localized field.
+var localizedFields = mimsy_schema.CollectionFields{
+	"title": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{
+		Localized:   true,
+		Constraints: &mimsy_schema.SchemaElementConstraints{Required: true, MaxLength: 5},
+	}},
+	"author": {Type: "relation", RelatesTo: "authors"},
+}
+
+var mockLocales = []collection.Locale{{Code: "en", IsDefault: true}, {Code: "fr"}}
+
+func TestService_CreateResource_LocalizedValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	mockRepo.EXPECT().FindLocales(gomock.Any()).Return(mockLocales, nil)
+
+	content := map[string]any{
+		"title": map[string]any{"fr": "Bonjour", "de": "Hallo"},
+	}
+
+	_, err := service.CreateResource(context.Background(), collection.NewTestCollection("pages", localizedFields), "home", 1, content)
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{
+		{Path: "title.de", Reason: "is not a supported locale"},
+		{Path: "title.fr", Reason: "must be at most 5 characters long"},
+		{Path: "title.en", Reason: "is required"},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+}
+
+func TestService_LocalizeContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+	pages := collection.NewTestCollection("pages", localizedFields)
+
+	mockRepo.EXPECT().FindLocales(gomock.Any()).Return(mockLocales, nil)
+	mockRepo.EXPECT().
+		FindResource(gomock.Any(), pages, "home").
+		Return(&collection.Resource{Slug: "home", Fields: map[string]any{"title": map[string]any{"en": "Hello"}}}, nil)
+
+	content, err := service.LocalizeContent(context.Background(), pages, "home", map[string]any{"title": "Salut", "author_id": float64(2)}, "fr")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]any{
+		"title":     map[string]any{"en": "Hello", "fr": "Salut"},
+		"author_id": float64(2),
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Errorf("expected %v, got %v", expected, content)
+	}
+}
+
+func TestService_LocalizeResources_Fallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	authors := collection.NewTestCollection("authors", mimsy_schema.CollectionFields{
+		"bio": {Type: "long_string", Options: &mimsy_schema.SchemaElementOptions{Localized: true}},
+	})
+
+	mockRepo.EXPECT().FindLocales(gomock.Any()).Return(mockLocales, nil)
+	mockRepo.EXPECT().
+		FindBySlug(gomock.Any(), "authors").
+		Return(authors, nil)
+
+	resources := []collection.Resource{
+		{Collection: "pages", Fields: map[string]any{
+			"title": map[string]any{"en": "Hello", "fr": "Salut"},
+			"author": collection.Resource{Collection: "authors", Fields: map[string]any{
+				"bio": map[string]any{"en": "Writer"},
+			}},
+		}},
+		{Collection: "pages", Fields: map[string]any{
+			"title": map[string]any{"en": "About"},
+		}},
+	}
+
+	if err := service.LocalizeResources(context.Background(), collection.NewTestCollection("pages", localizedFields), resources, "fr", "en"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if title := resources[0].Fields["title"]; title != "Salut" {
+		t.Errorf("expected the french title, got %v", title)
+	}
+	if title := resources[1].Fields["title"]; title != "About" {
+		t.Errorf("expected the english title as fallback, got %v", title)
+	}
+	if bio := resources[0].Fields["author"].(collection.Resource).Fields["bio"]; bio != "Writer" {
+		t.Errorf("expected the populated author to be localized, got %v", bio)
+	}
+}
+
+func TestGetResources_UnsupportedLocale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	pages := collection.NewTestCollection("pages", localizedFields)
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "pages").
+		Return(pages, nil)
+
+	mockService.EXPECT().
+		FindResources(gomock.Any(), pages, gomock.Any()).
+		Return([]collection.Resource{}, int64(0), nil)
+
+	mockService.EXPECT().
+		LocalizeResources(gomock.Any(), pages, []collection.Resource{}, "de", "").
+		Return(fmt.Errorf("%w: unsupported locale %q", collection.ErrInvalidQuery, "de"))
+
+	req := httptest.NewRequest("GET", "/collections/pages?locale=de", nil)
+	req.SetPathValue("slug", "pages")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResources), req, t)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status Bad Request, got %v", w.Code)
+	}
+}
@@ -0,0 +1,247 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mimsy-cms/mimsy/internal/media"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+const (
+	// DefaultMaxPopulateDepth is the default number of nested relations that can be populated.
+	DefaultMaxPopulateDepth = 2
+
+	builtinUser  = "<builtins.user>"
+	builtinMedia = "<builtins.media>"
+)
+
+// Populate is the tree of relation fields to resolve when reading resources.
+// Each key is a relation field, and its value are the relation fields to resolve on the related resources.
+type Populate map[string]Populate
+
+// ParsePopulate parses a comma separated list of relation fields to populate.
+// Nested relations are separated by dots, so `author,tags.category` populates
+// the author and tags of a resource, and the category of each tag.
+func ParsePopulate(value string) Populate {
+	populate := Populate{}
+
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		current := populate
+		for _, field := range strings.Split(path, ".") {
+			if _, ok := current[field]; !ok {
+				current[field] = Populate{}
+			}
+			current = current[field]
+		}
+	}
+
+	return populate
+}
+
+// Depth returns the number of nested levels of the populate tree.
+func (p Populate) Depth() int {
+	depth := 0
+	for _, child := range p {
+		depth = max(depth, child.Depth()+1)
+	}
+	return depth
+}
+
+// PopulateResources replaces the relation fields of the resources by the related resources.
+// Many-to-one relations are resolved from their `<field>_id` column, and many-to-many relations
+// from their join table. Users and media are resolved through their own services.
+// When publishedOnly is set, related draft resources are resolved as missing.
+func (s *service) PopulateResources(ctx context.Context, collection *Collection, resources []Resource, populate Populate, publishedOnly bool) error {
+	if depth := populate.Depth(); depth > s.maxPopulateDepth {
+		return fmt.Errorf("%w: populate depth %d exceeds the maximum of %d", ErrInvalidQuery, depth, s.maxPopulateDepth)
+	}
+
+	return s.populate(ctx, collection, resources, populate, publishedOnly)
+}
+
+func (s *service) populate(ctx context.Context, collection *Collection, resources []Resource, populate Populate, publishedOnly bool) error {
+	if len(populate) == 0 || len(resources) == 0 {
+		return nil
+	}
+
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+
+	for name, children := range populate {
+		element, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, name)
+		}
+
+		var err error
+		switch element.Type {
+		case "relation":
+			err = s.populateManyToOne(ctx, resources, name, element, children, publishedOnly)
+		case "multi_relation":
+			err = s.populateManyToMany(ctx, collection, resources, name, element, children, publishedOnly)
+		default:
+			return fmt.Errorf("%w: field %q is not a relation", ErrInvalidQuery, name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to populate field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) populateManyToOne(ctx context.Context, resources []Resource, name string, element mimsy_schema.SchemaElement, children Populate, publishedOnly bool) error {
+	idColumn := fmt.Sprintf("%s_id", name)
+
+	ids := []int64{}
+	for _, resource := range resources {
+		if id, ok := resource.Fields[idColumn].(int64); ok && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	related, err := s.findRelated(ctx, element.RelatesTo, ids, children, publishedOnly)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		if id, ok := resource.Fields[idColumn].(int64); ok {
+			resource.Fields[name] = related[id]
+		} else {
+			resource.Fields[name] = nil
+		}
+	}
+
+	return nil
+}
+
+func (s *service) populateManyToMany(ctx context.Context, collection *Collection, resources []Resource, name string, element mimsy_schema.SchemaElement, children Populate, publishedOnly bool) error {
+	relation, err := NewRelation(collection.Slug, name, element)
+	if err != nil {
+		return err
+	}
+
+	ownerIds := make([]int64, len(resources))
+	for i, resource := range resources {
+		ownerIds[i] = resource.Id
+	}
+
+	relations, err := s.collectionRepository.FindRelationIds(ctx, relation, ownerIds)
+	if err != nil {
+		return err
+	}
+
+	ids := []int64{}
+	for _, targetIds := range relations {
+		for _, id := range targetIds {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	related, err := s.findRelated(ctx, element.RelatesTo, ids, children, publishedOnly)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		values := []any{}
+		for _, id := range relations[resource.Id] {
+			if value, ok := related[id]; ok {
+				values = append(values, value)
+			}
+		}
+		resource.Fields[name] = values
+	}
+
+	return nil
+}
+
+// findRelated returns the related objects by identifier, populating their own relations if requested.
+func (s *service) findRelated(ctx context.Context, relatesTo string, ids []int64, children Populate, publishedOnly bool) (map[int64]any, error) {
+	related := make(map[int64]any, len(ids))
+	if len(ids) == 0 {
+		return related, nil
+	}
+
+	switch relatesTo {
+	case builtinUser:
+		if len(children) > 0 {
+			return nil, fmt.Errorf("%w: users have no relations to populate", ErrInvalidQuery)
+		}
+		if s.userService == nil {
+			return nil, fmt.Errorf("user population is not configured")
+		}
+
+		// Only the public part of the users is exposed, as published resources can be read anonymously
+		users, err := s.userService.FindUsersByIds(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find users: %w", err)
+		}
+		for _, user := range users {
+			related[user.ID] = user.Public()
+		}
+	case builtinMedia:
+		if len(children) > 0 {
+			return nil, fmt.Errorf("%w: media have no relations to populate", ErrInvalidQuery)
+		}
+		if s.mediaService == nil {
+			return nil, fmt.Errorf("media population is not configured")
+		}
+
+		medias, err := s.mediaService.GetByIds(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find media: %w", err)
+		}
+
+		urls, err := s.mediaService.GetTemporaryURLs(ctx, medias)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range medias {
+			response := media.NewMediaResponse(&medias[i])
+			response.URL = urls[medias[i].Id]
+			related[medias[i].Id] = response
+		}
+	default:
+		target, err := s.collectionRepository.FindBySlug(ctx, relatesTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find related collection %q: %w", relatesTo, err)
+		}
+
+		resources, err := s.collectionRepository.FindResourcesByIds(ctx, target, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		if publishedOnly {
+			resources = slices.DeleteFunc(resources, func(resource Resource) bool {
+				return resource.Status != StatusPublished
+			})
+		}
+
+		if err := s.populate(ctx, target, resources, children, publishedOnly); err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			related[resource.Id] = resource
+		}
+	}
+
+	return related, nil
+}
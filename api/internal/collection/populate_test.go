@@ -0,0 +1,228 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/media"
+	authMocks "github.com/mimsy-cms/mimsy/internal/mocks/auth"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	mediaMocks "github.com/mimsy-cms/mimsy/internal/mocks/media"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// =================================================================================================
+// Service Tests - PopulateResources
+// =================================================================================================
+
+func TestParsePopulate(t *testing.T) {
+	populate := collection.ParsePopulate("author, tags.category,tags.author,")
+
+	expected := collection.Populate{
+		"author": {},
+		"tags": {
+			"category": {},
+			"author":   {},
+		},
+	}
+
+	if !reflect.DeepEqual(populate, expected) {
+		t.Errorf("expected %v, got %v", expected, populate)
+	}
+
+	if populate.Depth() != 2 {
+		t.Errorf("expected depth 2, got %d", populate.Depth())
+	}
+}
+
+func TestService_PopulateResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	authors := collection.NewTestCollection("authors", mimsy_schema.CollectionFields{"name": {Type: "string"}})
+	tags := collection.NewTestCollection("tags", mimsy_schema.CollectionFields{"name": {Type: "string"}})
+
+	resources := []collection.Resource{
+		{Id: 1, Slug: "first", Fields: map[string]any{"author_id": int64(10)}},
+		{Id: 2, Slug: "second", Fields: map[string]any{"author_id": nil}},
+	}
+
+	mockRepo.EXPECT().FindBySlug(gomock.Any(), "authors").Return(authors, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), authors, []int64{10}).
+		Return([]collection.Resource{{Id: 10, Slug: "jane", Fields: map[string]any{"name": "Jane"}}}, nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), &collection.Relation{
+			JoinTable:    "posts_tags_relation_tags",
+			OwnerColumn:  "posts_id",
+			TargetColumn: "tags_id",
+		}, []int64{1, 2}).
+		Return(map[int64][]int64{1: {20, 21}}, nil)
+	mockRepo.EXPECT().FindBySlug(gomock.Any(), "tags").Return(tags, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), tags, []int64{20, 21}).
+		Return([]collection.Resource{
+			{Id: 20, Slug: "go", Fields: map[string]any{"name": "Go"}},
+			{Id: 21, Slug: "sql", Fields: map[string]any{"name": "SQL"}},
+		}, nil)
+
+	err := service.PopulateResources(context.Background(), posts, resources, collection.ParsePopulate("author,tags"), false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	author, ok := resources[0].Fields["author"].(collection.Resource)
+	if !ok || author.Slug != "jane" {
+		t.Errorf("expected author 'jane', got %v", resources[0].Fields["author"])
+	}
+	if resources[1].Fields["author"] != nil {
+		t.Errorf("expected no author, got %v", resources[1].Fields["author"])
+	}
+
+	if tags, ok := resources[0].Fields["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("expected 2 tags, got %v", resources[0].Fields["tags"])
+	}
+	if tags, ok := resources[1].Fields["tags"].([]any); !ok || len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", resources[1].Fields["tags"])
+	}
+}
+
+func TestService_PopulateResources_Media(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMediaService := mediaMocks.NewMockMediaService(ctrl)
+	service := collection.NewService(mockRepo, collection.WithMediaService(mockMediaService))
+
+	covers := []media.Media{{Id: 5, Name: "cover.png"}}
+	resources := []collection.Resource{
+		{Id: 1, Fields: map[string]any{"cover_id": int64(5)}},
+		{Id: 2, Fields: map[string]any{"cover_id": int64(5)}},
+	}
+
+	mockMediaService.EXPECT().GetByIds(gomock.Any(), []int64{5}).Return(covers, nil)
+	mockMediaService.EXPECT().GetTemporaryURLs(gomock.Any(), covers).Return(map[int64]string{5: "https://cdn.example.com/cover.png"}, nil)
+
+	err := service.PopulateResources(context.Background(), collection.NewTestCollection("posts", postsFields), resources, collection.ParsePopulate("cover"), false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	response, ok := resources[0].Fields["cover"].(media.MediaResponse)
+	if !ok {
+		t.Fatalf("expected media response, got %T", resources[0].Fields["cover"])
+	}
+	if response.URL != "https://cdn.example.com/cover.png" {
+		t.Errorf("expected temporary URL, got %q", response.URL)
+	}
+}
+
+func TestService_PopulateResources_User(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockUserService := authMocks.NewMockService(ctrl)
+	service := collection.NewService(mockRepo, collection.WithUserService(mockUserService))
+
+	pages := collection.NewTestCollection("pages", mimsy_schema.CollectionFields{"owner": {Type: "relation", RelatesTo: "<builtins.user>"}})
+	resources := []collection.Resource{{Id: 1, Fields: map[string]any{"owner_id": int64(3)}}}
+
+	mockUserService.EXPECT().FindUsersByIds(gomock.Any(), []int64{3}).
+		Return([]auth.User{{ID: 3, Email: "jane@example.com", IsAdmin: true}}, nil)
+
+	err := service.PopulateResources(context.Background(), pages, resources, collection.ParsePopulate("owner"), false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := auth.PublicUser{ID: 3}
+	if owner := resources[0].Fields["owner"]; owner != expected {
+		t.Errorf("expected only the public user %v, got %v", expected, owner)
+	}
+}
+
+func TestService_PopulateResources_InvalidField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+	resources := []collection.Resource{{Id: 1, Fields: map[string]any{}}}
+
+	err := service.PopulateResources(context.Background(), collection.NewTestCollection("posts", postsFields), resources, collection.ParsePopulate("title"), false)
+	if !errors.Is(err, collection.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestService_PopulateResources_MaxDepth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl), collection.WithMaxPopulateDepth(1))
+	resources := []collection.Resource{{Id: 1, Fields: map[string]any{}}}
+
+	err := service.PopulateResources(context.Background(), collection.NewTestCollection("posts", postsFields), resources, collection.ParsePopulate("tags.author"), false)
+	if !errors.Is(err, collection.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestGetResource_WithPopulate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResource(gomock.Any(), mockCollection, "test-resource").
+		Return(mockResource, nil)
+
+	mockService.EXPECT().
+		PopulateResources(gomock.Any(), mockCollection, gomock.Any(), collection.Populate{"author": {}}, true).
+		DoAndReturn(func(ctx context.Context, c *collection.Collection, resources []collection.Resource, populate collection.Populate, publishedOnly bool) error {
+			resources[0].Fields["author"] = map[string]any{"slug": "jane"}
+			return nil
+		})
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource?populate=author", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+
+	w := executeRequest(http.HandlerFunc(handler.GetResource), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if author, ok := response["author"].(map[string]any); !ok || author["slug"] != "jane" {
+		t.Errorf("expected populated author, got %v", response["author"])
+	}
+}
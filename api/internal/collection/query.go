@@ -3,6 +3,7 @@ package collection
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -17,24 +18,30 @@ type rowScanner interface {
 
 type selectQuery struct {
 	tableName   string
+	fields      mimsy_schema.CollectionFields
 	queryFields []string
+	codecs      map[string]Codec
+	blocks      map[string]*blocksTable
 }
 
 func NewSelectQuery(tableName string, fields mimsy_schema.CollectionFields) *selectQuery {
 	return &selectQuery{
 		tableName:   tableName,
+		fields:      fields,
 		queryFields: transformQueryFields(fields),
+		codecs:      columnCodecs(fields),
+		blocks:      blocksTables(tableName, fields),
 	}
 }
 
 var (
 	// defaultColumns are the columns that will always be selected in a query.
-	defaultColumns  = []string{"id", "slug", "created_at", "created_by", "updated_at", "updated_by"}
-	readOnlyColumns = []string{"id", "created_at", "created_by", "updated_at", "updated_by"}
+	defaultColumns  = []string{"id", "slug", "created_at", "created_by", "updated_at", "updated_by", "status", "published_at", "deleted_at", "publish_at", "unpublish_at"}
+	readOnlyColumns = []string{"id", "created_at", "created_by", "updated_at", "updated_by", "status", "published_at", "deleted_at", "publish_at", "unpublish_at"}
 )
 
 func (q *selectQuery) FindOne(ctx context.Context, slug string) (*Resource, error) {
-	query, args, err := q.buildSelectQuery(q.tableName, slug)
+	query, args, err := q.buildSelectQuery(q.tableName, nil, slug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build select SQL query: %w", err)
 	}
@@ -48,15 +55,23 @@ func (q *selectQuery) FindOne(ctx context.Context, slug string) (*Resource, erro
 	if err != nil {
 		return nil, err
 	}
+
+	if err := loadBlocks(ctx, q.blocks, []*Resource{resource}); err != nil {
+		return nil, err
+	}
 	return resource, nil
 }
 
-func (q *selectQuery) FindAll(ctx context.Context) ([]Resource, error) {
-	query, args, err := q.buildSelectQuery(q.tableName)
+func (q *selectQuery) FindAll(ctx context.Context, params *FindResourcesParams) ([]Resource, error) {
+	query, args, err := q.buildSelectQuery(q.tableName, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build select SQL query: %w", err)
 	}
 
+	return q.findAll(ctx, query, args)
+}
+
+func (q *selectQuery) findAll(ctx context.Context, query string, args []any) ([]Resource, error) {
 	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
@@ -74,9 +89,41 @@ func (q *selectQuery) FindAll(ctx context.Context) ([]Resource, error) {
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating over rows: %w", err)
 	}
+
+	pointers := make([]*Resource, len(resources))
+	for i := range resources {
+		pointers[i] = &resources[i]
+	}
+	if err := loadBlocks(ctx, q.blocks, pointers); err != nil {
+		return nil, err
+	}
 	return resources, nil
 }
 
+// FindByIds returns the resources with the given identifiers.
+func (q *selectQuery) FindByIds(ctx context.Context, ids []int64) ([]Resource, error) {
+	query, args, err := q.buildSelectQuery(q.tableName, &FindResourcesParams{ids: ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select SQL query: %w", err)
+	}
+
+	return q.findAll(ctx, query, args)
+}
+
+// Count returns the number of resources matching the filters of the params, ignoring pagination.
+func (q *selectQuery) Count(ctx context.Context, params *FindResourcesParams) (int64, error) {
+	query, args, err := q.buildCountQuery(q.tableName, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build count SQL query: %w", err)
+	}
+
+	var count int64
+	if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to execute count query: %w", err)
+	}
+	return count, nil
+}
+
 func (q *selectQuery) scan(row rowScanner) (*Resource, error) {
 	values := make([]any, len(q.queryFields))
 	valuesPtrs := make([]any, len(q.queryFields))
@@ -89,8 +136,13 @@ func (q *selectQuery) scan(row rowScanner) (*Resource, error) {
 	}
 
 	resource := Resource{Fields: map[string]any{}}
-	for i := 6; i < len(values); i++ {
-		resource.Fields[q.queryFields[i]] = values[i]
+	for i := len(defaultColumns); i < len(values); i++ {
+		column := q.queryFields[i]
+		value, err := q.codecs[column].Decode(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode column %q: %w", column, err)
+		}
+		resource.Fields[column] = value
 	}
 
 	resource.Id = values[0].(int64)
@@ -99,12 +151,25 @@ func (q *selectQuery) scan(row rowScanner) (*Resource, error) {
 	resource.CreatedBy = values[3].(int64)
 	resource.UpdatedAt = values[4].(time.Time)
 	resource.UpdatedBy = values[5].(int64)
+	resource.Status = Status(values[6].(string))
+	if publishedAt, ok := values[7].(time.Time); ok {
+		resource.PublishedAt = &publishedAt
+	}
+	if deletedAt, ok := values[8].(time.Time); ok {
+		resource.DeletedAt = &deletedAt
+	}
+	if publishAt, ok := values[9].(time.Time); ok {
+		resource.PublishAt = &publishAt
+	}
+	if unpublishAt, ok := values[10].(time.Time); ok {
+		resource.UnpublishAt = &unpublishAt
+	}
 
 	return &resource, nil
 }
 
 func transformQueryFields(fields mimsy_schema.CollectionFields) []string {
-	queryFields := defaultColumns
+	queryFields := slices.Clone(defaultColumns)
 	for name, field := range fields {
 		if field.IsRelation() {
 			// For many-to-one relations, we store the foreign key as fieldname_id
@@ -112,14 +177,15 @@ func transformQueryFields(fields mimsy_schema.CollectionFields) []string {
 				queryFields = append(queryFields, fmt.Sprintf("%s_id", name))
 			}
 			// multi_relation types are handled differently (many-to-many)
-		} else {
+			// and blocks are stored in their own table
+		} else if field.Type != "blocks" {
 			queryFields = append(queryFields, name)
 		}
 	}
 	return queryFields
 }
 
-func (q *selectQuery) buildSelectQuery(tableName string, slug ...string) (string, []any, error) {
+func (q *selectQuery) buildSelectQuery(tableName string, params *FindResourcesParams, slug ...string) (string, []any, error) {
 	quotedQueryFields := make([]string, len(q.queryFields))
 	for i, field := range q.queryFields {
 		quotedQueryFields[i] = pq.QuoteIdentifier(field)
@@ -132,5 +198,70 @@ func (q *selectQuery) buildSelectQuery(tableName string, slug ...string) (string
 		b = b.Where(sq.Eq{"slug": slug[0]})
 	}
 
+	if params == nil {
+		return b.Where(sq.Eq{`"deleted_at"`: nil}).ToSql()
+	}
+
+	b, err := q.applyFilters(b, params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, sort := range params.Sort {
+		orderBy, err := sort.toSql(q.fields)
+		if err != nil {
+			return "", nil, err
+		}
+		b = b.OrderBy(orderBy)
+	}
+	// Always end with the identifier so that pages are stable.
+	b = b.OrderBy(`"id" ASC`)
+
+	if params.Limit > 0 {
+		b = b.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		b = b.Offset(params.Offset)
+	}
+
 	return b.ToSql()
 }
+
+func (q *selectQuery) buildCountQuery(tableName string, params *FindResourcesParams) (string, []any, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	b := psql.Select("COUNT(*)").From(pq.QuoteIdentifier(tableName))
+
+	if params != nil {
+		var err error
+		if b, err = q.applyFilters(b, params); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return b.ToSql()
+}
+
+func (q *selectQuery) applyFilters(b sq.SelectBuilder, params *FindResourcesParams) (sq.SelectBuilder, error) {
+	if params.Trashed {
+		b = b.Where(sq.NotEq{`"deleted_at"`: nil})
+	} else {
+		b = b.Where(sq.Eq{`"deleted_at"`: nil})
+	}
+
+	if params.ids != nil {
+		b = b.Where(sq.Eq{`"id"`: params.ids})
+	}
+
+	if params.PublishedOnly {
+		b = b.Where(sq.Eq{`"status"`: StatusPublished})
+	}
+
+	for _, filter := range params.Filters {
+		condition, err := filter.toSql(q.fields)
+		if err != nil {
+			return b, err
+		}
+		b = b.Where(condition)
+	}
+	return b, nil
+}
@@ -0,0 +1,166 @@
+package collection
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func newTestSelectQuery() *selectQuery {
+	return NewSelectQuery("posts", mimsy_schema.CollectionFields{
+		"title":   {Type: "string"},
+		"views":   {Type: "number"},
+		"body":    {Type: "rich_text"},
+		"author":  {Type: "relation", RelatesTo: "<builtins.user>"},
+		"tags":    {Type: "multi_relation", RelatesTo: "tags"},
+		"visible": {Type: "checkbox"},
+	})
+}
+
+func TestBuildSelectQuery_FiltersSortAndPagination(t *testing.T) {
+	q := newTestSelectQuery()
+	// Pin the selected fields, map iteration order is random.
+	q.queryFields = []string{"id", "slug"}
+
+	params, err := ParseFindResourcesParams(url.Values{
+		"where[title][contains]": {"fo%o"},
+		"where[views][gte]":      {"10"},
+		"where[author]":          {"3"},
+		"sort":                   {"-created_at,title"},
+		"limit":                  {"20"},
+		"offset":                 {"40"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	query, args, err := q.buildSelectQuery(q.tableName, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT "id", "slug" FROM "posts" WHERE "deleted_at" IS NULL AND "author_id" = $1 AND "title" ILIKE $2 AND "views" >= $3 ORDER BY "created_at" DESC, "title" ASC, "id" ASC LIMIT 20 OFFSET 40`
+	if query != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, query)
+	}
+
+	expectedArgs := []any{int64(3), `%fo\%o%`, float64(10)}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestBuildSelectQuery_Schedule(t *testing.T) {
+	q := newTestSelectQuery()
+	q.queryFields = []string{"id", "slug"}
+
+	params, err := ParseFindResourcesParams(url.Values{
+		"where[publish_at][lte]":      {"2024-03-01T09:00:00Z"},
+		"where[unpublish_at][exists]": {"true"},
+		"sort":                        {"publish_at"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	query, args, err := q.buildSelectQuery(q.tableName, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT "id", "slug" FROM "posts" WHERE "deleted_at" IS NULL AND "publish_at" <= $1 AND "unpublish_at" IS NOT NULL ORDER BY "publish_at" ASC, "id" ASC`
+	if query != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, query)
+	}
+
+	expectedArgs := []any{time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestBuildCountQuery_IgnoresPagination(t *testing.T) {
+	q := newTestSelectQuery()
+
+	params := &FindResourcesParams{
+		Filters: []Filter{{Field: "visible", Operator: OperatorExists, Value: "false"}},
+		Sort:    []Sort{{Field: "title"}},
+		Limit:   10,
+		Offset:  10,
+
+		PublishedOnly: true,
+	}
+
+	query, args, err := q.buildCountQuery(q.tableName, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT COUNT(*) FROM "posts" WHERE "deleted_at" IS NULL AND "status" = $1 AND "visible" IS NULL`
+	if query != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, query)
+	}
+	if !reflect.DeepEqual(args, []any{StatusPublished}) {
+		t.Errorf("expected the published status as argument, got %v", args)
+	}
+}
+
+func TestBuildSelectQuery_RejectsInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *FindResourcesParams
+	}{
+		{
+			name:   "unknown field",
+			params: &FindResourcesParams{Filters: []Filter{{Field: "unknown", Operator: OperatorEquals, Value: "x"}}},
+		},
+		{
+			name:   "operator not supported by type",
+			params: &FindResourcesParams{Filters: []Filter{{Field: "title", Operator: OperatorGt, Value: "x"}}},
+		},
+		{
+			name:   "unknown operator",
+			params: &FindResourcesParams{Filters: []Filter{{Field: "title", Operator: "like", Value: "x"}}},
+		},
+		{
+			name:   "invalid number",
+			params: &FindResourcesParams{Filters: []Filter{{Field: "views", Operator: OperatorEquals, Value: "ten"}}},
+		},
+		{
+			name:   "rich text field",
+			params: &FindResourcesParams{Filters: []Filter{{Field: "body", Operator: OperatorEquals, Value: "x"}}},
+		},
+		{
+			name:   "sort on multi relation",
+			params: &FindResourcesParams{Sort: []Sort{{Field: "tags"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestSelectQuery()
+
+			_, _, err := q.buildSelectQuery(q.tableName, tt.params)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseFindResourcesParams_InvalidPagination(t *testing.T) {
+	for _, values := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"abc"}},
+		{"offset": {"-1"}},
+		{"sort": {"title,"}},
+	} {
+		if _, err := ParseFindResourcesParams(values); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("expected ErrInvalidQuery for %v, got %v", values, err)
+		}
+	}
+}
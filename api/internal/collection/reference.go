@@ -0,0 +1,248 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// ReferenceGroup lists the resources of a collection referencing a resource through one of their fields.
+type ReferenceGroup struct {
+	Collection string `json:"collection"`
+	// Field is the relation field, those of the blocks are given by their path `<field>.<block type>.<field>`.
+	Field string `json:"field"`
+	// OnDelete is the behaviour of the field when the referenced resource is purged.
+	OnDelete mimsy_schema.OnDeleteAction `json:"onDelete,omitempty"`
+	// Total is the number of referencing resources, of which at most MaxLimit are returned.
+	Total     int        `json:"total"`
+	Resources []Resource `json:"resources"`
+}
+
+// ReferencedError is returned when a resource cannot be deleted because other resources reference it.
+// It matches ErrReferenced.
+type ReferencedError struct {
+	// References are the resources preventing the deletion.
+	References []ReferenceGroup `json:"references"`
+}
+
+func (e *ReferencedError) Error() string {
+	return ErrReferenced.Error()
+}
+
+func (e *ReferencedError) Is(target error) bool {
+	return target == ErrReferenced
+}
+
+// FindReferences returns the resources referencing a resource, grouped by collection and field.
+// The target is the slug of a collection or a builtin reference such as `<builtins.media>`, as in the
+// `relatesTo` of the relation fields. Resources in the trash are ignored.
+func (s *service) FindReferences(ctx context.Context, target string, id int64) ([]ReferenceGroup, error) {
+	return s.findReferences(ctx, target, id, func(mimsy_schema.SchemaElement) bool { return true })
+}
+
+// findReferences returns the references through the relation fields accepted by the filter.
+func (s *service) findReferences(ctx context.Context, target string, id int64, filter func(element mimsy_schema.SchemaElement) bool) ([]ReferenceGroup, error) {
+	collections, err := s.collectionRepository.FindAll(ctx, &FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	globals, err := s.collectionRepository.FindAllGlobals(ctx, &FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	groups := []ReferenceGroup{}
+	for _, collection := range append(collections, globals...) {
+		fields := mimsy_schema.CollectionFields{}
+		if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of %s: %w", collection.Slug, err)
+		}
+
+		for _, field := range relationFields("", fields) {
+			name, element := field.path, field.element
+			if element.RelatesTo != target || !filter(element) {
+				continue
+			}
+
+			ids, err := s.collectionRepository.FindReferencingIds(ctx, &collection, name, element, id)
+			if err != nil {
+				return nil, err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+
+			resources, err := s.collectionRepository.FindResourcesByIds(ctx, &collection, ids[:min(len(ids), MaxLimit)])
+			if err != nil {
+				return nil, err
+			}
+
+			groups = append(groups, ReferenceGroup{
+				Collection: collection.Slug,
+				Field:      name,
+				OnDelete:   element.OnDelete,
+				Total:      len(ids),
+				Resources:  resources,
+			})
+		}
+	}
+
+	slices.SortStableFunc(groups, func(a, b ReferenceGroup) int {
+		return strings.Compare(a.Collection, b.Collection)
+	})
+
+	return groups, nil
+}
+
+// relationField is a relation field of a collection, or of one of its block types.
+type relationField struct {
+	path    string
+	element mimsy_schema.SchemaElement
+}
+
+// relationFields returns the relation fields in a stable order, those of the block types included
+// with their path, nested blocks included.
+func relationFields(prefix string, fields mimsy_schema.CollectionFields) []relationField {
+	relations := []relationField{}
+	for _, name := range sortedKeys(fields) {
+		element := fields[name]
+		switch {
+		case element.IsRelation():
+			relations = append(relations, relationField{path: prefix + name, element: element})
+		case element.Type == "blocks":
+			blocks := element.GetBlocks()
+			for _, blockType := range sortedKeys(blocks) {
+				relations = append(relations, relationFields(fmt.Sprintf("%s%s.%s.", prefix, name, blockType), blocks[blockType])...)
+			}
+		}
+	}
+	return relations
+}
+
+// checkRestrictedReferences returns a *ReferencedError when a resource is referenced through relation fields
+// restricting its deletion.
+func (s *service) checkRestrictedReferences(ctx context.Context, collection *Collection, id int64) error {
+	references, err := s.findReferences(ctx, collection.Slug, id, func(element mimsy_schema.SchemaElement) bool {
+		return element.OnDelete == mimsy_schema.OnDeleteRestrict
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find references: %w", err)
+	}
+	if len(references) > 0 {
+		return &ReferencedError{References: references}
+	}
+	return nil
+}
+
+// referencedError returns the error of a resource whose deletion failed with ErrReferenced, listing the references
+// through the relation fields that neither cascade nor set null on delete. The error is returned as is when they
+// cannot be listed, as within a transaction aborted by the failure.
+func (s *service) referencedError(ctx context.Context, collection *Collection, id int64, err error) error {
+	references, findErr := s.findReferences(ctx, collection.Slug, id, func(element mimsy_schema.SchemaElement) bool {
+		return element.OnDelete != mimsy_schema.OnDeleteCascade && element.OnDelete != mimsy_schema.OnDeleteSetNull
+	})
+	if findErr != nil {
+		return err
+	}
+	return &ReferencedError{References: references}
+}
+
+// FindReferencingIds returns the identifiers of the resources of a collection referencing a resource
+// through a relation field, the resources in the trash are ignored. The relation fields of the blocks
+// are given by their path, `<field>.<block type>.<field>`.
+func (r *repository) FindReferencingIds(ctx context.Context, collection *Collection, fieldName string, element mimsy_schema.SchemaElement, targetId int64) ([]int64, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	var b sq.SelectBuilder
+	switch {
+	case strings.Contains(fieldName, "."):
+		var err error
+		if b, err = blockReferencesQuery(collection.Slug, fieldName, element, targetId); err != nil {
+			return nil, err
+		}
+	case element.Type == "relation":
+		b = psql.
+			Select(`"id"`).
+			From(pq.QuoteIdentifier(collection.Slug)).
+			Where(sq.Eq{pq.QuoteIdentifier(fmt.Sprintf("%s_id", fieldName)): targetId, `"deleted_at"`: nil}).
+			OrderBy(`"id"`)
+	case element.Type == "multi_relation":
+		relation, err := NewRelation(collection.Slug, fieldName, element)
+		if err != nil {
+			return nil, err
+		}
+
+		owner := fmt.Sprintf("r.%s", pq.QuoteIdentifier(relation.OwnerColumn))
+		b = psql.
+			Select(owner).
+			From(fmt.Sprintf("%s r", pq.QuoteIdentifier(relation.JoinTable))).
+			Join(fmt.Sprintf(`%s o ON o."id" = %s`, pq.QuoteIdentifier(collection.Slug), owner)).
+			Where(sq.Eq{fmt.Sprintf("r.%s", pq.QuoteIdentifier(relation.TargetColumn)): targetId, `o."deleted_at"`: nil}).
+			OrderBy(owner)
+	default:
+		return nil, fmt.Errorf("field %q is not a relation", fieldName)
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build references SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query references of %s.%s: %w", collection.Slug, fieldName, err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan reference row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reference rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// blockReferencesQuery returns the query of the resources referencing a resource through a relation field of
+// a block type, given by its path. The blocks are joined up to the resources owning them.
+func blockReferencesQuery(collectionSlug string, path string, element mimsy_schema.SchemaElement, targetId int64) (sq.SelectBuilder, error) {
+	segments := strings.Split(path, ".")
+	if len(segments)%2 == 0 || element.Type != "relation" {
+		return sq.SelectBuilder{}, fmt.Errorf("field %q is not a relation of a block", path)
+	}
+
+	// The tables of the blocks, from the outermost one
+	tables := []string{schema_generator.GetBlocksTableName(collectionSlug, segments[0])}
+	for i := 1; i+2 < len(segments); i += 2 {
+		tables = append(tables, schema_generator.GetBlocksTableName(tables[len(tables)-1], schema_generator.GetBlockColumnName(segments[i], segments[i+1])))
+	}
+	column := schema_generator.GetBlockColumnName(segments[len(segments)-2], fmt.Sprintf("%s_id", segments[len(segments)-1]))
+
+	b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`o."id"`).
+		Distinct().
+		From(fmt.Sprintf("%s b%d", pq.QuoteIdentifier(tables[len(tables)-1]), len(tables)-1))
+	for i := len(tables) - 2; i >= 0; i-- {
+		b = b.Join(fmt.Sprintf(`%s b%d ON b%d."id" = b%d.%s`, pq.QuoteIdentifier(tables[i]), i, i, i+1, pq.QuoteIdentifier(schema_generator.BlockParentColumn)))
+	}
+
+	return b.
+		Join(fmt.Sprintf(`%s o ON o."id" = b0.%s`, pq.QuoteIdentifier(collectionSlug), pq.QuoteIdentifier(schema_generator.BlockParentColumn))).
+		Where(sq.Eq{fmt.Sprintf("b%d.%s", len(tables)-1, pq.QuoteIdentifier(column)): targetId, `o."deleted_at"`: nil}).
+		OrderBy(`o."id"`), nil
+}
@@ -0,0 +1,265 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// =================================================================================================
+// References Tests
+// =================================================================================================
+
+func TestService_FindReferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	pages := collection.NewTestCollection("pages", mimsy_schema.CollectionFields{
+		"hero":  {Type: "relation", RelatesTo: "<builtins.media>"},
+		"title": {Type: "string"},
+		"content": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{Blocks: map[string]mimsy_schema.CollectionFields{
+			"gallery": {"image": {Type: "relation", RelatesTo: "<builtins.media>"}},
+		}}},
+	})
+	settings := collection.NewTestGlobal("settings", mimsy_schema.CollectionFields{
+		"logo": {Type: "relation", RelatesTo: "<builtins.media>"},
+	})
+
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*pages, *posts}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{*settings}, nil)
+
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "hero", gomock.Any(), int64(9)).
+		Return([]int64{1, 2}, nil)
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "cover", gomock.Any(), int64(9)).
+		Return([]int64{}, nil)
+	// The relations of the blocks are given by their path
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "content.gallery.image", gomock.Any(), int64(9)).
+		Return([]int64{2}, nil)
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "logo", gomock.Any(), int64(9)).
+		Return([]int64{1}, nil)
+
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), gomock.Any(), []int64{1, 2}).
+		Return([]collection.Resource{{Id: 1, Slug: "home"}, {Id: 2, Slug: "about"}}, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), gomock.Any(), []int64{2}).
+		Return([]collection.Resource{{Id: 2, Slug: "about"}}, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return([]collection.Resource{{Id: 1, Slug: "settings"}}, nil)
+
+	groups, err := service.FindReferences(context.Background(), "<builtins.media>", 9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []collection.ReferenceGroup{
+		{Collection: "pages", Field: "content.gallery.image", Total: 1, Resources: []collection.Resource{{Id: 2, Slug: "about"}}},
+		{Collection: "pages", Field: "hero", Total: 2, Resources: []collection.Resource{{Id: 1, Slug: "home"}, {Id: 2, Slug: "about"}}},
+		{Collection: "settings", Field: "logo", Total: 1, Resources: []collection.Resource{{Id: 1, Slug: "settings"}}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %+v, got %+v", expected, groups)
+	}
+}
+
+func TestGetReferences_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	tags := collection.NewTestCollection("tags", mimsy_schema.CollectionFields{})
+	groups := []collection.ReferenceGroup{{Collection: "posts", Field: "tags", Total: 1, Resources: []collection.Resource{}}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "tags").Return(tags, nil)
+	mockService.EXPECT().FindResource(gomock.Any(), tags, "go").Return(&collection.Resource{Id: 4, Slug: "go"}, nil)
+	mockService.EXPECT().FindReferences(gomock.Any(), "tags", int64(4)).Return(groups, nil)
+
+	req := httptest.NewRequest("GET", "/collections/tags/go/references", nil)
+	req.SetPathValue("slug", "tags")
+	req.SetPathValue("resourceSlug", "go")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.GetReferences), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	var response []collection.ReferenceGroup
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response) != 1 || response[0].Field != "tags" {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestGetReferences_Builtin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockService.EXPECT().FindReferences(gomock.Any(), "<builtins.user>", int64(3)).Return([]collection.ReferenceGroup{}, nil)
+
+	req := httptest.NewRequest("GET", "/collections/%3Cbuiltins.user%3E/3/references", nil)
+	req.SetPathValue("slug", "<builtins.user>")
+	req.SetPathValue("resourceSlug", "3")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.GetReferences), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status OK, got %v", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/collections/%3Cbuiltins.user%3E/admin/references", nil)
+	req.SetPathValue("slug", "<builtins.user>")
+	req.SetPathValue("resourceSlug", "admin")
+	req = addUserToContext(req, createMockUser())
+
+	if w := executeRequest(http.HandlerFunc(handler.GetReferences), req, t); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request for a builtin slug, got %v", w.Code)
+	}
+}
+
+// =================================================================================================
+// OnDelete Tests
+// =================================================================================================
+
+func TestService_DeleteResource_Restricted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	authors := collection.NewTestCollection("authors", mimsy_schema.CollectionFields{})
+	books := collection.NewTestCollection("books", mimsy_schema.CollectionFields{
+		"author": {Type: "relation", RelatesTo: "authors", OnDelete: mimsy_schema.OnDeleteRestrict},
+		"editor": {Type: "relation", RelatesTo: "authors"},
+	})
+	resource := &collection.Resource{Id: 4, Slug: "tolkien"}
+
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*books}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+
+	// Only the fields restricting the deletion are looked up
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "author", gomock.Any(), int64(4)).
+		Return([]int64{1}, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return([]collection.Resource{{Id: 1, Slug: "the-hobbit"}}, nil)
+
+	err := service.DeleteResource(context.Background(), authors, resource, 5)
+
+	var referencedErr *collection.ReferencedError
+	if !errors.As(err, &referencedErr) || !errors.Is(err, collection.ErrReferenced) {
+		t.Fatalf("expected a referenced error, got %v", err)
+	}
+
+	expected := []collection.ReferenceGroup{{
+		Collection: "books",
+		Field:      "author",
+		OnDelete:   mimsy_schema.OnDeleteRestrict,
+		Total:      1,
+		Resources:  []collection.Resource{{Id: 1, Slug: "the-hobbit"}},
+	}}
+	if !reflect.DeepEqual(referencedErr.References, expected) {
+		t.Errorf("expected references %+v, got %+v", expected, referencedErr.References)
+	}
+}
+
+func TestService_PurgeResource_Referenced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	authors := collection.NewTestCollection("authors", mimsy_schema.CollectionFields{})
+	books := collection.NewTestCollection("books", mimsy_schema.CollectionFields{
+		"author": {Type: "relation", RelatesTo: "authors"},
+		"editor": {Type: "relation", RelatesTo: "authors", OnDelete: mimsy_schema.OnDeleteSetNull},
+	})
+
+	mockRepo.EXPECT().PurgeResource(gomock.Any(), authors, "tolkien").Return(collection.ErrReferenced)
+	mockRepo.EXPECT().
+		FindResources(gomock.Any(), authors, gomock.Any()).
+		Return([]collection.Resource{{Id: 4, Slug: "tolkien"}}, int64(1), nil)
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*books}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+	mockRepo.EXPECT().
+		FindReferencingIds(gomock.Any(), gomock.Any(), "author", gomock.Any(), int64(4)).
+		Return([]int64{1}, nil)
+	mockRepo.EXPECT().
+		FindResourcesByIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return([]collection.Resource{{Id: 1, Slug: "the-hobbit"}}, nil)
+
+	err := service.PurgeResource(context.Background(), authors, "tolkien")
+
+	var referencedErr *collection.ReferencedError
+	if !errors.As(err, &referencedErr) {
+		t.Fatalf("expected a referenced error, got %v", err)
+	}
+	if len(referencedErr.References) != 1 || referencedErr.References[0].Field != "author" {
+		t.Errorf("expected the author references, got %+v", referencedErr.References)
+	}
+}
+
+func TestDeleteResource_Referenced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	authors := collection.NewTestCollection("authors", mimsy_schema.CollectionFields{})
+	resource := &collection.Resource{Id: 4, Slug: "tolkien"}
+	referencedErr := &collection.ReferencedError{References: []collection.ReferenceGroup{{
+		Collection: "books",
+		Field:      "author",
+		OnDelete:   mimsy_schema.OnDeleteRestrict,
+		Total:      1,
+		Resources:  []collection.Resource{},
+	}}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "authors").Return(authors, nil)
+	mockService.EXPECT().FindResource(gomock.Any(), authors, "tolkien").Return(resource, nil)
+	mockService.EXPECT().DeleteResource(gomock.Any(), authors, resource, gomock.Any()).Return(referencedErr)
+
+	req := httptest.NewRequest("DELETE", "/collections/authors/tolkien", nil)
+	req.SetPathValue("slug", "authors")
+	req.SetPathValue("resourceSlug", "tolkien")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.DeleteResource), req, t)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status Conflict, got %v", w.Code)
+	}
+
+	var response collection.ReferencedError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.References) != 1 || response.References[0].OnDelete != mimsy_schema.OnDeleteRestrict {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package collection
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func TestFindReferencingIds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	posts := &Collection{Slug: "posts"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "posts" WHERE "author_id" = $1 AND "deleted_at" IS NULL ORDER BY "id"`)).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)).AddRow(int64(8)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT r."posts_id" FROM "posts_tags_relation_tags" r JOIN "posts" o ON o."id" = r."posts_id" WHERE o."deleted_at" IS NULL AND r."tags_id" = $1 ORDER BY r."posts_id"`)).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"posts_id"}).AddRow(int64(5)))
+
+	ids, err := NewRepository().FindReferencingIds(ctx, posts, "author", mimsy_schema.SchemaElement{Type: "relation", RelatesTo: "<builtins.user>"}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{5, 8}) {
+		t.Errorf("expected [5 8], got %v", ids)
+	}
+
+	ids, err = NewRepository().FindReferencingIds(ctx, posts, "tags", mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "tags"}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{5}) {
+		t.Errorf("expected [5], got %v", ids)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFindReferencingIds_Blocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	pages := &Collection{Slug: "pages"}
+	image := mimsy_schema.SchemaElement{Type: "relation", RelatesTo: "<builtins.media>"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT o."id" FROM "pages_content_blocks" b0 JOIN "pages" o ON o."id" = b0."parent_id" WHERE b0."hero__image_id" = $1 AND o."deleted_at" IS NULL ORDER BY o."id"`)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	// The nested blocks are joined up to the resources
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT o."id" FROM "pages_content_blocks_columns__items_blocks" b1 JOIN "pages_content_blocks" b0 ON b0."id" = b1."parent_id" JOIN "pages" o ON o."id" = b0."parent_id" WHERE b1."card__image_id" = $1 AND o."deleted_at" IS NULL ORDER BY o."id"`)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)).AddRow(int64(9)))
+
+	ids, err := NewRepository().FindReferencingIds(ctx, pages, "content.hero.image", image, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{7}) {
+		t.Errorf("expected [7], got %v", ids)
+	}
+
+	ids, err = NewRepository().FindReferencingIds(ctx, pages, "content.columns.items.card.image", image, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{7, 9}) {
+		t.Errorf("expected [7 9], got %v", ids)
+	}
+
+	if _, err := NewRepository().FindReferencingIds(ctx, pages, "content.hero", image, 4); err == nil {
+		t.Error("expected an error for a path not ending with a field")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
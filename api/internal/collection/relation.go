@@ -0,0 +1,320 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/postgres"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// Relation describes the join table of a many-to-many relation.
+type Relation struct {
+	// JoinTable is the name of the join table, `<collection>_<field>_relation_<target>`.
+	JoinTable string
+	// OwnerColumn references the resource owning the relation.
+	OwnerColumn string
+	// TargetColumn references the related resource.
+	TargetColumn string
+}
+
+// NewRelation returns the relation stored by a multi_relation field of a collection.
+func NewRelation(collectionSlug string, fieldName string, element mimsy_schema.SchemaElement) (*Relation, error) {
+	joinTable, err := schema_generator.GetRelationTableName(&element, collectionSlug, fieldName)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTable, err := schema_generator.GetSimpleTableName(element.RelatesTo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Relation{
+		JoinTable:    joinTable,
+		OwnerColumn:  fmt.Sprintf("%s_id", collectionSlug),
+		TargetColumn: fmt.Sprintf("%s_id", targetTable),
+	}, nil
+}
+
+func (r *repository) FindRelationIds(ctx context.Context, relation *Relation, ownerIds []int64) (map[int64][]int64, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(pq.QuoteIdentifier(relation.OwnerColumn), pq.QuoteIdentifier(relation.TargetColumn)).
+		From(pq.QuoteIdentifier(relation.JoinTable)).
+		Where(sq.Eq{pq.QuoteIdentifier(relation.OwnerColumn): ownerIds}).
+		OrderBy(pq.QuoteIdentifier(relation.TargetColumn)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build relation SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query relation %s: %w", relation.JoinTable, err)
+	}
+	defer rows.Close()
+
+	relations := make(map[int64][]int64)
+	for rows.Next() {
+		var ownerId, targetId int64
+		if err := rows.Scan(&ownerId, &targetId); err != nil {
+			return nil, fmt.Errorf("failed to scan relation row: %w", err)
+		}
+		relations[ownerId] = append(relations[ownerId], targetId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over relation rows: %w", err)
+	}
+
+	return relations, nil
+}
+
+// setRelation stores the targets given for a multi_relation field of a resource.
+// The value is a list of identifiers or slugs of the related resources, the links that
+// already exist are kept, the new ones are inserted and the missing ones are deleted.
+// It must be called within a transaction.
+func (r *repository) setRelation(ctx context.Context, collection *Collection, fieldName string, element mimsy_schema.SchemaElement, ownerId int64, value any) error {
+	relation, err := NewRelation(collection.Slug, fieldName, element)
+	if err != nil {
+		return err
+	}
+
+	targetIds, err := resolveRelationIds(ctx, fieldName, element, value)
+	if err != nil {
+		return err
+	}
+
+	existing, err := r.FindRelationIds(ctx, relation, []int64{ownerId})
+	if err != nil {
+		return err
+	}
+	currentIds := existing[ownerId]
+
+	toInsert := []int64{}
+	for _, id := range targetIds {
+		if !slices.Contains(currentIds, id) && !slices.Contains(toInsert, id) {
+			toInsert = append(toInsert, id)
+		}
+	}
+
+	toDelete := []int64{}
+	for _, id := range currentIds {
+		if !slices.Contains(targetIds, id) {
+			toDelete = append(toDelete, id)
+		}
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	if len(toDelete) > 0 {
+		query, args, err := psql.
+			Delete(pq.QuoteIdentifier(relation.JoinTable)).
+			Where(sq.Eq{pq.QuoteIdentifier(relation.OwnerColumn): ownerId}).
+			Where(sq.Eq{pq.QuoteIdentifier(relation.TargetColumn): toDelete}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build relation delete SQL query: %w", err)
+		}
+
+		if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to delete relations of field %q: %w", fieldName, err)
+		}
+	}
+
+	if len(toInsert) > 0 {
+		b := psql.
+			Insert(pq.QuoteIdentifier(relation.JoinTable)).
+			Columns(pq.QuoteIdentifier(relation.OwnerColumn), pq.QuoteIdentifier(relation.TargetColumn))
+		for _, id := range toInsert {
+			b = b.Values(ownerId, id)
+		}
+
+		query, args, err := b.ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build relation insert SQL query: %w", err)
+		}
+
+		if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+			if postgres.IsErrCode(err, postgres.ErrForeignKeyViolation) {
+				return fmt.Errorf("%w: field %q references a resource that does not exist", ErrInvalidContent, fieldName)
+			}
+			return fmt.Errorf("failed to insert relations of field %q: %w", fieldName, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveRelationIds converts the value of a multi_relation field to the identifiers of the related resources.
+// Numbers are used as identifiers, and strings are looked up as slugs in the related collection.
+// Builtin collections have no slug, so only identifiers are accepted for them.
+func resolveRelationIds(ctx context.Context, fieldName string, element mimsy_schema.SchemaElement, value any) ([]int64, error) {
+	if value == nil {
+		return []int64{}, nil
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q must be a list of identifiers or slugs", ErrInvalidContent, fieldName)
+	}
+
+	ids := make([]int64, 0, len(values))
+	slugs := []string{}
+
+	for _, v := range values {
+		switch v := v.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%w: field %q contains an invalid identifier %v", ErrInvalidContent, fieldName, v)
+			}
+			ids = append(ids, int64(v))
+		case int64:
+			ids = append(ids, v)
+		case int:
+			ids = append(ids, int64(v))
+		case json.Number:
+			id, err := v.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %q contains an invalid identifier %v", ErrInvalidContent, fieldName, v)
+			}
+			ids = append(ids, id)
+		case string:
+			if schema_generator.IsBuiltin(element.RelatesTo) {
+				id, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w: field %q only accepts identifiers", ErrInvalidContent, fieldName)
+				}
+				ids = append(ids, id)
+			} else {
+				slugs = append(slugs, v)
+			}
+		default:
+			return nil, fmt.Errorf("%w: field %q must be a list of identifiers or slugs", ErrInvalidContent, fieldName)
+		}
+	}
+
+	if len(slugs) == 0 {
+		return ids, nil
+	}
+
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`"slug"`, `"id"`).
+		From(pq.QuoteIdentifier(element.RelatesTo)).
+		Where(sq.Eq{`"slug"`: slugs, `"deleted_at"`: nil}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build slug lookup SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up slugs of field %q: %w", fieldName, err)
+	}
+	defer rows.Close()
+
+	idsBySlug := make(map[string]int64, len(slugs))
+	for rows.Next() {
+		var slug string
+		var id int64
+		if err := rows.Scan(&slug, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan slug row: %w", err)
+		}
+		idsBySlug[slug] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over slug rows: %w", err)
+	}
+
+	for _, slug := range slugs {
+		id, ok := idsBySlug[slug]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q references unknown resource %q", ErrInvalidContent, fieldName, slug)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// FindExistingIds returns the given identifiers that exist in the collection, or builtin, the relation targets.
+func (r *repository) FindExistingIds(ctx context.Context, relatesTo string, ids []int64) ([]int64, error) {
+	tableName, err := schema_generator.GetSimpleTableName(relatesTo)
+	if err != nil {
+		return nil, err
+	}
+
+	b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`"id"`).
+		From(pq.QuoteIdentifier(tableName)).
+		Where(sq.Eq{`"id"`: ids})
+
+	// Builtin tables have no trash
+	if !schema_generator.IsBuiltin(relatesTo) {
+		b = b.Where(sq.Eq{`"deleted_at"`: nil})
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build identifier lookup SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up identifiers in %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	existing := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan identifier row: %w", err)
+		}
+		existing = append(existing, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over identifier rows: %w", err)
+	}
+
+	return existing, nil
+}
+
+// FindExistingSlugs returns the given slugs that exist in the collection the relation targets.
+func (r *repository) FindExistingSlugs(ctx context.Context, relatesTo string, slugs []string) ([]string, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`"slug"`).
+		From(pq.QuoteIdentifier(relatesTo)).
+		Where(sq.Eq{`"slug"`: slugs, `"deleted_at"`: nil}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build slug lookup SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up slugs in %s: %w", relatesTo, err)
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, fmt.Errorf("failed to scan slug row: %w", err)
+		}
+		existing = append(existing, slug)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over slug rows: %w", err)
+	}
+
+	return existing, nil
+}
@@ -0,0 +1,96 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func TestNewRelation(t *testing.T) {
+	relation, err := NewRelation("posts", "tags", mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "tags"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Relation{JoinTable: "posts_tags_relation_tags", OwnerColumn: "posts_id", TargetColumn: "tags_id"}
+	if *relation != expected {
+		t.Errorf("expected %+v, got %+v", expected, *relation)
+	}
+
+	relation, err = NewRelation("posts", "editors", mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "<builtins.user>"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = Relation{JoinTable: "posts_editors_relation_user", OwnerColumn: "posts_id", TargetColumn: "user_id"}
+	if *relation != expected {
+		t.Errorf("expected %+v, got %+v", expected, *relation)
+	}
+}
+
+func TestSetRelation_DiffsJoinTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	collection := &Collection{Slug: "posts"}
+	element := mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "tags"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "slug", "id" FROM "tags" WHERE "deleted_at" IS NULL AND "slug" IN ($1)`)).
+		WithArgs("sql").
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "id"}).AddRow("sql", int64(3)))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "posts_id", "tags_id" FROM "posts_tags_relation_tags" WHERE "posts_id" IN ($1) ORDER BY "tags_id"`)).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"posts_id", "tags_id"}).AddRow(int64(7), int64(1)).AddRow(int64(7), int64(2)))
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "posts_tags_relation_tags" WHERE "posts_id" = $1 AND "tags_id" IN ($2)`)).
+		WithArgs(int64(7), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "posts_tags_relation_tags" ("posts_id","tags_id") VALUES ($1,$2)`)).
+		WithArgs(int64(7), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := NewRepository()
+	if err := r.setRelation(ctx, collection, "tags", element, 7, []any{float64(2), "sql"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestResolveRelationIds_Invalid(t *testing.T) {
+	ctx := context.Background()
+	tags := mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "tags"}
+	users := mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "<builtins.user>"}
+
+	tests := []struct {
+		name    string
+		element mimsy_schema.SchemaElement
+		value   any
+	}{
+		{name: "not a list", element: tags, value: "go"},
+		{name: "decimal identifier", element: tags, value: []any{1.5}},
+		{name: "object", element: tags, value: []any{map[string]any{"id": 1}}},
+		{name: "slug for builtin", element: users, value: []any{"admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveRelationIds(ctx, "field", tt.element, tt.value); !errors.Is(err, ErrInvalidContent) {
+				t.Errorf("expected ErrInvalidContent, got %v", err)
+			}
+		})
+	}
+}
@@ -8,14 +8,15 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
-	"strings"
 	"time"
 
 	"github.com/lib/pq"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/postgres"
 	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
 )
 
 // Red: In case you are wondering why the collections are named `c` instead of `collection`
@@ -29,12 +30,30 @@ type Repository interface {
 	UpdateCollection(ctx context.Context, slug string, name string, fieldsJson []byte) error
 	DeleteCollection(ctx context.Context, slug string) error
 	FindResource(ctx context.Context, c *Collection, slug string) (*Resource, error)
-	FindResources(ctx context.Context, c *Collection) ([]Resource, error)
+	FindResources(ctx context.Context, c *Collection, params *FindResourcesParams) ([]Resource, int64, error)
 	FindAll(ctx context.Context, params *FindAllParams) ([]Collection, error)
 	FindAllGlobals(ctx context.Context, params *FindAllParams) ([]Collection, error)
 	CreateResource(ctx context.Context, c *Collection, resourceSlug string, createdBy int64, content map[string]any) (*Resource, error)
 	UpdateResource(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64, content map[string]any) (*Resource, error)
 	DeleteResource(ctx context.Context, resource *Resource) error
+	FindResourcesByIds(ctx context.Context, c *Collection, ids []int64) ([]Resource, error)
+	RestoreResource(ctx context.Context, c *Collection, resourceSlug string, restoredBy int64) (*Resource, error)
+	PurgeResource(ctx context.Context, c *Collection, resourceSlug string) error
+	PurgeTrash(ctx context.Context, c *Collection, before time.Time) (int64, error)
+	UpdateResourceStatus(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64, status Status) (*Resource, error)
+	FindRelationIds(ctx context.Context, relation *Relation, ownerIds []int64) (map[int64][]int64, error)
+	FindExistingIds(ctx context.Context, relatesTo string, ids []int64) ([]int64, error)
+	FindExistingSlugs(ctx context.Context, relatesTo string, slugs []string) ([]string, error)
+	FindReferencingIds(ctx context.Context, c *Collection, fieldName string, element mimsy_schema.SchemaElement, targetId int64) ([]int64, error)
+	AggregateResources(ctx context.Context, c *Collection, params *AggregateParams) ([]AggregateGroup, error)
+	CreateRevision(ctx context.Context, revision *Revision) error
+	FindRevisions(ctx context.Context, c *Collection, resourceSlug string) ([]Revision, error)
+	FindRevision(ctx context.Context, c *Collection, resourceSlug string, id int64) (*Revision, error)
+	UpdateResourceSchedule(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64, schedule Schedule) (*Resource, error)
+	ApplyScheduledTransitions(ctx context.Context, c *Collection, status Status, now time.Time, updatedBy int64) ([]ScheduledTransition, error)
+	FindScheduledTransitions(ctx context.Context, limit uint64) ([]ScheduledTransition, error)
+	FindLocales(ctx context.Context) ([]Locale, error)
+	SetLocales(ctx context.Context, locales []Locale) error
 }
 
 type repository struct{}
@@ -43,6 +62,16 @@ func NewRepository() *repository {
 	return &repository{}
 }
 
+// Status is the publication status of a resource.
+type Status string
+
+const (
+	// StatusDraft resources are only visible to authenticated users asking for drafts.
+	StatusDraft Status = "draft"
+	// StatusPublished resources are visible to everyone.
+	StatusPublished Status = "published"
+)
+
 type Collection struct {
 	Slug      string
 	Name      string
@@ -65,6 +94,16 @@ type Resource struct {
 	UpdatedAt time.Time
 	// UpdatedBy is the identifier of the user who last updated the resource.
 	UpdatedBy int64
+	// Status is whether the resource is a draft or is published.
+	Status Status
+	// PublishedAt is the timestamp when the resource was last published, nil for drafts.
+	PublishedAt *time.Time
+	// DeletedAt is the timestamp when the resource was moved to the trash, nil for the other resources.
+	DeletedAt *time.Time
+	// PublishAt is the timestamp when the resource is scheduled to be published, nil when not scheduled.
+	PublishAt *time.Time
+	// UnpublishAt is the timestamp when the resource is scheduled to go back to draft, nil when not scheduled.
+	UnpublishAt *time.Time
 	// Fields is a map of field names to their values.
 	Fields map[string]any
 	// Collection is the slug of the collection this resource belongs to.
@@ -72,7 +111,8 @@ type Resource struct {
 }
 
 // MarshalJSON implements the json.Marshaler interface for Resource.
-// We need this custom implementation to handle the conversion of byte slices in the Resource map to JSON.
+// The fields are decoded by the codec of their type when they are scanned, so they are written as is.
+// Byte slices of columns without a codec are written as strings.
 func (r Resource) MarshalJSON() ([]byte, error) {
 	transformed := make(map[string]any)
 
@@ -82,28 +122,16 @@ func (r Resource) MarshalJSON() ([]byte, error) {
 	transformed["updated_at"] = r.UpdatedAt
 	transformed["updated_by"] = r.UpdatedBy
 	transformed["created_by"] = r.CreatedBy
+	transformed["status"] = r.Status
+	transformed["published_at"] = r.PublishedAt
+	transformed["deleted_at"] = r.DeletedAt
+	transformed["publish_at"] = r.PublishAt
+	transformed["unpublish_at"] = r.UnpublishAt
 
 	for key, value := range r.Fields {
-		switch v := value.(type) {
-		case []byte:
-			var jsonObject any
-			if err := json.Unmarshal(v, &jsonObject); err != nil {
-				transformed[key] = string(v)
-			} else {
-				transformed[key] = jsonObject
-			}
-		case string:
-			if (strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}")) || (strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]")) {
-				var jsonObject any
-				if err := json.Unmarshal([]byte(v), &jsonObject); err == nil {
-					transformed[key] = jsonObject
-				} else {
-					transformed[key] = v
-				}
-			} else {
-				transformed[key] = v
-			}
-		default:
+		if v, ok := value.([]byte); ok {
+			transformed[key] = string(v)
+		} else {
 			transformed[key] = value
 		}
 	}
@@ -112,8 +140,10 @@ func (r Resource) MarshalJSON() ([]byte, error) {
 }
 
 var (
-	ErrNotFound      = errors.New("not found")
-	ErrAlreadyExists = errors.New("already exists")
+	ErrNotFound       = errors.New("not found")
+	ErrAlreadyExists  = errors.New("already exists")
+	ErrInvalidContent = errors.New("invalid content")
+	ErrReferenced     = errors.New("referenced by other resources")
 )
 
 func (r *repository) FindBySlug(ctx context.Context, slug string) (*Collection, error) {
@@ -164,13 +194,38 @@ func (r *repository) FindResource(ctx context.Context, collection *Collection, s
 	return resource, nil
 }
 
-func (r *repository) FindResources(ctx context.Context, collection *Collection) ([]Resource, error) {
+func (r *repository) FindResources(ctx context.Context, collection *Collection, params *FindResourcesParams) ([]Resource, int64, error) {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+
+	query := NewSelectQuery(collection.Slug, fields)
+
+	resources, err := query.FindAll(ctx, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find resources: %w", err)
+	}
+
+	total, err := query.Count(ctx, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count resources: %w", err)
+	}
+
+	for i := range resources {
+		resources[i].Collection = collection.Slug
+	}
+
+	return resources, total, nil
+}
+
+func (r *repository) FindResourcesByIds(ctx context.Context, collection *Collection, ids []int64) ([]Resource, error) {
 	fields := mimsy_schema.CollectionFields{}
 	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal fields: %w", err)
 	}
 
-	resources, err := NewSelectQuery(collection.Slug, fields).FindAll(ctx)
+	resources, err := NewSelectQuery(collection.Slug, fields).FindByIds(ctx, ids)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find resources: %w", err)
 	}
@@ -233,8 +288,10 @@ func (r *repository) CreateResource(ctx context.Context, collection *Collection,
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-	columns := []string{"slug", "created_at", "updated_at", "created_by", "updated_by"}
-	values := []any{resourceSlug, sq.Expr("NOW()"), sq.Expr("NOW()"), createdBy, createdBy}
+	columns := []string{"slug", "created_at", "updated_at", "created_by", "updated_by", "status"}
+	values := []any{resourceSlug, sq.Expr("NOW()"), sq.Expr("NOW()"), createdBy, createdBy, StatusDraft}
+	relations := map[string]any{}
+	blocks := map[string]any{}
 
 	for fieldName, fieldDef := range fields {
 		colName := fieldName
@@ -243,29 +300,97 @@ func (r *repository) CreateResource(ctx context.Context, collection *Collection,
 		case "relation":
 			colName = fmt.Sprintf("%s_id", fieldName)
 		case "multi_relation":
-			// TODO: Add support for multi relation
+			// Many-to-many relations are stored in their join table once the resource exists
+			if value, ok := content[fieldName]; ok {
+				relations[fieldName] = value
+			}
+			continue
+		case "blocks":
+			// Blocks are stored in their table once the resource exists
+			if value, ok := content[fieldName]; ok {
+				blocks[fieldName] = value
+			}
 			continue
 		}
 
+		value, err := elementCodec(fieldDef).Encode(content[colName])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", colName, err)
+		}
+
 		columns = append(columns, pq.QuoteIdentifier(colName))
-		values = append(values, content[colName])
+		values = append(values, value)
 	}
 
 	insertBuilder := psql.
 		Insert(pq.QuoteIdentifier(collection.Slug)).
 		Columns(columns...).
-		Values(values...)
+		Values(values...).
+		Suffix(`RETURNING "id"`)
 
 	query, args, err := insertBuilder.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build insert SQL query: %w", err)
 	}
 
-	if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
-		return nil, fmt.Errorf("failed to insert resource: %w", err)
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var id int64
+		if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			// The slug can still be used by a resource in the trash
+			if violation := uniqueViolation(err, collection, fields); violation != nil {
+				return violation
+			}
+			return fmt.Errorf("failed to insert resource: %w", err)
+		}
+
+		for fieldName, value := range relations {
+			if err := r.setRelation(ctx, collection, fieldName, fields[fieldName], id, value); err != nil {
+				return err
+			}
+		}
+
+		for fieldName, value := range blocks {
+			if err := newBlocksTable(collection.Slug, fieldName, fields[fieldName]).set(ctx, fieldName, id, value); err != nil {
+				return err
+			}
+		}
+
+		resource, err = r.FindResource(ctx, collection, resourceSlug)
+		return err
+	}); err != nil {
+		return nil, err
 	}
 
-	return r.FindResource(ctx, collection, resourceSlug)
+	return resource, nil
+}
+
+// uniqueViolation returns the error of a unique violation on a resource, a validation error when the value of a
+// unique field is already used and ErrAlreadyExists for the slug. It returns nil for the other errors.
+func uniqueViolation(err error, collection *Collection, fields mimsy_schema.CollectionFields) error {
+	if !postgres.IsErrCode(err, postgres.ErrUniqueViolation) {
+		return nil
+	}
+
+	violated := err.(*pq.Error).Constraint
+	for _, name := range sortedKeys(fields) {
+		element := fields[name]
+		if !element.IsUnique() {
+			continue
+		}
+
+		column := name
+		if element.Type == "relation" {
+			column = fmt.Sprintf("%s_id", name)
+		}
+
+		constraint := schema_generator.UniqueConstraint{Table: collection.Slug, Key: column}
+		if violated == constraint.Name() {
+			return &ValidationError{Errors: []FieldError{{Path: column, Reason: "is already used by another resource"}}}
+		}
+	}
+
+	return ErrAlreadyExists
 }
 
 func (r *repository) FindAllGlobals(ctx context.Context, params *FindAllParams) ([]Collection, error) {
@@ -298,68 +423,179 @@ func (r *repository) FindAllGlobals(ctx context.Context, params *FindAllParams)
 	return collections, nil
 }
 
+// DeleteResource moves a resource to the trash, see PurgeResource to delete it permanently.
+// The relations to the resource are kept, so the onDelete actions only apply once it is purged.
 func (r *repository) DeleteResource(ctx context.Context, resource *Resource) error {
-	query := fmt.Sprintf(`DELETE FROM "%s" WHERE slug = $1`, resource.Collection)
-
-	if _, err := config.GetDB(ctx).ExecContext(ctx, query, resource.Slug); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrNotFound
+	b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(pq.QuoteIdentifier(resource.Collection)).
+		Set("deleted_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"slug": resource.Slug, "deleted_at": nil})
+
+	precondition := preconditionFromContext(ctx)
+	if precondition != nil {
+		if where := precondition.where(); where != nil {
+			b = b.Where(where)
 		}
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete SQL query: %w", err)
+	}
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
 		return fmt.Errorf("failed to delete resource: %w", err)
 	}
 
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	} else if rows == 0 {
+		if precondition != nil {
+			return ErrPreconditionFailed
+		}
+		return ErrNotFound
+	}
+
 	return nil
 }
 
 func (r *repository) UpdateResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, content map[string]any) (*Resource, error) {
-	// Parse collection fields to identify rich text fields
 	fields := mimsy_schema.CollectionFields{}
 	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
 	}
+	codecs := columnCodecs(fields)
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
 	b := psql.
 		Update(pq.QuoteIdentifier(collection.Slug)).
-		Where(sq.Eq{"slug": resourceSlug}).
+		Where(sq.Eq{"slug": resourceSlug, "deleted_at": nil}).
 		Set("updated_at", sq.Expr("NOW()")).
 		Set("updated_by", updatedBy)
 
+	precondition := preconditionFromContext(ctx)
+	if precondition != nil {
+		if where := precondition.where(); where != nil {
+			b = b.Where(where)
+		}
+	}
+
+	relations := map[string]any{}
+	blocks := map[string]any{}
+
 	for field, value := range content {
 		// Skip read only columns that should not be updated
 		if slices.Contains(readOnlyColumns, field) {
 			continue
 		}
 
-		if fieldDef, exists := fields[field]; exists && fieldDef.Type == "richtext" {
-			jsonValue, err := json.Marshal(value)
+		// Keys that are not fields are ignored, as on creation
+		if _, exists := fieldOfKey(fields, field); !exists && field != "slug" {
+			continue
+		}
+
+		if fieldDef, exists := fields[field]; exists && fieldDef.Type == "multi_relation" {
+			relations[field] = value
+			continue
+		}
+
+		if fieldDef, exists := fields[field]; exists && fieldDef.Type == "blocks" {
+			blocks[field] = value
+			continue
+		}
+
+		if codec, exists := codecs[field]; exists {
+			encoded, err := codec.Encode(value)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal richtext field %q: %w", field, err)
+				return nil, fmt.Errorf("field %q: %w", field, err)
 			}
-
-			b = b.Set(pq.QuoteIdentifier(field), string(jsonValue))
-		} else {
-			b = b.Set(pq.QuoteIdentifier(field), value)
+			value = encoded
 		}
+
+		b = b.Set(pq.QuoteIdentifier(field), value)
 	}
 
-	query, args, err := b.ToSql()
+	query, args, err := b.Suffix(`RETURNING "id"`).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build update SQL query: %w", err)
 	}
 
-	_, err = config.GetDB(ctx).ExecContext(ctx, query, args...)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var id int64
+		if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				if precondition != nil {
+					return ErrPreconditionFailed
+				}
+				return ErrNotFound
+			}
+			if violation := uniqueViolation(err, collection, fields); violation != nil {
+				return violation
+			}
+			return fmt.Errorf("failed to update resource content: %w", err)
+		}
+
+		for fieldName, value := range relations {
+			if err := r.setRelation(ctx, collection, fieldName, fields[fieldName], id, value); err != nil {
+				return err
+			}
 		}
-		return nil, fmt.Errorf("failed to update resource content: %w", err)
+
+		for fieldName, value := range blocks {
+			if err := newBlocksTable(collection.Slug, fieldName, fields[fieldName]).set(ctx, fieldName, id, value); err != nil {
+				return err
+			}
+		}
+
+		resource, err = r.FindResource(ctx, collection, resourceSlug)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// UpdateResourceStatus publishes or unpublishes a resource.
+// Publishing sets the publication timestamp to now, and unpublishing clears it.
+func (r *repository) UpdateResourceStatus(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, status Status) (*Resource, error) {
+	var publishedAt any = nil
+	if status == StatusPublished {
+		publishedAt = sq.Expr("NOW()")
+	}
+
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(pq.QuoteIdentifier(collection.Slug)).
+		Set("status", status).
+		Set("published_at", publishedAt).
+		Set("updated_at", sq.Expr("NOW()")).
+		Set("updated_by", updatedBy).
+		Where(sq.Eq{"slug": resourceSlug, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build status update SQL query: %w", err)
+	}
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update resource status: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	} else if rows == 0 {
+		return nil, ErrNotFound
 	}
 
 	return r.FindResource(ctx, collection, resourceSlug)
 }
 
+// systemUser is the user the changes made by the CMS on its own are attributed to, the first admin.
+const systemUser int64 = 1
+
 func (r *repository) CreateCollection(ctx context.Context, slug string, name string, fieldsJson []byte, isGlobal bool) error {
 	query := `
 		INSERT INTO "collection" (slug, name, fields, created_at, updated_at, is_global)
@@ -378,13 +614,18 @@ func (r *repository) CreateCollection(ctx context.Context, slug string, name str
 			return fmt.Errorf("failed to find newly created collection: %w", err)
 		}
 
-		userID := int64(1)
+		userID := systemUser
 		content := make(map[string]any)
 
 		_, err = r.CreateResource(ctx, collection, slug, userID, content)
 		if err != nil {
 			return fmt.Errorf("failed to create resource for global collection: %w", err)
 		}
+
+		// The singleton is published, otherwise the global cannot be read anonymously
+		if _, err := r.UpdateResourceStatus(ctx, collection, slug, userID, StatusPublished); err != nil {
+			return fmt.Errorf("failed to publish resource for global collection: %w", err)
+		}
 	}
 
 	return nil
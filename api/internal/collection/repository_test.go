@@ -0,0 +1,54 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/postgres"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func TestUniqueViolation(t *testing.T) {
+	collection := &Collection{Slug: "subscribers"}
+	unique := &mimsy_schema.SchemaElementOptions{Constraints: &mimsy_schema.SchemaElementConstraints{Unique: true}}
+	fields := mimsy_schema.CollectionFields{
+		"email":    {Type: "email", Options: unique},
+		"referrer": {Type: "relation", RelatesTo: "subscribers", Options: unique},
+		"name":     {Type: "string"},
+	}
+
+	violation := func(constraint string) error {
+		return &pq.Error{Code: postgres.ErrUniqueViolation, Constraint: constraint}
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected []FieldError
+	}{
+		{name: "field", err: violation("uq__subscribers__email"), expected: []FieldError{{Path: "email", Reason: "is already used by another resource"}}},
+		{name: "relation", err: violation("uq__subscribers__referrer_id"), expected: []FieldError{{Path: "referrer_id", Reason: "is already used by another resource"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if err := uniqueViolation(tt.err, collection, fields); !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if !reflect.DeepEqual(validationErr.Errors, tt.expected) {
+				t.Errorf("expected errors %v, got %v", tt.expected, validationErr.Errors)
+			}
+		})
+	}
+
+	if err := uniqueViolation(violation("uq__subscribers__slug"), collection, fields); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for the slug, got %v", err)
+	}
+
+	if err := uniqueViolation(errors.New("connection refused"), collection, fields); err != nil {
+		t.Errorf("expected nil for other errors, got %v", err)
+	}
+}
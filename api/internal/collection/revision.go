@@ -0,0 +1,327 @@
+package collection
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// RevisionAction is the change of a resource recorded by a revision.
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+	// RevisionPublish and RevisionUnpublish are recorded when a resource is published or unpublished, by hand or on schedule.
+	RevisionPublish   RevisionAction = "publish"
+	RevisionUnpublish RevisionAction = "unpublish"
+)
+
+// Revision is a snapshot of the content of a resource, recorded on every change.
+type Revision struct {
+	Id           int64          `json:"id"`
+	Collection   string         `json:"collection"`
+	ResourceId   int64          `json:"resource_id"`
+	ResourceSlug string         `json:"resource_slug"`
+	Action       RevisionAction `json:"action"`
+	// Snapshot is the content of the resource after the change, or before it for deletions.
+	// Many-to-many relations are stored as the list of the related identifiers.
+	Snapshot  map[string]any `json:"snapshot"`
+	CreatedAt time.Time      `json:"created_at"`
+	CreatedBy int64          `json:"created_by"`
+}
+
+// FieldChange is the difference of a field between two revisions.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// DiffRevisions returns the fields that differ between two revisions, sorted by name.
+// Fields missing from a snapshot are compared as null.
+func DiffRevisions(from *Revision, to *Revision) ([]FieldChange, error) {
+	// Compare the JSON representation, so that snapshots read from the database
+	// and snapshots recorded in memory are handled the same way.
+	fromSnapshot, err := normalizeSnapshot(from.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	toSnapshot, err := normalizeSnapshot(to.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := slices.Sorted(maps.Keys(fromSnapshot))
+	for field := range toSnapshot {
+		if _, ok := fromSnapshot[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	changes := []FieldChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(fromSnapshot[field], toSnapshot[field]) {
+			changes = append(changes, FieldChange{Field: field, From: fromSnapshot[field], To: toSnapshot[field]})
+		}
+	}
+
+	return changes, nil
+}
+
+func normalizeSnapshot(snapshot map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return decodeSnapshot(data)
+}
+
+// decodeSnapshot keeps numbers as json.Number, so that identifiers and numeric fields are restored without rounding.
+func decodeSnapshot(data []byte) (map[string]any, error) {
+	snapshot := map[string]any{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+func (r *repository) CreateRevision(ctx context.Context, revision *Revision) error {
+	snapshot, err := json.Marshal(revision.Snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Insert("resource_revision").
+		Columns("collection_slug", "resource_id", "resource_slug", "action", "snapshot", "created_by").
+		Values(revision.Collection, revision.ResourceId, revision.ResourceSlug, revision.Action, string(snapshot), revision.CreatedBy).
+		Suffix(`RETURNING "id", "created_at"`).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build revision insert SQL query: %w", err)
+	}
+
+	if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&revision.Id, &revision.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert revision: %w", err)
+	}
+
+	return nil
+}
+
+var revisionColumns = []string{"id", "collection_slug", "resource_id", "resource_slug", "action", "snapshot", "created_at", "created_by"}
+
+// FindRevisions returns the revisions of a resource, the most recent first.
+func (r *repository) FindRevisions(ctx context.Context, c *Collection, resourceSlug string) ([]Revision, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(revisionColumns...).
+		From("resource_revision").
+		Where(sq.Eq{"collection_slug": c.Slug, "resource_slug": resourceSlug}).
+		OrderBy("id DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build revisions SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *repository) FindRevision(ctx context.Context, c *Collection, resourceSlug string, id int64) (*Revision, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(revisionColumns...).
+		From("resource_revision").
+		Where(sq.Eq{"id": id, "collection_slug": c.Slug, "resource_slug": resourceSlug}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build revision SQL query: %w", err)
+	}
+
+	revision, err := scanRevision(config.GetDB(ctx).QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return revision, err
+}
+
+func scanRevision(row rowScanner) (*Revision, error) {
+	var revision Revision
+	var snapshot []byte
+
+	if err := row.Scan(
+		&revision.Id,
+		&revision.Collection,
+		&revision.ResourceId,
+		&revision.ResourceSlug,
+		&revision.Action,
+		&snapshot,
+		&revision.CreatedAt,
+		&revision.CreatedBy,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan revision: %w", err)
+	}
+
+	var err error
+	if revision.Snapshot, err = decodeSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// snapshot returns the content of a resource to store in a revision, including its many-to-many relations.
+func (s *service) snapshot(ctx context.Context, collection *Collection, resource *Resource) (map[string]any, error) {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	snapshot := maps.Clone(resource.Fields)
+	for name, element := range fields {
+		if element.Type != "multi_relation" {
+			continue
+		}
+
+		relation, err := NewRelation(collection.Slug, name, element)
+		if err != nil {
+			return nil, err
+		}
+
+		relations, err := s.collectionRepository.FindRelationIds(ctx, relation, []int64{resource.Id})
+		if err != nil {
+			return nil, err
+		}
+
+		ids := relations[resource.Id]
+		if ids == nil {
+			ids = []int64{}
+		}
+		snapshot[name] = ids
+	}
+
+	return snapshot, nil
+}
+
+// recordRevision stores the current content of a resource, it must be called in the transaction of the change.
+func (s *service) recordRevision(ctx context.Context, collection *Collection, resource *Resource, action RevisionAction, author int64) error {
+	snapshot, err := s.snapshot(ctx, collection, resource)
+	if err != nil {
+		return fmt.Errorf("failed to take snapshot of resource: %w", err)
+	}
+
+	return s.collectionRepository.CreateRevision(ctx, &Revision{
+		Collection:   collection.Slug,
+		ResourceId:   resource.Id,
+		ResourceSlug: resource.Slug,
+		Action:       action,
+		Snapshot:     snapshot,
+		CreatedBy:    author,
+	})
+}
+
+func (s *service) FindRevisions(ctx context.Context, collection *Collection, resourceSlug string) ([]Revision, error) {
+	return s.collectionRepository.FindRevisions(ctx, collection, resourceSlug)
+}
+
+func (s *service) FindRevision(ctx context.Context, collection *Collection, resourceSlug string, id int64) (*Revision, error) {
+	return s.collectionRepository.FindRevision(ctx, collection, resourceSlug, id)
+}
+
+// RestoreRevision sets the content of a resource back to a revision, and records the restoration as a new revision.
+// Fields that were removed from the collection since are ignored. Resources in the trash are taken out of it
+// before their content is restored, and purged resources are created again.
+func (s *service) RestoreRevision(ctx context.Context, collection *Collection, resourceSlug string, id int64, restoredBy int64) (*Resource, error) {
+	revision, err := s.collectionRepository.FindRevision(ctx, collection, resourceSlug, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	codecs := columnCodecs(fields)
+	content := map[string]any{}
+	for key, value := range revision.Snapshot {
+		if _, ok := codecs[key]; ok {
+			content[key] = value
+		} else if element, ok := fields[key]; ok && (element.Type == "multi_relation" || element.Type == "blocks") {
+			content[key] = value
+		}
+	}
+
+	if err := validateContent(ctx, s.collectionRepository, collection, content, true); err != nil {
+		return nil, err
+	}
+
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		event := EventResourceUpdated
+
+		var err error
+		resource, err = s.collectionRepository.UpdateResource(ctx, collection, resourceSlug, restoredBy, content)
+		if errors.Is(err, ErrNotFound) {
+			// The resource is back in the lists as if it was created again
+			event = EventResourceCreated
+
+			// A trashed resource keeps its slug, so it is restored instead of being created
+			if _, err = s.collectionRepository.RestoreResource(ctx, collection, resourceSlug, restoredBy); err == nil {
+				resource, err = s.collectionRepository.UpdateResource(ctx, collection, resourceSlug, restoredBy, content)
+			} else if errors.Is(err, ErrNotFound) {
+				resource, err = s.collectionRepository.CreateResource(ctx, collection, resourceSlug, restoredBy, content)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.recordRevision(ctx, collection, resource, RevisionRestore, restoredBy); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, event, collection, resource)
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
@@ -0,0 +1,199 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Service Tests - Revisions
+// =================================================================================================
+
+func TestService_DeleteResource_RecordsRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	resource := &collection.Resource{Id: 7, Slug: "hello", Fields: map[string]any{"title": "Hello", "author_id": int64(2)}}
+
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{7}).
+		Return(map[int64][]int64{7: {1, 3}}, nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, revision *collection.Revision) error {
+			expected := map[string]any{"title": "Hello", "author_id": int64(2), "tags": []int64{1, 3}}
+			if revision.Action != collection.RevisionDelete || revision.CreatedBy != 5 || revision.ResourceSlug != "hello" {
+				t.Errorf("unexpected revision %+v", revision)
+			}
+			if !reflect.DeepEqual(revision.Snapshot, expected) {
+				t.Errorf("expected snapshot %v, got %v", expected, revision.Snapshot)
+			}
+			return nil
+		})
+
+	mockRepo.EXPECT().
+		DeleteResource(gomock.Any(), resource).
+		Return(nil)
+
+	if err := service.DeleteResource(context.Background(), posts, resource, 5); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestService_RestoreRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	revision := &collection.Revision{
+		Id:           3,
+		ResourceSlug: "hello",
+		Snapshot:     map[string]any{"title": "Old title", "subtitle": "removed field", "tags": []any{}},
+	}
+	restored := &collection.Resource{Id: 7, Slug: "hello", Fields: map[string]any{"title": "Old title"}}
+
+	mockRepo.EXPECT().
+		FindRevision(gomock.Any(), posts, "hello", int64(3)).
+		Return(revision, nil)
+
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), posts, "hello", int64(5), map[string]any{"title": "Old title", "tags": []any{}}).
+		Return(nil, collection.ErrNotFound)
+
+	mockRepo.EXPECT().
+		RestoreResource(gomock.Any(), posts, "hello", int64(5)).
+		Return(nil, collection.ErrNotFound)
+
+	mockRepo.EXPECT().
+		CreateResource(gomock.Any(), posts, "hello", int64(5), map[string]any{"title": "Old title", "tags": []any{}}).
+		Return(restored, nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{7}).
+		Return(map[int64][]int64{}, nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, revision *collection.Revision) error {
+			if revision.Action != collection.RevisionRestore {
+				t.Errorf("expected a restore revision, got %v", revision.Action)
+			}
+			return nil
+		})
+
+	resource, err := service.RestoreRevision(context.Background(), posts, "hello", 3, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resource != restored {
+		t.Errorf("expected the restored resource, got %v", resource)
+	}
+}
+
+func TestService_RestoreRevision_Trashed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	posts := collection.NewTestCollection("posts", postsFields)
+	revision := &collection.Revision{Id: 3, ResourceSlug: "hello", Snapshot: map[string]any{"title": "Old title"}}
+	content := map[string]any{"title": "Old title"}
+	restored := &collection.Resource{Id: 7, Slug: "hello", Fields: content}
+
+	mockRepo.EXPECT().
+		FindRevision(gomock.Any(), posts, "hello", int64(3)).
+		Return(revision, nil)
+
+	// The trashed resource still holds the slug, it is taken out of the trash instead of being created again
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			UpdateResource(gomock.Any(), posts, "hello", int64(5), content).
+			Return(nil, collection.ErrNotFound),
+		mockRepo.EXPECT().
+			RestoreResource(gomock.Any(), posts, "hello", int64(5)).
+			Return(&collection.Resource{Id: 7, Slug: "hello"}, nil),
+		mockRepo.EXPECT().
+			UpdateResource(gomock.Any(), posts, "hello", int64(5), content).
+			Return(restored, nil),
+	)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{7}).
+		Return(map[int64][]int64{}, nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	resource, err := service.RestoreRevision(context.Background(), posts, "hello", 3, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resource != restored {
+		t.Errorf("expected the restored resource, got %v", resource)
+	}
+}
+
+func TestDiffRevisions_Handler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindRevision(gomock.Any(), mockCollection, "test-resource", int64(1)).
+		Return(&collection.Revision{Id: 1, Snapshot: map[string]any{"title": "Before"}}, nil)
+
+	mockService.EXPECT().
+		FindRevision(gomock.Any(), mockCollection, "test-resource", int64(2)).
+		Return(&collection.Revision{Id: 2, Snapshot: map[string]any{"title": "After"}}, nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource/revisions/diff?from=1&to=2", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.DiffRevisions), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	var response collection.DiffRevisionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	expected := []collection.FieldChange{{Field: "title", From: "Before", To: "After"}}
+	if !reflect.DeepEqual(response.Changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, response.Changes)
+	}
+}
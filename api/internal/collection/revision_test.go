@@ -0,0 +1,49 @@
+package collection
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffRevisions(t *testing.T) {
+	from := &Revision{Snapshot: map[string]any{
+		"title":   "Hello",
+		"views":   json.Number("3"),
+		"tags":    []any{json.Number("1"), json.Number("2")},
+		"removed": "gone",
+	}}
+	to := &Revision{Snapshot: map[string]any{
+		"title": "Hello",
+		"views": json.Number("4"),
+		"tags":  []int64{1, 2},
+		"date":  time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+	}}
+
+	changes, err := DiffRevisions(from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []FieldChange{
+		{Field: "date", From: nil, To: "2024-01-02T10:00:00Z"},
+		{Field: "removed", From: "gone", To: nil},
+		{Field: "views", From: json.Number("3"), To: json.Number("4")},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestDiffRevisions_Identical(t *testing.T) {
+	revision := &Revision{Snapshot: map[string]any{"title": "Hello"}}
+
+	changes, err := DiffRevisions(revision, revision)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
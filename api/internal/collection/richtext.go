@@ -0,0 +1,664 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// RichTextFormat is the format rich text fields are rendered to, with `?richText=`.
+type RichTextFormat string
+
+const (
+	RichTextHTML     RichTextFormat = "html"
+	RichTextMarkdown RichTextFormat = "markdown"
+	RichTextText     RichTextFormat = "text"
+)
+
+// ParseRichTextFormat parses the `richText` query parameter, an empty format leaves the documents unchanged.
+func ParseRichTextFormat(value string) (RichTextFormat, error) {
+	switch format := RichTextFormat(value); format {
+	case "", RichTextHTML, RichTextMarkdown, RichTextText:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unknown rich text format %q, expected html, markdown or text", ErrInvalidQuery, value)
+	}
+}
+
+// RichTextNode is a node of the documents stored in rich text fields, the JSON of the admin editor
+// (TipTap, with its starter kit). A document is a tree of nodes:
+//
+//   - "doc" is the root, its content are blocks.
+//   - "paragraph", "blockquote" and "heading" (attrs.level, from 1 to 6) are blocks.
+//   - "bulletList" and "orderedList" (attrs.start) contain "listItem" nodes, whose content are blocks.
+//   - "codeBlock" (attrs.language) contains the text of the code.
+//   - "horizontalRule" is a separator.
+//   - "image" shows an uploaded file (attrs.media, its identifier) or an external one (attrs.src),
+//     described by attrs.alt and attrs.title.
+//   - "text" holds its text and marks, "hardBreak" is a line break within a block.
+//
+// The marks of a text are "bold", "italic", "underline", "strike", "code", and "link", which points to
+// an uploaded file (attrs.media) or to attrs.href, and opens in a new tab when attrs.target is "_blank".
+// Unknown nodes are rendered as their content, and unknown marks are ignored.
+type RichTextNode struct {
+	Type    string         `json:"type"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Content []RichTextNode `json:"content,omitempty"`
+	Marks   []RichTextMark `json:"marks,omitempty"`
+	Text    string         `json:"text,omitempty"`
+}
+
+type RichTextMark struct {
+	Type  string         `json:"type"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// MediaURLResolver returns the URL of an uploaded file, or ErrNotFound when it does not exist.
+type MediaURLResolver func(ctx context.Context, id int64) (string, error)
+
+// RenderRichText renders the value of a rich text field. Documents are decoded as RichTextNode trees,
+// plain strings, and lists of strings, as paragraphs. Links and images to missing files are dropped.
+func RenderRichText(ctx context.Context, value any, format RichTextFormat, resolve MediaURLResolver) (string, error) {
+	var doc RichTextNode
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		doc = RichTextNode{Type: "doc", Content: []RichTextNode{paragraphOf(v)}}
+	case []any:
+		doc = RichTextNode{Type: "doc"}
+		for _, item := range v {
+			if text, ok := item.(string); ok {
+				doc.Content = append(doc.Content, paragraphOf(text))
+			}
+		}
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode rich text: %w", err)
+		}
+		if err := json.Unmarshal(encoded, &doc); err != nil {
+			return "", fmt.Errorf("failed to decode rich text: %w", err)
+		}
+	}
+
+	r := &richTextRenderer{ctx: ctx, format: format, resolve: resolve}
+
+	var rendered string
+	if format == RichTextHTML {
+		var b strings.Builder
+		r.html(&b, doc)
+		rendered = b.String()
+	} else {
+		rendered = r.block(doc)
+	}
+
+	if r.err != nil {
+		return "", r.err
+	}
+	return rendered, nil
+}
+
+func paragraphOf(text string) RichTextNode {
+	return RichTextNode{Type: "paragraph", Content: []RichTextNode{{Type: "text", Text: text}}}
+}
+
+type richTextRenderer struct {
+	ctx     context.Context
+	format  RichTextFormat
+	resolve MediaURLResolver
+	// err is the first failure to resolve a file, the rendering goes on to keep the code linear.
+	err error
+}
+
+// html writes a node as HTML, every text and attribute is escaped.
+func (r *richTextRenderer) html(b *strings.Builder, node RichTextNode) {
+	switch node.Type {
+	case "paragraph":
+		r.htmlElement(b, "<p>", node.Content, "</p>")
+	case "heading":
+		level := strconv.Itoa(headingLevel(node))
+		r.htmlElement(b, "<h"+level+">", node.Content, "</h"+level+">")
+	case "blockquote":
+		r.htmlElement(b, "<blockquote>", node.Content, "</blockquote>")
+	case "bulletList":
+		r.htmlElement(b, "<ul>", node.Content, "</ul>")
+	case "orderedList":
+		if start := intAttr(node.Attrs, "start", 1); start != 1 {
+			r.htmlElement(b, `<ol start="`+strconv.Itoa(start)+`">`, node.Content, "</ol>")
+		} else {
+			r.htmlElement(b, "<ol>", node.Content, "</ol>")
+		}
+	case "listItem":
+		r.htmlElement(b, "<li>", node.Content, "</li>")
+	case "codeBlock":
+		b.WriteString("<pre><code")
+		if language := stringAttr(node.Attrs, "language"); language != "" {
+			b.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+		}
+		b.WriteString(">" + html.EscapeString(plainText(node)) + "</code></pre>")
+	case "horizontalRule":
+		b.WriteString("<hr>")
+	case "hardBreak":
+		b.WriteString("<br>")
+	case "image":
+		src := r.fileURL(node.Attrs, "src")
+		if src == "" {
+			return
+		}
+		b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(stringAttr(node.Attrs, "alt")) + `"`)
+		if title := stringAttr(node.Attrs, "title"); title != "" {
+			b.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		b.WriteString(">")
+	case "text":
+		r.htmlText(b, node)
+	default:
+		for _, child := range node.Content {
+			r.html(b, child)
+		}
+	}
+}
+
+func (r *richTextRenderer) htmlElement(b *strings.Builder, open string, content []RichTextNode, close string) {
+	b.WriteString(open)
+	for _, child := range content {
+		r.html(b, child)
+	}
+	b.WriteString(close)
+}
+
+func (r *richTextRenderer) htmlText(b *strings.Builder, node RichTextNode) {
+	var closing []string
+	for _, mark := range node.Marks {
+		switch mark.Type {
+		case "bold":
+			b.WriteString("<strong>")
+			closing = append(closing, "</strong>")
+		case "italic":
+			b.WriteString("<em>")
+			closing = append(closing, "</em>")
+		case "underline":
+			b.WriteString("<u>")
+			closing = append(closing, "</u>")
+		case "strike":
+			b.WriteString("<s>")
+			closing = append(closing, "</s>")
+		case "code":
+			b.WriteString("<code>")
+			closing = append(closing, "</code>")
+		case "link":
+			href := r.fileURL(mark.Attrs, "href")
+			if href == "" {
+				continue
+			}
+			b.WriteString(`<a href="` + html.EscapeString(href) + `"`)
+			if stringAttr(mark.Attrs, "target") == "_blank" {
+				b.WriteString(` target="_blank" rel="noopener noreferrer nofollow"`)
+			}
+			b.WriteString(">")
+			closing = append(closing, "</a>")
+		}
+	}
+
+	b.WriteString(html.EscapeString(node.Text))
+	for i := len(closing) - 1; i >= 0; i-- {
+		b.WriteString(closing[i])
+	}
+}
+
+// block renders a node as Markdown or plain text, blocks are separated by blank lines.
+func (r *richTextRenderer) block(node RichTextNode) string {
+	markdown := r.format == RichTextMarkdown
+
+	switch node.Type {
+	case "paragraph":
+		text := r.inline(node.Content)
+		if markdown {
+			return escapeBlockStart(text)
+		}
+		return text
+	case "heading":
+		text := r.inline(node.Content)
+		if markdown {
+			return strings.Repeat("#", headingLevel(node)) + " " + text
+		}
+		return text
+	case "blockquote":
+		text := r.blocks(node.Content, "\n\n")
+		if markdown {
+			return prefixLines(text, "> ", ">")
+		}
+		return text
+	case "bulletList", "orderedList":
+		start := intAttr(node.Attrs, "start", 1)
+		items := make([]string, 0, len(node.Content))
+		for i, item := range node.Content {
+			marker := "- "
+			if node.Type == "orderedList" {
+				marker = strconv.Itoa(start+i) + ". "
+			}
+			items = append(items, listItem(marker, r.blocks(item.Content, "\n")))
+		}
+		return strings.Join(items, "\n")
+	case "codeBlock":
+		code := plainText(node)
+		if markdown {
+			fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+			return fence + stringAttr(node.Attrs, "language") + "\n" + code + "\n" + fence
+		}
+		return code
+	case "horizontalRule":
+		if markdown {
+			return "---"
+		}
+		return ""
+	case "text", "hardBreak", "image":
+		return r.inline([]RichTextNode{node})
+	default:
+		return r.blocks(node.Content, "\n\n")
+	}
+}
+
+func (r *richTextRenderer) blocks(nodes []RichTextNode, separator string) string {
+	blocks := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if block := r.block(node); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, separator)
+}
+
+// inline renders the content of a block as Markdown or plain text.
+func (r *richTextRenderer) inline(nodes []RichTextNode) string {
+	markdown := r.format == RichTextMarkdown
+
+	var b strings.Builder
+	for _, node := range nodes {
+		switch node.Type {
+		case "text":
+			if markdown {
+				b.WriteString(r.markdownText(node))
+			} else {
+				b.WriteString(node.Text)
+			}
+		case "hardBreak":
+			if markdown {
+				b.WriteString("\\")
+			}
+			b.WriteString("\n")
+		case "image":
+			alt := stringAttr(node.Attrs, "alt")
+			if !markdown {
+				b.WriteString(alt)
+				continue
+			}
+			if src := r.fileURL(node.Attrs, "src"); src != "" {
+				b.WriteString("![" + escapeMarkdown(alt) + "](" + markdownDestination(src) + ")")
+			}
+		default:
+			b.WriteString(r.inline(node.Content))
+		}
+	}
+	return b.String()
+}
+
+func (r *richTextRenderer) markdownText(node RichTextNode) string {
+	text := escapeMarkdown(node.Text)
+	for _, mark := range node.Marks {
+		if mark.Type == "code" {
+			text = codeSpan(node.Text)
+		}
+	}
+
+	for _, mark := range node.Marks {
+		switch mark.Type {
+		case "bold":
+			text = emphasize(text, "**")
+		case "italic":
+			text = emphasize(text, "*")
+		case "strike":
+			text = emphasize(text, "~~")
+		case "link":
+			if href := r.fileURL(mark.Attrs, "href"); href != "" {
+				text = "[" + text + "](" + markdownDestination(href) + ")"
+			}
+		}
+	}
+	return text
+}
+
+// fileURL returns the URL of an uploaded file referenced by the `media` attribute, or the URL of the given
+// attribute when it is safe to follow. It is empty when there is no URL, or the file does not exist.
+func (r *richTextRenderer) fileURL(attrs map[string]any, name string) string {
+	id, ok := int64Attr(attrs, "media")
+	if !ok {
+		return safeURL(stringAttr(attrs, name))
+	}
+
+	if r.resolve == nil {
+		return ""
+	}
+
+	resolved, err := r.resolve(r.ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && r.err == nil {
+			r.err = fmt.Errorf("failed to resolve media %d: %w", id, err)
+		}
+		return ""
+	}
+	return resolved
+}
+
+// safeURL returns the URL unless it uses a scheme which could run scripts, such as `javascript:`.
+func safeURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+
+	switch parsed.Scheme {
+	case "", "http", "https", "mailto", "tel":
+		return value
+	default:
+		return ""
+	}
+}
+
+func headingLevel(node RichTextNode) int {
+	return min(max(intAttr(node.Attrs, "level", 1), 1), 6)
+}
+
+func stringAttr(attrs map[string]any, name string) string {
+	value, _ := attrs[name].(string)
+	return value
+}
+
+func intAttr(attrs map[string]any, name string, fallback int) int {
+	if value, ok := int64Attr(attrs, name); ok {
+		return int(value)
+	}
+	return fallback
+}
+
+// int64Attr reads an integer attribute, given as a JSON number or as a string.
+func int64Attr(attrs map[string]any, name string) (int64, bool) {
+	switch v := attrs[name].(type) {
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case string:
+		value, err := strconv.ParseInt(v, 10, 64)
+		return value, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// plainText concatenates the texts of a node.
+func plainText(node RichTextNode) string {
+	if node.Type == "text" {
+		return node.Text
+	}
+
+	var b strings.Builder
+	for _, child := range node.Content {
+		b.WriteString(plainText(child))
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `~`, `\~`, `|`, `\|`, `#`, `\#`,
+)
+
+// escapeMarkdown escapes the characters of a text which Markdown could interpret.
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+var listMarkerPattern = regexp.MustCompile(`^(\d+)([.)])`)
+
+// escapeBlockStart escapes the start of a paragraph which would be read as a list item or a rule.
+func escapeBlockStart(text string) string {
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") || strings.HasPrefix(text, "=") {
+		return `\` + text
+	}
+	return listMarkerPattern.ReplaceAllString(text, `$1\$2`)
+}
+
+// emphasize wraps a text in delimiters, which cannot be adjacent to whitespace in Markdown.
+func emphasize(text string, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	start := strings.Index(text, trimmed)
+	return text[:start] + delimiter + trimmed + delimiter + text[start+len(trimmed):]
+}
+
+// codeSpan returns an inline code span, delimited by more backticks than it contains.
+func codeSpan(code string) string {
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return fence + " " + code + " " + fence
+	}
+	return fence + code + fence
+}
+
+var destinationEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+func markdownDestination(href string) string {
+	return destinationEscaper.Replace(href)
+}
+
+func longestRun(text string, c rune) int {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// listItem prefixes the text of an item with its marker, the following lines are aligned on the first one.
+func listItem(marker string, text string) string {
+	lines := strings.Split(text, "\n")
+	lines[0] = marker + lines[0]
+	indent := strings.Repeat(" ", len(marker))
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes each line of a text, empty lines get the blank prefix.
+func prefixLines(text string, prefix string, blank string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blank
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *service) RenderRichText(ctx context.Context, collection *Collection, resources []Resource, format RichTextFormat) error {
+	if format == "" {
+		return nil
+	}
+
+	rr := &richTextResources{
+		repository: s.collectionRepository,
+		format:     format,
+		resolve:    s.mediaURLResolver(),
+		fields:     map[string]mimsy_schema.CollectionFields{},
+	}
+
+	if err := rr.addCollection(collection); err != nil {
+		return err
+	}
+
+	for i := range resources {
+		if err := rr.render(ctx, &resources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mediaURLResolver returns a resolver of the temporary URLs of the uploaded files, resolving each file once.
+func (s *service) mediaURLResolver() MediaURLResolver {
+	urls := map[int64]string{}
+
+	return func(ctx context.Context, id int64) (string, error) {
+		if resolved, ok := urls[id]; ok {
+			return resolved, nil
+		}
+		if s.mediaService == nil {
+			return "", fmt.Errorf("media resolution is not configured")
+		}
+
+		m, err := s.mediaService.GetById(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+
+		resolved, err := s.mediaService.GetTemporaryURL(ctx, m)
+		if err != nil {
+			return "", err
+		}
+
+		urls[id] = resolved
+		return resolved, nil
+	}
+}
+
+// richTextResources renders the rich text fields of resources, loading the fields of their collections once.
+type richTextResources struct {
+	repository Repository
+	format     RichTextFormat
+	resolve    MediaURLResolver
+	fields     map[string]mimsy_schema.CollectionFields
+}
+
+func (rr *richTextResources) addCollection(collection *Collection) error {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+	rr.fields[collection.Slug] = fields
+	return nil
+}
+
+func (rr *richTextResources) render(ctx context.Context, resource *Resource) error {
+	if _, ok := rr.fields[resource.Collection]; !ok {
+		collection, err := rr.repository.FindBySlug(ctx, resource.Collection)
+		if err != nil {
+			return fmt.Errorf("failed to find collection %q: %w", resource.Collection, err)
+		}
+		if err := rr.addCollection(collection); err != nil {
+			return err
+		}
+	}
+
+	return rr.renderFields(ctx, rr.fields[resource.Collection], resource.Fields)
+}
+
+// renderFields renders the rich text fields among the given values, along with those of the blocks
+// and of the populated relations.
+func (rr *richTextResources) renderFields(ctx context.Context, fields mimsy_schema.CollectionFields, values map[string]any) error {
+	for name, value := range values {
+		element, ok := fields[name]
+		if ok && element.Type == "rich_text" {
+			rendered, err := rr.renderValue(ctx, value, element.IsLocalized())
+			if err != nil {
+				return fmt.Errorf("failed to render field %q: %w", name, err)
+			}
+			values[name] = rendered
+			continue
+		}
+
+		// The fields of each block are those of its type
+		if ok && element.Type == "blocks" {
+			blocks, _ := value.([]any)
+			for _, item := range blocks {
+				block, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				blockType, _ := block[schema_generator.BlockTypeColumn].(string)
+				if err := rr.renderFields(ctx, element.GetBlocks()[blockType], block); err != nil {
+					return fmt.Errorf("failed to render blocks %q: %w", name, err)
+				}
+			}
+			continue
+		}
+
+		// Populated relations hold resources of other collections
+		switch v := value.(type) {
+		case Resource:
+			if err := rr.render(ctx, &v); err != nil {
+				return err
+			}
+			values[name] = v
+		case []any:
+			for i, item := range v {
+				if related, ok := item.(Resource); ok {
+					if err := rr.render(ctx, &related); err != nil {
+						return err
+					}
+					v[i] = related
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// renderValue renders a rich text value, the values of a localized field which was not localized
+// by the request are rendered for each locale.
+func (rr *richTextResources) renderValue(ctx context.Context, value any, localized bool) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if values, ok := value.(map[string]any); ok && localized {
+		if _, isDocument := values["type"]; !isDocument {
+			rendered := make(map[string]any, len(values))
+			for locale, value := range values {
+				var err error
+				if rendered[locale], err = rr.renderValue(ctx, value, false); err != nil {
+					return nil, err
+				}
+			}
+			return rendered, nil
+		}
+	}
+
+	return RenderRichText(ctx, value, rr.format, rr.resolve)
+}
@@ -0,0 +1,48 @@
+package collection_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Rich Text Tests
+// =================================================================================================
+
+func TestGetResource_RichText(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	mockResource := createMockResource()
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "test-collection").Return(mockCollection, nil).Times(2)
+	mockService.EXPECT().FindResource(gomock.Any(), mockCollection, "test-resource").Return(mockResource, nil).Times(2)
+	mockService.EXPECT().
+		RenderRichText(gomock.Any(), mockCollection, gomock.Any(), collection.RichTextMarkdown).
+		Return(nil)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/test-resource?richText=markdown", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+
+	if w := executeRequest(http.HandlerFunc(handler.GetResource), req, t); w.Code != http.StatusOK {
+		t.Errorf("expected status OK, got %v", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/collections/test-collection/test-resource?richText=pdf", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+
+	if w := executeRequest(http.HandlerFunc(handler.GetResource), req, t); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+}
@@ -0,0 +1,223 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// testDocument is a document as saved by the admin editor.
+const testDocument = `{
+	"type": "doc",
+	"content": [
+		{"type": "heading", "attrs": {"level": 2}, "content": [{"type": "text", "text": "Tips & <tricks>"}]},
+		{"type": "paragraph", "content": [
+			{"type": "text", "text": "Read "},
+			{"type": "text", "text": "the guide", "marks": [{"type": "link", "attrs": {"href": "https://example.com/a b", "target": "_blank"}}]},
+			{"type": "text", "text": ", "},
+			{"type": "text", "text": "bold* ", "marks": [{"type": "bold"}]},
+			{"type": "text", "text": "x", "marks": [{"type": "link", "attrs": {"href": "javascript:alert(1)"}}]},
+			{"type": "hardBreak"},
+			{"type": "text", "text": "a` + "`" + `b", "marks": [{"type": "code"}]}
+		]},
+		{"type": "bulletList", "content": [
+			{"type": "listItem", "content": [
+				{"type": "paragraph", "content": [{"type": "text", "text": "one"}]},
+				{"type": "orderedList", "attrs": {"start": 3}, "content": [
+					{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "nested"}]}]}
+				]}
+			]},
+			{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "1. two"}]}]}
+		]},
+		{"type": "blockquote", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "quoted"}]}]},
+		{"type": "codeBlock", "attrs": {"language": "go"}, "content": [{"type": "text", "text": "x := \"<b>\""}]},
+		{"type": "image", "attrs": {"media": 4, "alt": "A \"cat\""}},
+		{"type": "image", "attrs": {"media": 5, "alt": "missing"}},
+		{"type": "horizontalRule"},
+		{"type": "callout", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "unknown"}]}]}
+	]
+}`
+
+func renderTestDocument(t *testing.T, format RichTextFormat) string {
+	t.Helper()
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(testDocument), &doc); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	resolve := func(ctx context.Context, id int64) (string, error) {
+		if id == 4 {
+			return "https://cdn.example.com/cat.png?sig=1&exp=2", nil
+		}
+		return "", ErrNotFound
+	}
+
+	rendered, err := RenderRichText(context.Background(), doc, format, resolve)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rendered
+}
+
+func TestRenderRichText_HTML(t *testing.T) {
+	expected := `<h2>Tips &amp; &lt;tricks&gt;</h2>` +
+		`<p>Read <a href="https://example.com/a b" target="_blank" rel="noopener noreferrer nofollow">the guide</a>, <strong>bold* </strong>x<br><code>a` + "`" + `b</code></p>` +
+		`<ul><li><p>one</p><ol start="3"><li><p>nested</p></li></ol></li><li><p>1. two</p></li></ul>` +
+		`<blockquote><p>quoted</p></blockquote>` +
+		`<pre><code class="language-go">x := &#34;&lt;b&gt;&#34;</code></pre>` +
+		`<img src="https://cdn.example.com/cat.png?sig=1&amp;exp=2" alt="A &#34;cat&#34;">` +
+		`<hr>` +
+		`<p>unknown</p>`
+
+	if rendered := renderTestDocument(t, RichTextHTML); rendered != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+}
+
+func TestRenderRichText_Markdown(t *testing.T) {
+	expected := "## Tips & \\<tricks\\>\n\n" +
+		"Read [the guide](https://example.com/a%20b), **bold\\*** x\\\n``a`b``\n\n" +
+		"- one\n  3. nested\n- 1\\. two\n\n" +
+		"> quoted\n\n" +
+		"```go\nx := \"<b>\"\n```\n\n" +
+		"![A \"cat\"](https://cdn.example.com/cat.png?sig=1&exp=2)\n\n" +
+		"---\n\n" +
+		"unknown"
+
+	if rendered := renderTestDocument(t, RichTextMarkdown); rendered != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+}
+
+func TestRenderRichText_Text(t *testing.T) {
+	expected := "Tips & <tricks>\n\n" +
+		"Read the guide, bold* x\na`b\n\n" +
+		"- one\n  3. nested\n- 1. two\n\n" +
+		"quoted\n\n" +
+		"x := \"<b>\"\n\n" +
+		"A \"cat\"\n\n" +
+		"missing\n\n" +
+		"unknown"
+
+	if rendered := renderTestDocument(t, RichTextText); rendered != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+}
+
+func TestRenderRichText_Strings(t *testing.T) {
+	rendered, err := RenderRichText(context.Background(), []any{"<b>one</b>", "two"}, RichTextHTML, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "<p>&lt;b&gt;one&lt;/b&gt;</p><p>two</p>"; rendered != expected {
+		t.Errorf("expected %s, got %s", expected, rendered)
+	}
+}
+
+func TestRenderRichText_ResolveError(t *testing.T) {
+	resolveErr := errors.New("storage unavailable")
+	resolve := func(ctx context.Context, id int64) (string, error) {
+		return "", resolveErr
+	}
+
+	doc := map[string]any{"type": "doc", "content": []any{
+		map[string]any{"type": "image", "attrs": map[string]any{"media": float64(1)}},
+	}}
+
+	if _, err := RenderRichText(context.Background(), doc, RichTextHTML, resolve); !errors.Is(err, resolveErr) {
+		t.Errorf("expected the resolution error, got %v", err)
+	}
+}
+
+func TestParseRichTextFormat(t *testing.T) {
+	for _, value := range []string{"", "html", "markdown", "text"} {
+		if format, err := ParseRichTextFormat(value); err != nil || string(format) != value {
+			t.Errorf("expected %q to be parsed, got %q, %v", value, format, err)
+		}
+	}
+
+	if _, err := ParseRichTextFormat("pdf"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestService_RenderRichText_Localized(t *testing.T) {
+	s := NewService(nil)
+	collection := NewTestCollection("posts", mimsy_schema.CollectionFields{
+		"body":  {Type: "rich_text", Options: &mimsy_schema.SchemaElementOptions{Localized: true}},
+		"title": {Type: "string"},
+	})
+	resources := []Resource{{
+		Collection: "posts",
+		Fields: map[string]any{
+			"title": "*kept*",
+			"body":  map[string]any{"en": "Hello", "fr": nil},
+		},
+	}}
+
+	if err := s.RenderRichText(context.Background(), collection, resources, RichTextMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, ok := resources[0].Fields["body"].(map[string]any)
+	if !ok || body["en"] != "Hello" || body["fr"] != nil {
+		t.Errorf("expected the body to be rendered by locale, got %v", resources[0].Fields["body"])
+	}
+	if resources[0].Fields["title"] != "*kept*" {
+		t.Errorf("expected the other fields to be unchanged, got %v", resources[0].Fields["title"])
+	}
+}
+
+func TestService_RenderRichText_Blocks(t *testing.T) {
+	s := NewService(nil)
+	collection := NewTestCollection("pages", mimsy_schema.CollectionFields{
+		"content": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{Blocks: map[string]mimsy_schema.CollectionFields{
+			"quote": {"text": {Type: "rich_text"}, "author": {Type: "string"}},
+			"columns": {"items": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+				Blocks: map[string]mimsy_schema.CollectionFields{"text": {"body": {Type: "rich_text"}}},
+			}}},
+		}}},
+	})
+	document := func() map[string]any {
+		return map[string]any{"type": "doc", "content": []any{
+			map[string]any{"type": "paragraph", "content": []any{
+				map[string]any{"type": "text", "text": "Hi", "marks": []any{map[string]any{"type": "bold"}}},
+			}},
+		}}
+	}
+	resources := []Resource{{
+		Collection: "pages",
+		Fields: map[string]any{
+			"content": []any{
+				map[string]any{"block_type": "quote", "text": document(), "author": "*kept*"},
+				map[string]any{"block_type": "columns", "items": []any{
+					map[string]any{"block_type": "text", "body": document()},
+				}},
+			},
+		},
+	}}
+
+	expected, err := RenderRichText(context.Background(), document(), RichTextMarkdown, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.RenderRichText(context.Background(), collection, resources, RichTextMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	blocks := resources[0].Fields["content"].([]any)
+	quote := blocks[0].(map[string]any)
+	if quote["text"] != expected || quote["author"] != "*kept*" {
+		t.Errorf("expected the rich text of the block to be rendered, got %v", quote)
+	}
+	// The nested blocks are rendered with the fields of their own type
+	nested := blocks[1].(map[string]any)["items"].([]any)[0].(map[string]any)
+	if nested["body"] != expected {
+		t.Errorf("expected the rich text of the nested block to be rendered, got %v", nested)
+	}
+}
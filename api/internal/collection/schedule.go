@@ -0,0 +1,317 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/cron"
+)
+
+// defaultTransitionsLimit is the number of scheduled transitions returned when no limit is requested.
+const defaultTransitionsLimit = 20
+
+// Schedule is when a resource is automatically published and unpublished, nil timestamps are not scheduled.
+type Schedule struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// validate returns a validation error when the resource would be unpublished before being published.
+func (s Schedule) validate() error {
+	if s.PublishAt != nil && s.UnpublishAt != nil && !s.UnpublishAt.After(*s.PublishAt) {
+		errs := &ValidationError{}
+		errs.add("unpublish_at", "must be after publish_at")
+		return errs
+	}
+	return nil
+}
+
+// ScheduledTransition is a change of status of a resource made when its schedule was due.
+type ScheduledTransition struct {
+	Id           int64  `json:"id"`
+	Collection   string `json:"collection"`
+	ResourceId   int64  `json:"resource_id"`
+	ResourceSlug string `json:"resource_slug"`
+	// Status is the status of the resource after the transition.
+	Status      Status    `json:"status"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+func (r *repository) UpdateResourceSchedule(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, schedule Schedule) (*Resource, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(pq.QuoteIdentifier(collection.Slug)).
+		Set("publish_at", schedule.PublishAt).
+		Set("unpublish_at", schedule.UnpublishAt).
+		Set("updated_at", sq.Expr("NOW()")).
+		Set("updated_by", updatedBy).
+		Where(sq.Eq{"slug": resourceSlug, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schedule update SQL query: %w", err)
+	}
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update resource schedule: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	} else if rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	return r.FindResource(ctx, collection, resourceSlug)
+}
+
+// ApplyScheduledTransitions changes to a status the resources of a collection whose schedule for it is due,
+// publishing or unpublishing them, and records the transitions. The schedule of a resource is cleared once applied.
+func (r *repository) ApplyScheduledTransitions(ctx context.Context, collection *Collection, status Status, now time.Time, updatedBy int64) ([]ScheduledTransition, error) {
+	table := pq.QuoteIdentifier(collection.Slug)
+
+	// The due timestamp is selected before the update, as it is cleared by it
+	var query string
+	switch status {
+	case StatusPublished:
+		query = fmt.Sprintf(`
+			WITH due AS (
+				SELECT id, publish_at FROM %s WHERE deleted_at IS NULL AND publish_at <= $1 FOR UPDATE
+			)
+			UPDATE %s AS r SET status = $2, published_at = due.publish_at, publish_at = NULL, updated_at = NOW(), updated_by = $3
+			FROM due WHERE r.id = due.id
+			RETURNING r.id, r.slug, due.publish_at
+		`, table, table)
+	case StatusDraft:
+		query = fmt.Sprintf(`
+			WITH due AS (
+				SELECT id, unpublish_at FROM %s WHERE deleted_at IS NULL AND unpublish_at <= $1 FOR UPDATE
+			)
+			UPDATE %s AS r SET status = $2, published_at = NULL, unpublish_at = NULL, updated_at = NOW(), updated_by = $3
+			FROM due WHERE r.id = due.id
+			RETURNING r.id, r.slug, due.unpublish_at
+		`, table, table)
+	default:
+		return nil, fmt.Errorf("unknown scheduled status %q", status)
+	}
+
+	var transitions []ScheduledTransition
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if transitions, err = r.applyTransition(ctx, collection, query, now, status, updatedBy); err != nil {
+			return err
+		}
+
+		if len(transitions) == 0 {
+			return nil
+		}
+
+		b := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+			Insert("scheduled_transition").
+			Columns("collection_slug", "resource_id", "resource_slug", "status", "scheduled_at").
+			Suffix(`RETURNING "id", "executed_at"`)
+		for _, transition := range transitions {
+			b = b.Values(transition.Collection, transition.ResourceId, transition.ResourceSlug, transition.Status, transition.ScheduledAt)
+		}
+
+		query, args, err := b.ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build transition insert SQL query: %w", err)
+		}
+
+		rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to insert transitions: %w", err)
+		}
+		defer rows.Close()
+
+		// Rows are returned in the order of the inserted values
+		for i := 0; rows.Next(); i++ {
+			if err := rows.Scan(&transitions[i].Id, &transitions[i].ExecutedAt); err != nil {
+				return fmt.Errorf("failed to scan transition: %w", err)
+			}
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+func (r *repository) applyTransition(ctx context.Context, collection *Collection, query string, now time.Time, status Status, updatedBy int64) ([]ScheduledTransition, error) {
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, now, status, updatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply scheduled %s transitions: %w", status, err)
+	}
+	defer rows.Close()
+
+	transitions := []ScheduledTransition{}
+	for rows.Next() {
+		transition := ScheduledTransition{Collection: collection.Slug, Status: status}
+		if err := rows.Scan(&transition.ResourceId, &transition.ResourceSlug, &transition.ScheduledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transition: %w", err)
+		}
+		transitions = append(transitions, transition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over scheduled transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// FindScheduledTransitions returns the most recent transitions, across every collection.
+func (r *repository) FindScheduledTransitions(ctx context.Context, limit uint64) ([]ScheduledTransition, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("id", "collection_slug", "resource_id", "resource_slug", "status", "scheduled_at", "executed_at").
+		From("scheduled_transition").
+		OrderBy("id DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transitions SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []ScheduledTransition{}
+	for rows.Next() {
+		var transition ScheduledTransition
+		if err := rows.Scan(
+			&transition.Id,
+			&transition.Collection,
+			&transition.ResourceId,
+			&transition.ResourceSlug,
+			&transition.Status,
+			&transition.ScheduledAt,
+			&transition.ExecutedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transition: %w", err)
+		}
+		transitions = append(transitions, transition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+func (s *service) ScheduleResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, schedule Schedule) (*Resource, error) {
+	if err := schedule.validate(); err != nil {
+		return nil, err
+	}
+
+	return s.collectionRepository.UpdateResourceSchedule(ctx, collection, resourceSlug, updatedBy, schedule)
+}
+
+// ApplyScheduledTransitions applies the due schedules of every collection, on behalf of the system user.
+// The transitions of a collection are applied in a transaction along with their revisions and events,
+// so that a failure leaves them due for the next run. A collection that fails does not prevent the others
+// from being updated. When both are due, a resource is published then unpublished.
+func (s *service) ApplyScheduledTransitions(ctx context.Context, now time.Time) error {
+	collections, err := s.collectionRepository.FindAll(ctx, &FindAllParams{})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	globals, err := s.collectionRepository.FindAllGlobals(ctx, &FindAllParams{})
+	if err != nil {
+		return fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	var errs []error
+	for _, collection := range append(collections, globals...) {
+		var applied []ScheduledTransition
+		if err := config.WithinTx(ctx, func(ctx context.Context) error {
+			for _, status := range []Status{StatusPublished, StatusDraft} {
+				transitions, err := s.collectionRepository.ApplyScheduledTransitions(ctx, &collection, status, now, systemUser)
+				if err != nil {
+					return err
+				}
+
+				for _, transition := range transitions {
+					if err := s.recordTransition(ctx, &collection, transition); err != nil {
+						return fmt.Errorf("failed to record scheduled transition of %s: %w", transition.ResourceSlug, err)
+					}
+				}
+				applied = append(applied, transitions...)
+			}
+			return nil
+		}); err != nil {
+			slog.Error("Failed to apply scheduled transitions", "slug", collection.Slug, "error", err)
+			errs = append(errs, fmt.Errorf("failed to apply scheduled transitions of %s: %w", collection.Slug, err))
+			continue
+		}
+
+		for _, transition := range applied {
+			slog.Info("Applied scheduled transition", "slug", collection.Slug, "resourceSlug", transition.ResourceSlug, "status", transition.Status)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// recordTransition records the revision of a scheduled transition and notifies the event hooks, it must be called
+// in the transaction of the transition, before the next one is applied, so that they see the resource it left.
+func (s *service) recordTransition(ctx context.Context, collection *Collection, transition ScheduledTransition) error {
+	resource, err := s.collectionRepository.FindResource(ctx, collection, transition.ResourceSlug)
+	if err != nil {
+		return err
+	}
+
+	if transition.Status == StatusPublished {
+		if err := s.recordRevision(ctx, collection, resource, RevisionPublish, systemUser); err != nil {
+			return err
+		}
+		return s.emit(ctx, EventResourcePublished, collection, resource)
+	}
+
+	if err := s.recordRevision(ctx, collection, resource, RevisionUnpublish, systemUser); err != nil {
+		return err
+	}
+	return s.emit(ctx, EventResourceUpdated, collection, resource)
+}
+
+func (s *service) FindScheduledTransitions(ctx context.Context, limit uint64) ([]ScheduledTransition, error) {
+	return s.collectionRepository.FindScheduledTransitions(ctx, limit)
+}
+
+// RegisterScheduleJobs registers the cron job that applies the due schedules every minute.
+// As every job of the cron service, it only runs on the instance holding its lock.
+// The last transitions are shown in the history of the job.
+func RegisterScheduleJobs(cronService cron.CronService, db *sql.DB, service Service) error {
+	ctx := context.Background()
+
+	scheduleJob := cron.Job{
+		Name:     "apply-schedules",
+		Schedule: "* * * * *", // Every minute
+		Function: func() error {
+			ctx := config.ContextWithDB(context.Background(), db)
+			return service.ApplyScheduledTransitions(ctx, time.Now())
+		},
+		Params: []any{},
+		History: func(ctx context.Context) (any, error) {
+			return service.FindScheduledTransitions(config.ContextWithDB(ctx, db), defaultTransitionsLimit)
+		},
+	}
+
+	if err := cronService.RegisterJob(ctx, scheduleJob); err != nil {
+		return fmt.Errorf("failed to register schedule job: %w", err)
+	}
+
+	slog.Info("Successfully registered schedule job")
+	return nil
+}
@@ -0,0 +1,114 @@
+package collection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Schedule Tests
+// =================================================================================================
+
+func TestService_ApplyScheduledTransitions_RecordsRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	var events []collection.Event
+	service := collection.NewService(mockRepo, collection.WithEventHook(func(ctx context.Context, event collection.Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	now := time.Date(2024, 3, 1, 9, 0, 30, 0, time.UTC)
+	posts := collection.NewTestCollection("posts", postsFields)
+
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*posts}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(map[int64][]int64{}, nil).
+		AnyTimes()
+
+	var revisions []collection.Revision
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, revision *collection.Revision) error {
+			if revision.CreatedBy != 1 {
+				t.Errorf("expected the revision to be made by the system user, got %d", revision.CreatedBy)
+			}
+			revisions = append(revisions, *revision)
+			return nil
+		}).
+		Times(2)
+
+	// The publication and the unpublication of the resource are both due, each is recorded with the resource it left
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			ApplyScheduledTransitions(gomock.Any(), gomock.Any(), collection.StatusPublished, now, int64(1)).
+			Return([]collection.ScheduledTransition{{Collection: "posts", ResourceId: 4, ResourceSlug: "launch", Status: collection.StatusPublished}}, nil),
+		mockRepo.EXPECT().
+			FindResource(gomock.Any(), gomock.Any(), "launch").
+			Return(&collection.Resource{Id: 4, Slug: "launch", Status: collection.StatusPublished, Fields: map[string]any{}}, nil),
+		mockRepo.EXPECT().
+			ApplyScheduledTransitions(gomock.Any(), gomock.Any(), collection.StatusDraft, now, int64(1)).
+			Return([]collection.ScheduledTransition{{Collection: "posts", ResourceId: 4, ResourceSlug: "launch", Status: collection.StatusDraft}}, nil),
+		mockRepo.EXPECT().
+			FindResource(gomock.Any(), gomock.Any(), "launch").
+			Return(&collection.Resource{Id: 4, Slug: "launch", Status: collection.StatusDraft, Fields: map[string]any{}}, nil),
+	)
+
+	if err := service.ApplyScheduledTransitions(context.Background(), now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(revisions) != 2 || revisions[0].Action != collection.RevisionPublish || revisions[1].Action != collection.RevisionUnpublish {
+		t.Errorf("expected a publish then an unpublish revision, got %+v", revisions)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != collection.EventResourcePublished || events[0].Resource.Status != collection.StatusPublished {
+		t.Errorf("expected the published resource to be notified, got %s with %s", events[0].Type, events[0].Resource.Status)
+	}
+	if events[1].Type != collection.EventResourceUpdated || events[1].Resource.Status != collection.StatusDraft {
+		t.Errorf("expected the unpublished resource to be notified, got %s with %s", events[1].Type, events[1].Resource.Status)
+	}
+}
+
+func TestService_ApplyScheduledTransitions_RecordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo, collection.WithEventHook(func(ctx context.Context, event collection.Event) error {
+		return errors.New("feed unavailable")
+	}))
+
+	now := time.Date(2024, 3, 1, 9, 0, 30, 0, time.UTC)
+	posts := collection.NewTestCollection("posts", postsFields)
+
+	mockRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*posts}, nil)
+	mockRepo.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{}, nil)
+	mockRepo.EXPECT().
+		ApplyScheduledTransitions(gomock.Any(), gomock.Any(), collection.StatusPublished, now, int64(1)).
+		Return([]collection.ScheduledTransition{{Collection: "posts", ResourceId: 4, ResourceSlug: "launch", Status: collection.StatusPublished}}, nil)
+	mockRepo.EXPECT().
+		FindResource(gomock.Any(), gomock.Any(), "launch").
+		Return(&collection.Resource{Id: 4, Slug: "launch", Status: collection.StatusPublished, Fields: map[string]any{}}, nil)
+	mockRepo.EXPECT().FindRelationIds(gomock.Any(), gomock.Any(), gomock.Any()).Return(map[int64][]int64{}, nil).AnyTimes()
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil)
+
+	// The transition is rolled back with its event, so that it is applied again by the next run
+	if err := service.ApplyScheduledTransitions(context.Background(), now); err == nil {
+		t.Error("expected the failure of the event hook to be returned")
+	}
+}
@@ -0,0 +1,89 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+	publishAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+
+	if err := (Schedule{PublishAt: &publishAt, UnpublishAt: &unpublishAt}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := (Schedule{UnpublishAt: &publishAt}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var validationErr *ValidationError
+	if err := (Schedule{PublishAt: &unpublishAt, UnpublishAt: &publishAt}).validate(); !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if validationErr.Errors[0].Path != "unpublish_at" {
+		t.Errorf("expected an error on unpublish_at, got %+v", validationErr.Errors)
+	}
+}
+
+func TestApplyScheduledTransitions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	collection := &Collection{Slug: "posts"}
+	now := time.Date(2024, 3, 1, 9, 0, 30, 0, time.UTC)
+	publishAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	executedAt := now.Add(time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "posts" AS r SET status = \$2, published_at = due.publish_at, publish_at = NULL, updated_at = NOW\(\), updated_by = \$3`).
+		WithArgs(now, StatusPublished, systemUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "publish_at"}).AddRow(int64(4), "launch", publishAt))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO scheduled_transition (collection_slug,resource_id,resource_slug,status,scheduled_at) VALUES ($1,$2,$3,$4,$5) RETURNING "id", "executed_at"`)).
+		WithArgs("posts", int64(4), "launch", StatusPublished, publishAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at"}).AddRow(int64(1), executedAt))
+	mock.ExpectCommit()
+
+	transitions, err := NewRepository().ApplyScheduledTransitions(ctx, collection, StatusPublished, now, systemUser)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := ScheduledTransition{
+		Id:           1,
+		Collection:   "posts",
+		ResourceId:   4,
+		ResourceSlug: "launch",
+		Status:       StatusPublished,
+		ScheduledAt:  publishAt,
+		ExecutedAt:   executedAt,
+	}
+	if len(transitions) != 1 || transitions[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, transitions)
+	}
+
+	// Nothing is recorded when no unpublication is due
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "posts" AS r SET status = \$2, published_at = NULL, unpublish_at = NULL`).
+		WithArgs(now, StatusDraft, systemUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "unpublish_at"}))
+	mock.ExpectCommit()
+
+	if transitions, err := NewRepository().ApplyScheduledTransitions(ctx, collection, StatusDraft, now, systemUser); err != nil || len(transitions) != 0 {
+		t.Errorf("expected no transitions, got %+v (%v)", transitions, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -2,27 +2,88 @@ package collection
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/media"
 )
 
 type Service interface {
 	FindBySlug(ctx context.Context, slug string) (*Collection, error)
-	FindResource(ctx context.Context, collection *Collection, slug string) (*Resource, error)
-	FindResources(ctx context.Context, collection *Collection) ([]Resource, error)
+	FindResource(ctx context.Context, c *Collection, slug string) (*Resource, error)
+	FindResources(ctx context.Context, c *Collection, params *FindResourcesParams) ([]Resource, int64, error)
 	FindAll(ctx context.Context, params *FindAllParams) ([]Collection, error)
-	CreateResource(ctx context.Context, collection *Collection, resourceSlug string, createdBy int64, content map[string]any) (*Resource, error)
+	CreateResource(ctx context.Context, c *Collection, resourceSlug string, createdBy int64, content map[string]any) (*Resource, error)
 	FindAllGlobals(ctx context.Context, params *FindAllParams) ([]Collection, error)
-	UpdateResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, content map[string]any) (*Resource, error)
-	DeleteResource(ctx context.Context, resource *Resource) error
+	UpdateResource(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64, content map[string]any) (*Resource, error)
+	DeleteResource(ctx context.Context, c *Collection, resource *Resource, deletedBy int64) error
+	PopulateResources(ctx context.Context, c *Collection, resources []Resource, populate Populate, publishedOnly bool) error
+	PublishResource(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64) (*Resource, error)
+	UnpublishResource(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64) (*Resource, error)
+	FindRevisions(ctx context.Context, c *Collection, resourceSlug string) ([]Revision, error)
+	FindRevision(ctx context.Context, c *Collection, resourceSlug string, id int64) (*Revision, error)
+	RestoreRevision(ctx context.Context, c *Collection, resourceSlug string, id int64, restoredBy int64) (*Resource, error)
+	RestoreResource(ctx context.Context, c *Collection, resourceSlug string, restoredBy int64) (*Resource, error)
+	PurgeResource(ctx context.Context, c *Collection, resourceSlug string) error
+	PurgeTrash(ctx context.Context, retention time.Duration) error
+	ScheduleResource(ctx context.Context, c *Collection, resourceSlug string, updatedBy int64, schedule Schedule) (*Resource, error)
+	ApplyScheduledTransitions(ctx context.Context, now time.Time) error
+	FindScheduledTransitions(ctx context.Context, limit uint64) ([]ScheduledTransition, error)
+	FindLocales(ctx context.Context) ([]Locale, error)
+	LocalizeContent(ctx context.Context, c *Collection, resourceSlug string, content map[string]any, locale string) (map[string]any, error)
+	LocalizeResources(ctx context.Context, c *Collection, resources []Resource, locale string, fallbackLocale string) error
+	ExportResources(ctx context.Context, c *Collection, fn func(resource Resource) error) error
+	ImportResources(ctx context.Context, c *Collection, rows []ImportRow, importedBy int64, batchSize int) (*ImportReport, error)
+	ExecuteBatch(ctx context.Context, operations []BatchOperation, executedBy int64) ([]BatchResult, error)
+	FindReferences(ctx context.Context, target string, id int64) ([]ReferenceGroup, error)
+	AggregateResources(ctx context.Context, c *Collection, params *AggregateParams) ([]AggregateGroup, error)
+	RenderRichText(ctx context.Context, c *Collection, resources []Resource, format RichTextFormat) error
+}
+
+type ServiceOption func(*service)
+
+// WithMediaService sets the media service used to populate media relations.
+func WithMediaService(mediaService media.MediaService) ServiceOption {
+	return func(s *service) {
+		s.mediaService = mediaService
+	}
+}
+
+// WithUserService sets the auth service used to populate user relations.
+func WithUserService(userService auth.Service) ServiceOption {
+	return func(s *service) {
+		s.userService = userService
+	}
+}
+
+// WithMaxPopulateDepth sets the maximum number of nested relations that can be populated.
+func WithMaxPopulateDepth(depth int) ServiceOption {
+	return func(s *service) {
+		s.maxPopulateDepth = depth
+	}
 }
 
-func NewService(collectionRepository Repository) *service {
-	return &service{
+func NewService(collectionRepository Repository, options ...ServiceOption) *service {
+	s := &service{
 		collectionRepository: collectionRepository,
+		maxPopulateDepth:     DefaultMaxPopulateDepth,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 type service struct {
 	collectionRepository Repository
+	mediaService         media.MediaService
+	userService          auth.Service
+	maxPopulateDepth     int
+	eventHooks           []EventHook
 }
 
 func (s *service) FindBySlug(ctx context.Context, slug string) (*Collection, error) {
@@ -33,8 +94,8 @@ func (s *service) FindResource(ctx context.Context, collection *Collection, slug
 	return s.collectionRepository.FindResource(ctx, collection, slug)
 }
 
-func (s *service) FindResources(ctx context.Context, collection *Collection) ([]Resource, error) {
-	return s.collectionRepository.FindResources(ctx, collection)
+func (s *service) FindResources(ctx context.Context, collection *Collection, params *FindResourcesParams) ([]Resource, int64, error) {
+	return s.collectionRepository.FindResources(ctx, collection, params)
 }
 
 func (s *service) FindAll(ctx context.Context, params *FindAllParams) ([]Collection, error) {
@@ -42,7 +103,37 @@ func (s *service) FindAll(ctx context.Context, params *FindAllParams) ([]Collect
 }
 
 func (s *service) CreateResource(ctx context.Context, collection *Collection, resourceSlug string, createdBy int64, content map[string]any) (*Resource, error) {
-	return s.collectionRepository.CreateResource(ctx, collection, resourceSlug, createdBy, content)
+	if collection.IsGlobal && resourceSlug != collection.Slug {
+		return nil, fmt.Errorf("%w: globals have a single resource, with the slug of the global", ErrInvalidContent)
+	}
+
+	if !collection.IsGlobal {
+		if err := validateSlug(resourceSlug); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateContent(ctx, s.collectionRepository, collection, content, false); err != nil {
+		return nil, err
+	}
+
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resource, err = s.collectionRepository.CreateResource(ctx, collection, resourceSlug, createdBy, content); err != nil {
+			return err
+		}
+
+		if err := s.recordRevision(ctx, collection, resource, RevisionCreate, createdBy); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, EventResourceCreated, collection, resource)
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
 }
 
 func (s *service) FindAllGlobals(ctx context.Context, params *FindAllParams) ([]Collection, error) {
@@ -50,9 +141,86 @@ func (s *service) FindAllGlobals(ctx context.Context, params *FindAllParams) ([]
 }
 
 func (s *service) UpdateResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, content map[string]any) (*Resource, error) {
-	return s.collectionRepository.UpdateResource(ctx, collection, resourceSlug, updatedBy, content)
+	if err := validateContent(ctx, s.collectionRepository, collection, content, true); err != nil {
+		return nil, err
+	}
+
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resource, err = s.collectionRepository.UpdateResource(ctx, collection, resourceSlug, updatedBy, content); err != nil {
+			return err
+		}
+
+		if err := s.recordRevision(ctx, collection, resource, RevisionUpdate, updatedBy); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, EventResourceUpdated, collection, resource)
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// DeleteResource moves a resource to the trash, unless it is referenced through a relation field restricting its deletion.
+// The other onDelete actions apply when the resource is purged, as the resource can be restored until then.
+func (s *service) DeleteResource(ctx context.Context, collection *Collection, resource *Resource, deletedBy int64) error {
+	if collection.IsGlobal {
+		return fmt.Errorf("%w: globals cannot be deleted", ErrInvalidContent)
+	}
+
+	return config.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkRestrictedReferences(ctx, collection, resource.Id); err != nil {
+			return err
+		}
+
+		// The snapshot keeps the content of the resource, so that it can be compared once restored
+		if err := s.recordRevision(ctx, collection, resource, RevisionDelete, deletedBy); err != nil {
+			return err
+		}
+
+		if err := s.collectionRepository.DeleteResource(ctx, resource); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, EventResourceDeleted, collection, resource)
+	})
 }
 
-func (s *service) DeleteResource(ctx context.Context, resource *Resource) error {
-	return s.collectionRepository.DeleteResource(ctx, resource)
+func (s *service) PublishResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64) (*Resource, error) {
+	return s.updateResourceStatus(ctx, collection, resourceSlug, updatedBy, StatusPublished)
+}
+
+func (s *service) UnpublishResource(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64) (*Resource, error) {
+	return s.updateResourceStatus(ctx, collection, resourceSlug, updatedBy, StatusDraft)
+}
+
+// updateResourceStatus changes the status of a resource and records it as a revision, a resource back to draft
+// is notified as updated.
+func (s *service) updateResourceStatus(ctx context.Context, collection *Collection, resourceSlug string, updatedBy int64, status Status) (*Resource, error) {
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resource, err = s.collectionRepository.UpdateResourceStatus(ctx, collection, resourceSlug, updatedBy, status); err != nil {
+			return err
+		}
+
+		if status == StatusPublished {
+			if err := s.recordRevision(ctx, collection, resource, RevisionPublish, updatedBy); err != nil {
+				return err
+			}
+			return s.emit(ctx, EventResourcePublished, collection, resource)
+		}
+
+		if err := s.recordRevision(ctx, collection, resource, RevisionUnpublish, updatedBy); err != nil {
+			return err
+		}
+		return s.emit(ctx, EventResourceUpdated, collection, resource)
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
 }
@@ -0,0 +1,262 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/cron"
+	"github.com/mimsy-cms/mimsy/internal/postgres"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// DefaultTrashRetention is the default duration resources stay in the trash before being purged.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// RestoreResource moves a resource out of the trash.
+func (r *repository) RestoreResource(ctx context.Context, collection *Collection, resourceSlug string, restoredBy int64) (*Resource, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update(pq.QuoteIdentifier(collection.Slug)).
+		Set("deleted_at", nil).
+		Set("updated_at", sq.Expr("NOW()")).
+		Set("updated_by", restoredBy).
+		Where(sq.Eq{"slug": resourceSlug}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build restore SQL query: %w", err)
+	}
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore resource: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	} else if rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	return r.FindResource(ctx, collection, resourceSlug)
+}
+
+// PurgeResource permanently deletes a resource from the trash.
+func (r *repository) PurgeResource(ctx context.Context, collection *Collection, resourceSlug string) error {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`"id"`).
+		From(pq.QuoteIdentifier(collection.Slug)).
+		Where(sq.Eq{"slug": resourceSlug}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build trash lookup SQL query: %w", err)
+	}
+
+	var id int64
+	if err := config.GetDB(ctx).QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to find trashed resource: %w", err)
+	}
+
+	return config.WithinTx(ctx, func(ctx context.Context) error {
+		return r.purge(ctx, collection, []int64{id})
+	})
+}
+
+// PurgeTrash permanently deletes the resources of a collection trashed before the given time.
+// It returns the number of deleted resources.
+func (r *repository) PurgeTrash(ctx context.Context, collection *Collection, before time.Time) (int64, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(`"id"`).
+		From(pq.QuoteIdentifier(collection.Slug)).
+		Where(sq.Lt{"deleted_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build trash lookup SQL query: %w", err)
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query trashed resources: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("failed to scan trashed resource: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating over trashed resources: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		return r.purge(ctx, collection, ids)
+	}); err != nil {
+		return 0, err
+	}
+
+	return int64(len(ids)), nil
+}
+
+// purge deletes resources along with their many-to-many relations, it must be called within a transaction.
+// The relations of the resources deleted by a cascade are deleted by the database, with their owner.
+func (r *repository) purge(ctx context.Context, collection *Collection, ids []int64) error {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	for name, element := range fields {
+		if element.Type != "multi_relation" {
+			continue
+		}
+
+		relation, err := NewRelation(collection.Slug, name, element)
+		if err != nil {
+			return err
+		}
+
+		query, args, err := psql.
+			Delete(pq.QuoteIdentifier(relation.JoinTable)).
+			Where(sq.Eq{pq.QuoteIdentifier(relation.OwnerColumn): ids}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build relation delete SQL query: %w", err)
+		}
+
+		if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to delete relations of field %q: %w", name, err)
+		}
+	}
+
+	query, args, err := psql.
+		Delete(pq.QuoteIdentifier(collection.Slug)).
+		Where(sq.Eq{`"id"`: ids}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete SQL query: %w", err)
+	}
+
+	if _, err := config.GetDB(ctx).ExecContext(ctx, query, args...); err != nil {
+		if postgres.IsErrCode(err, postgres.ErrForeignKeyViolation) {
+			return ErrReferenced
+		}
+		return fmt.Errorf("failed to delete resources: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) RestoreResource(ctx context.Context, collection *Collection, resourceSlug string, restoredBy int64) (*Resource, error) {
+	var resource *Resource
+	if err := config.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resource, err = s.collectionRepository.RestoreResource(ctx, collection, resourceSlug, restoredBy); err != nil {
+			return err
+		}
+
+		if err := s.recordRevision(ctx, collection, resource, RevisionRestore, restoredBy); err != nil {
+			return err
+		}
+
+		// The resource is back in the lists as if it was created again
+		return s.emit(ctx, EventResourceCreated, collection, resource)
+	}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// PurgeResource permanently deletes a resource from the trash.
+// When the deletion is prevented by relations, the error lists the blocking references.
+func (s *service) PurgeResource(ctx context.Context, collection *Collection, resourceSlug string) error {
+	err := s.collectionRepository.PurgeResource(ctx, collection, resourceSlug)
+	if !errors.Is(err, ErrReferenced) {
+		return err
+	}
+
+	trashed, _, findErr := s.collectionRepository.FindResources(ctx, collection, &FindResourcesParams{
+		Filters: []Filter{{Field: "slug", Operator: OperatorEquals, Value: resourceSlug}},
+		Trashed: true,
+	})
+	if findErr != nil || len(trashed) == 0 {
+		return err
+	}
+
+	return s.referencedError(ctx, collection, trashed[0].Id, err)
+}
+
+// PurgeTrash permanently deletes the resources of every collection trashed for longer than the retention period.
+// A collection that fails to be purged does not prevent the others from being purged.
+func (s *service) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	collections, err := s.collectionRepository.FindAll(ctx, &FindAllParams{})
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	globals, err := s.collectionRepository.FindAllGlobals(ctx, &FindAllParams{})
+	if err != nil {
+		return fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	before := time.Now().Add(-retention)
+
+	var errs []error
+	for _, collection := range append(collections, globals...) {
+		count, err := s.collectionRepository.PurgeTrash(ctx, &collection, before)
+		if err != nil {
+			slog.Error("Failed to purge trash", "slug", collection.Slug, "error", err)
+			errs = append(errs, fmt.Errorf("failed to purge trash of %s: %w", collection.Slug, err))
+			continue
+		}
+
+		if count > 0 {
+			slog.Info("Purged trash", "slug", collection.Slug, "count", count)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RegisterTrashJobs registers the cron job that purges the trash every hour.
+func RegisterTrashJobs(cronService cron.CronService, db *sql.DB, service Service, retention time.Duration) error {
+	ctx := context.Background()
+
+	purgeJob := cron.Job{
+		Name:     "purge-trash",
+		Schedule: "0 * * * *", // Every hour
+		Function: func() error {
+			ctx := config.ContextWithDB(context.Background(), db)
+			return service.PurgeTrash(ctx, retention)
+		},
+		Params: []any{},
+	}
+
+	if err := cronService.RegisterJob(ctx, purgeJob); err != nil {
+		return fmt.Errorf("failed to register trash purge job: %w", err)
+	}
+
+	slog.Info("Successfully registered trash purge job", "retention", retention)
+	return nil
+}
@@ -0,0 +1,189 @@
+package collection_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+// =================================================================================================
+// Trash Tests
+// =================================================================================================
+
+func TestGetTrash_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockResource := createMockResource()
+	mockResource.DeletedAt = &deletedAt
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		FindResources(gomock.Any(), mockCollection, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *collection.Collection, params *collection.FindResourcesParams) ([]collection.Resource, int64, error) {
+			if !params.Trashed {
+				t.Errorf("expected trashed resources to be requested")
+			}
+			if params.PublishedOnly {
+				t.Errorf("expected drafts to be included in the trash")
+			}
+			return []collection.Resource{*mockResource}, 1, nil
+		})
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/trash", nil)
+	req.SetPathValue("slug", "test-collection")
+	req = addUserToContext(req, createMockUser())
+
+	w := executeRequest(http.HandlerFunc(handler.GetTrash), req, t)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v", w.Code)
+	}
+
+	if total := w.Header().Get("X-Total-Count"); total != "1" {
+		t.Errorf("expected X-Total-Count 1, got %q", total)
+	}
+}
+
+func TestGetTrash_Unauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/collections/test-collection/trash", nil)
+	req.SetPathValue("slug", "test-collection")
+
+	w := executeRequest(http.HandlerFunc(handler.GetTrash), req, t)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status Unauthorized, got %v", w.Code)
+	}
+}
+
+func TestRestoreResource_NotInTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	user := createMockUser()
+	mockCollection := createMockCollection()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		RestoreResource(gomock.Any(), mockCollection, "test-resource", user.ID).
+		Return(nil, collection.ErrNotFound)
+
+	req := httptest.NewRequest("POST", "/collections/test-collection/trash/test-resource/restore", nil)
+	req.SetPathValue("slug", "test-collection")
+	req.SetPathValue("resourceSlug", "test-resource")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.RestoreResource), req, t)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status Not Found, got %v", w.Code)
+	}
+}
+
+func TestPurgeResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "purged", err: nil, expected: http.StatusNoContent},
+		{name: "not in trash", err: collection.ErrNotFound, expected: http.StatusNotFound},
+		{name: "referenced", err: collection.ErrReferenced, expected: http.StatusConflict},
+		{name: "database error", err: errors.New("database error"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mocks.NewMockService(ctrl)
+			handler := collection.NewHandler(mockService)
+
+			mockCollection := createMockCollection()
+
+			mockService.EXPECT().
+				FindBySlug(gomock.Any(), "test-collection").
+				Return(mockCollection, nil)
+
+			mockService.EXPECT().
+				PurgeResource(gomock.Any(), mockCollection, "test-resource").
+				Return(tt.err)
+
+			req := httptest.NewRequest("DELETE", "/collections/test-collection/trash/test-resource", nil)
+			req.SetPathValue("slug", "test-collection")
+			req.SetPathValue("resourceSlug", "test-resource")
+			req = addUserToContext(req, createMockUser())
+
+			w := executeRequest(http.HandlerFunc(handler.PurgeResource), req, t)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected status %v, got %v", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestService_PurgeTrash_ContinuesOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepository := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepository)
+
+	mockRepository.EXPECT().
+		FindAll(gomock.Any(), gomock.Any()).
+		Return([]collection.Collection{{Slug: "posts"}, {Slug: "tags"}}, nil)
+
+	mockRepository.EXPECT().
+		FindAllGlobals(gomock.Any(), gomock.Any()).
+		Return([]collection.Collection{{Slug: "settings"}}, nil)
+
+	expectedBefore := time.Now().Add(-time.Hour)
+
+	mockRepository.EXPECT().
+		PurgeTrash(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, c *collection.Collection, before time.Time) (int64, error) {
+			if before.Sub(expectedBefore).Abs() > time.Minute {
+				t.Errorf("expected resources trashed before %v to be purged, got %v", expectedBefore, before)
+			}
+			if c.Slug == "tags" {
+				return 0, errors.New("database error")
+			}
+			return 1, nil
+		}).
+		Times(3)
+
+	err := service.PurgeTrash(context.Background(), time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Fatalf("expected the error of the tags collection, got %v", err)
+	}
+}
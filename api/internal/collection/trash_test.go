@@ -0,0 +1,102 @@
+package collection
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func TestPurgeResource_DeletesRelations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	collection := NewTestCollection("posts", mimsy_schema.CollectionFields{
+		"tags": {Type: "multi_relation", RelatesTo: "tags"},
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "posts" WHERE slug = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs("hello").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "posts_tags_relation_tags" WHERE "posts_id" IN ($1)`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "posts" WHERE "id" IN ($1)`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewRepository().PurgeResource(ctx, collection, "hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPurgeResource_Cascade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	authors := NewTestCollection("authors", mimsy_schema.CollectionFields{})
+
+	// The books of the author are deleted by their cascading relation, and their tags by the join table of the books
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "authors" WHERE slug = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs("tolkien").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(2)))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "authors" WHERE "id" IN ($1)`)).
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewRepository().PurgeResource(ctx, authors, "tolkien"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPurgeResource_Referenced(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := config.ContextWithDB(context.Background(), db)
+	collection := NewTestCollection("tags", mimsy_schema.CollectionFields{})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "tags" WHERE slug = $1 AND deleted_at IS NOT NULL`)).
+		WithArgs("sql").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(3)))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "tags" WHERE "id" IN ($1)`)).
+		WithArgs(int64(3)).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	if err := NewRepository().PurgeResource(ctx, collection, "sql"); err != ErrReferenced {
+		t.Fatalf("expected ErrReferenced, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
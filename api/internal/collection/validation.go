@@ -0,0 +1,470 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// FieldError describes why the value given for a field is invalid.
+type FieldError struct {
+	// Path is the key of the invalid value in the payload, list items are suffixed by their index (`tags[1]`)
+	// and localized values by their locale (`title.fr`).
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError is returned when the content of a resource does not match its collection definition.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		reasons[i] = fmt.Sprintf("%s: %s", fieldError.Path, fieldError.Reason)
+	}
+	return "validation failed: " + strings.Join(reasons, ", ")
+}
+
+func (e *ValidationError) add(path string, reason string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Path: path, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// reservedSlugs are the collection actions routed next to the resources, under `/collections/{slug}/`.
+// A resource using one of them as its slug could not be reached.
+var reservedSlugs = []string{"aggregate", "definition", "export", "import", "trash"}
+
+// validateSlug checks that a resource slug does not collide with a collection action.
+func validateSlug(slug string) error {
+	if slices.Contains(reservedSlugs, slug) {
+		return &ValidationError{Errors: []FieldError{{Path: "slug", Reason: fmt.Sprintf("%q is reserved", slug)}}}
+	}
+	return nil
+}
+
+// validator checks the content of a resource against the fields of its collection.
+type validator struct {
+	repository Repository
+	// partial is true for updates, where only the given fields are checked.
+	partial bool
+	// locales are loaded on the first localized field.
+	locales []Locale
+	errors  ValidationError
+}
+
+// validateContent checks the content of a resource against the collection definition.
+// On creation every required field must be given, on update only the given fields are checked.
+// It returns a *ValidationError listing every invalid field.
+func validateContent(ctx context.Context, repository Repository, collection *Collection, content map[string]any, partial bool) error {
+	fields := mimsy_schema.CollectionFields{}
+	if err := json.Unmarshal(collection.Fields, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal collection fields: %w", err)
+	}
+
+	v := &validator{repository: repository, partial: partial}
+	v.validateKeys(content, fields, append([]string{"slug"}, readOnlyColumns...))
+	if slug, ok := content["slug"].(string); ok && slices.Contains(reservedSlugs, slug) {
+		v.errors.add("slug", "%q is reserved", slug)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := v.validateField(ctx, name, fields[name], content); err != nil {
+			return err
+		}
+	}
+
+	if len(v.errors.Errors) > 0 {
+		return &v.errors
+	}
+	return nil
+}
+
+// validateKeys rejects the keys of the content that are neither a field nor one of the given system keys,
+// instead of silently dropping or storing them.
+func (v *validator) validateKeys(content map[string]any, fields mimsy_schema.CollectionFields, system []string) {
+	for _, key := range sortedKeys(content) {
+		if _, ok := fieldOfKey(fields, key); !ok && !slices.Contains(system, key) {
+			v.errors.add(key, "is not a known field")
+		}
+	}
+}
+
+// contentKey returns the key holding the value of a field in the content of a resource.
+func contentKey(name string, element mimsy_schema.SchemaElement) string {
+	if element.Type == "relation" {
+		return fmt.Sprintf("%s_id", name)
+	}
+	return name
+}
+
+// fieldOfKey returns the name of the field whose value is held by a key of the content.
+func fieldOfKey(fields mimsy_schema.CollectionFields, key string) (string, bool) {
+	for name, element := range fields {
+		if contentKey(name, element) == key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (v *validator) validateField(ctx context.Context, name string, element mimsy_schema.SchemaElement, content map[string]any) error {
+	key := contentKey(name, element)
+
+	value, present := content[key]
+	if element.IsLocalized() {
+		return v.validateLocalized(ctx, key, element, value, present)
+	}
+
+	if isEmpty(value) {
+		if element.IsRequired() && (present || !v.partial) {
+			v.errors.add(key, "is required")
+		}
+		return nil
+	}
+
+	return v.validateValue(ctx, key, element, value)
+}
+
+// validateValue checks a non empty value against the type of its field.
+func (v *validator) validateValue(ctx context.Context, key string, element mimsy_schema.SchemaElement, value any) error {
+	switch element.Type {
+	case "string", "long_string":
+		v.validateString(key, element, value)
+	case "email":
+		if v.validateString(key, element, value) {
+			if address, err := mail.ParseAddress(value.(string)); err != nil || address.Address != value {
+				v.errors.add(key, "must be a valid email address")
+			}
+		}
+	case "number":
+		if _, ok := toFloat(value); !ok {
+			v.errors.add(key, "must be a number")
+		}
+	case "date_time", "created_at":
+		if _, ok := toTime(value); !ok {
+			v.errors.add(key, "must be a RFC3339 date")
+		}
+	case "checkbox":
+		if _, ok := value.(bool); !ok {
+			v.errors.add(key, "must be a boolean")
+		}
+	case "select":
+		v.validateSelect(key, element, value)
+	case "relation":
+		id, ok := toId(value)
+		if !ok {
+			v.errors.add(key, "must be an identifier")
+			return nil
+		}
+		return v.validateReferences(ctx, element, map[string]int64{key: id}, nil)
+	case "multi_relation":
+		return v.validateMultiRelation(ctx, key, element, value)
+	case "blocks":
+		return v.validateBlocks(ctx, key, element, value)
+	}
+
+	return nil
+}
+
+// validateLocalized checks the values by locale of a localized field.
+// Required fields must have a value in the default locale.
+func (v *validator) validateLocalized(ctx context.Context, key string, element mimsy_schema.SchemaElement, value any, present bool) error {
+	values := map[string]any{}
+	if value != nil {
+		var ok bool
+		if values, ok = value.(map[string]any); !ok {
+			v.errors.add(key, "must be an object of values by locale")
+			return nil
+		}
+	}
+
+	if v.locales == nil {
+		locales, err := v.repository.FindLocales(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to find locales: %w", err)
+		}
+		v.locales = locales
+	}
+
+	for _, code := range sortedKeys(values) {
+		path := fmt.Sprintf("%s.%s", key, code)
+		if !slices.ContainsFunc(v.locales, func(locale Locale) bool { return locale.Code == code }) {
+			v.errors.add(path, "is not a supported locale")
+			continue
+		}
+
+		if isEmpty(values[code]) {
+			continue
+		}
+		if err := v.validateValue(ctx, path, element, values[code]); err != nil {
+			return err
+		}
+	}
+
+	if element.IsRequired() && (present || !v.partial) {
+		index := slices.IndexFunc(v.locales, func(locale Locale) bool { return locale.IsDefault })
+		if index == -1 {
+			v.errors.add(key, "is required but no default locale is declared")
+		} else if code := v.locales[index].Code; isEmpty(values[code]) {
+			v.errors.add(fmt.Sprintf("%s.%s", key, code), "is required")
+		}
+	}
+
+	return nil
+}
+
+// validateString checks the type and length constraints of a string value, returning whether it is a string.
+func (v *validator) validateString(key string, element mimsy_schema.SchemaElement, value any) bool {
+	s, ok := value.(string)
+	if !ok {
+		v.errors.add(key, "must be a string")
+		return false
+	}
+
+	if element.Options == nil || element.Options.Constraints == nil {
+		return true
+	}
+
+	constraints := element.Options.Constraints
+	length := utf8.RuneCountInString(s)
+	if constraints.MinLength > 0 && length < constraints.MinLength {
+		v.errors.add(key, "must be at least %d characters long", constraints.MinLength)
+	}
+	if constraints.MaxLength > 0 && length > constraints.MaxLength {
+		v.errors.add(key, "must be at most %d characters long", constraints.MaxLength)
+	}
+
+	return true
+}
+
+// validateSelect checks that the value of a select field is one of its values, or a list of them for multiple selects.
+func (v *validator) validateSelect(key string, element mimsy_schema.SchemaElement, value any) {
+	allowed := element.GetValues()
+
+	if !element.IsMultiple() {
+		if s, ok := value.(string); !ok {
+			v.errors.add(key, "must be a string")
+		} else if !slices.Contains(allowed, s) {
+			v.errors.add(key, "must be one of %s", strings.Join(allowed, ", "))
+		}
+		return
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		v.errors.add(key, "must be a list of strings")
+		return
+	}
+
+	if len(values) == 0 && element.IsRequired() {
+		v.errors.add(key, "is required")
+		return
+	}
+
+	for i, item := range values {
+		path := fmt.Sprintf("%s[%d]", key, i)
+
+		if s, ok := item.(string); !ok {
+			v.errors.add(path, "must be a string")
+		} else if !slices.Contains(allowed, s) {
+			v.errors.add(path, "must be one of %s", strings.Join(allowed, ", "))
+		} else if slices.Contains(values[:i], item) {
+			v.errors.add(path, "is given more than once")
+		}
+	}
+}
+
+func (v *validator) validateMultiRelation(ctx context.Context, key string, element mimsy_schema.SchemaElement, value any) error {
+	values, ok := value.([]any)
+	if !ok {
+		v.errors.add(key, "must be a list of identifiers or slugs")
+		return nil
+	}
+
+	if len(values) == 0 && element.IsRequired() {
+		v.errors.add(key, "is required")
+		return nil
+	}
+
+	ids := map[string]int64{}
+	slugs := map[string]string{}
+	for i, item := range values {
+		path := fmt.Sprintf("%s[%d]", key, i)
+
+		if slug, ok := item.(string); ok && !schema_generator.IsBuiltin(element.RelatesTo) {
+			slugs[path] = slug
+		} else if id, ok := toId(item); ok {
+			ids[path] = id
+		} else {
+			v.errors.add(path, "must be an identifier or a slug")
+		}
+	}
+
+	return v.validateReferences(ctx, element, ids, slugs)
+}
+
+// validateBlocks checks that the value of a blocks field is a list of blocks, each having a known `block_type`
+// and fields valid against the fields of its type. Blocks are replaced as a whole, so all their required fields must be given.
+func (v *validator) validateBlocks(ctx context.Context, key string, element mimsy_schema.SchemaElement, value any) error {
+	values, ok := value.([]any)
+	if !ok {
+		v.errors.add(key, "must be a list of blocks")
+		return nil
+	}
+
+	if len(values) == 0 && element.IsRequired() {
+		v.errors.add(key, "is required")
+		return nil
+	}
+
+	blocks := element.GetBlocks()
+	for i, item := range values {
+		path := fmt.Sprintf("%s[%d]", key, i)
+
+		block, ok := item.(map[string]any)
+		if !ok {
+			v.errors.add(path, "must be an object")
+			continue
+		}
+
+		name, _ := block[schema_generator.BlockTypeColumn].(string)
+		fields, ok := blocks[name]
+		if !ok {
+			v.errors.add(fmt.Sprintf("%s.%s", path, schema_generator.BlockTypeColumn), "must be one of %s", strings.Join(sortedKeys(blocks), ", "))
+			continue
+		}
+
+		blockValidator := &validator{repository: v.repository, locales: v.locales}
+		blockValidator.validateKeys(block, fields, []string{schema_generator.BlockTypeColumn})
+		for _, field := range sortedKeys(fields) {
+			if err := blockValidator.validateField(ctx, field, fields[field], block); err != nil {
+				return err
+			}
+		}
+		for _, fieldError := range blockValidator.errors.Errors {
+			v.errors.add(fmt.Sprintf("%s.%s", path, fieldError.Path), "%s", fieldError.Reason)
+		}
+	}
+
+	return nil
+}
+
+// validateReferences checks that the referenced resources exist, the maps are indexed by path.
+func (v *validator) validateReferences(ctx context.Context, element mimsy_schema.SchemaElement, ids map[string]int64, slugs map[string]string) error {
+	if len(ids) > 0 {
+		existing, err := v.repository.FindExistingIds(ctx, element.RelatesTo, mapValues(ids))
+		if err != nil {
+			return fmt.Errorf("failed to check references: %w", err)
+		}
+
+		for _, path := range sortedKeys(ids) {
+			if !slices.Contains(existing, ids[path]) {
+				v.errors.add(path, "references %s %d which does not exist", element.RelatesTo, ids[path])
+			}
+		}
+	}
+
+	if len(slugs) > 0 {
+		existing, err := v.repository.FindExistingSlugs(ctx, element.RelatesTo, mapValues(slugs))
+		if err != nil {
+			return fmt.Errorf("failed to check references: %w", err)
+		}
+
+		for _, path := range sortedKeys(slugs) {
+			if !slices.Contains(existing, slugs[path]) {
+				v.errors.add(path, "references %s %q which does not exist", element.RelatesTo, slugs[path])
+			}
+		}
+	}
+
+	return nil
+}
+
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	if s, ok := value.(string); ok {
+		return s == ""
+	}
+	return false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+func toId(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case string:
+		id, err := strconv.ParseInt(v, 10, 64)
+		return id, err == nil
+	default:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return 0, false
+		}
+		return int64(f), true
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func mapValues[V comparable](m map[string]V) []V {
+	values := make([]V, 0, len(m))
+	for _, value := range m {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,363 @@
+package collection_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+// =================================================================================================
+// Service Tests - Validation
+// =================================================================================================
+
+func TestService_CreateResource_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+
+	mockRepo.EXPECT().
+		FindExistingIds(gomock.Any(), "authors", []int64{4}).
+		Return([]int64{}, nil)
+
+	mockRepo.EXPECT().
+		FindExistingIds(gomock.Any(), "tags", []int64{1}).
+		Return([]int64{1}, nil)
+
+	mockRepo.EXPECT().
+		FindExistingSlugs(gomock.Any(), "tags", []string{"go"}).
+		Return([]string{}, nil)
+
+	content := map[string]any{
+		"contact":   "not an email",
+		"views":     "many",
+		"published": "yes",
+		"date":      "2024-01-01",
+		"author_id": float64(4),
+		"tags":      []any{float64(1), "go", true},
+	}
+
+	_, err := service.CreateResource(context.Background(), collection.NewTestCollection("articles", articlesFields), "article", 1, content)
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{
+		{Path: "author_id", Reason: "references authors 4 which does not exist"},
+		{Path: "contact", Reason: "must be a valid email address"},
+		{Path: "date", Reason: "must be a RFC3339 date"},
+		{Path: "published", Reason: "must be a boolean"},
+		{Path: "tags[2]", Reason: "must be an identifier or a slug"},
+		{Path: "tags[1]", Reason: `references tags "go" which does not exist`},
+		{Path: "title", Reason: "is required"},
+		{Path: "views", Reason: "must be a number"},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+}
+
+func TestService_CreateResource_Valid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+	mockCollection := collection.NewTestCollection("articles", articlesFields)
+
+	content := map[string]any{
+		"slug":      "article",
+		"title":     "Hello",
+		"contact":   "jane@example.com",
+		"views":     "12",
+		"published": true,
+		"date":      "2024-01-01T10:00:00Z",
+		"tags":      []any{},
+	}
+
+	mockRepo.EXPECT().
+		CreateResource(gomock.Any(), mockCollection, "article", int64(1), content).
+		Return(createMockResource(), nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return(map[int64][]int64{}, nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	if _, err := service.CreateResource(context.Background(), mockCollection, "article", 1, content); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestService_UpdateResource_PartialValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+	mockCollection := collection.NewTestCollection("articles", articlesFields)
+
+	_, err := service.UpdateResource(context.Background(), mockCollection, "article", 1, map[string]any{"title": "A much too long title"})
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{{Path: "title", Reason: "must be at most 10 characters long"}}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+
+	content := map[string]any{"views": float64(3)}
+	mockRepo.EXPECT().
+		UpdateResource(gomock.Any(), mockCollection, "article", int64(1), content).
+		Return(createMockResource(), nil)
+
+	mockRepo.EXPECT().
+		FindRelationIds(gomock.Any(), gomock.Any(), []int64{1}).
+		Return(map[int64][]int64{}, nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	if _, err := service.UpdateResource(context.Background(), mockCollection, "article", 1, content); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestService_CreateResource_ReservedSlug(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+	mockCollection := collection.NewTestCollection("articles", articlesFields)
+
+	for _, slug := range []string{"trash", "export", "import", "aggregate", "definition"} {
+		_, err := service.CreateResource(context.Background(), mockCollection, slug, 1, map[string]any{"title": "Hello"})
+
+		var validationErr *collection.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("%s: expected a validation error, got %v", slug, err)
+		}
+		if validationErr.Errors[0].Path != "slug" {
+			t.Errorf("%s: expected an error on the slug, got %v", slug, validationErr.Errors)
+		}
+	}
+
+	_, err := service.UpdateResource(context.Background(), mockCollection, "article", 1, map[string]any{"slug": "trash"})
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a validation error when renaming, got %v", err)
+	}
+}
+
+func TestService_UpdateResource_UnknownKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+	mockCollection := collection.NewTestCollection("articles", articlesFields)
+
+	// System keys sent back by clients are accepted, the relation is only known by its column
+	content := map[string]any{"id": float64(1), "slug": "article", "updated_at": "2024-01-01T10:00:00Z", "titel": "Hello", "author": float64(4)}
+
+	_, err := service.UpdateResource(context.Background(), mockCollection, "article", 1, content)
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{
+		{Path: "author", Reason: "is not a known field"},
+		{Path: "titel", Reason: "is not a known field"},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+
+	if _, err := service.CreateResource(context.Background(), mockCollection, "article", 1, map[string]any{"title": "Hello", "titel": "Hello"}); !errors.As(err, &validationErr) {
+		t.Errorf("expected a validation error on creation, got %v", err)
+	}
+}
+
+func TestCreateResource_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := collection.NewHandler(mockService)
+
+	mockCollection := createMockCollection()
+	user := createMockUser()
+
+	mockService.EXPECT().
+		FindBySlug(gomock.Any(), "test-collection").
+		Return(mockCollection, nil)
+
+	mockService.EXPECT().
+		CreateResource(gomock.Any(), mockCollection, "test-resource", user.ID, gomock.Any()).
+		Return(nil, &collection.ValidationError{Errors: []collection.FieldError{{Path: "title", Reason: "is required"}}})
+
+	req := newJSONRequest(t, "POST", "/collections/test-collection", `{"slug": "test-resource"}`)
+	req.SetPathValue("slug", "test-collection")
+	req = addUserToContext(req, user)
+
+	w := executeRequest(http.HandlerFunc(handler.CreateResource), req, t)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status Unprocessable Entity, got %v", w.Code)
+	}
+
+	var response collection.ValidationError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Errors) != 1 || response.Errors[0].Path != "title" {
+		t.Errorf("expected a single error on title, got %v", response.Errors)
+	}
+}
+
+// =================================================================================================
+// Select Tests
+// =================================================================================================
+
+// selectFields are the fields of a collection with a single and a multiple select.
+var selectFields = mimsy_schema.CollectionFields{
+	"category": {Type: "select", Options: &mimsy_schema.SchemaElementOptions{
+		Values:      []string{"news", "guide"},
+		Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+	}},
+	"labels": {Type: "select", Options: &mimsy_schema.SchemaElementOptions{
+		Values:   []string{"new", "featured"},
+		Multiple: true,
+	}},
+}
+
+func TestService_CreateResource_SelectValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	content := map[string]any{
+		"category": "opinion",
+		"labels":   []any{"new", "old", float64(1), "new"},
+	}
+
+	_, err := service.CreateResource(context.Background(), collection.NewTestCollection("articles", selectFields), "article", 1, content)
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{
+		{Path: "category", Reason: "must be one of news, guide"},
+		{Path: "labels[1]", Reason: "must be one of new, featured"},
+		{Path: "labels[2]", Reason: "must be a string"},
+		{Path: "labels[3]", Reason: "is given more than once"},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+}
+
+func TestService_CreateResource_SelectValid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := collection.NewService(mockRepo)
+	mockCollection := collection.NewTestCollection("articles", selectFields)
+
+	content := map[string]any{
+		"slug":     "article",
+		"category": "guide",
+		"labels":   []any{"featured", "new"},
+	}
+
+	mockRepo.EXPECT().
+		CreateResource(gomock.Any(), mockCollection, "article", int64(1), content).
+		Return(createMockResource(), nil)
+
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	if _, err := service.CreateResource(context.Background(), mockCollection, "article", 1, content); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// =================================================================================================
+// Blocks Tests
+// =================================================================================================
+
+func TestService_CreateResource_BlocksValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := collection.NewService(mocks.NewMockRepository(ctrl))
+
+	fields := mimsy_schema.CollectionFields{
+		"content": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{
+				"hero": {"heading": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{
+					Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+				}}},
+				"columns": {"items": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+					Blocks: map[string]mimsy_schema.CollectionFields{"text": {"body": {Type: "string"}}},
+				}}},
+			},
+		}},
+	}
+	mockCollection := collection.NewTestCollection("pages", fields)
+
+	content := map[string]any{
+		"content": []any{
+			map[string]any{"block_type": "hero"},
+			map[string]any{"block_type": "quote"},
+			"text",
+			map[string]any{"block_type": "columns", "items": []any{
+				map[string]any{"block_type": "text", "body": "First"},
+				map[string]any{"block_type": "text", "body": float64(2)},
+			}},
+		},
+	}
+
+	_, err := service.CreateResource(context.Background(), mockCollection, "home", 1, content)
+
+	var validationErr *collection.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []collection.FieldError{
+		{Path: "content[0].heading", Reason: "is required"},
+		{Path: "content[1].block_type", Reason: "must be one of columns, hero"},
+		{Path: "content[2]", Reason: "must be an object"},
+		{Path: "content[3].items[1].body", Reason: "must be a string"},
+	}
+	if !reflect.DeepEqual(validationErr.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, validationErr.Errors)
+	}
+}
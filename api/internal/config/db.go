@@ -132,3 +132,28 @@ func WithinTx(ctx context.Context, fn func(context.Context) error) error {
 
 	return tx.Commit()
 }
+
+// WithinSavepoint executes a function within a savepoint of the transaction of the context.
+// If an error is returned inside the fn function, only its changes are rolled back,
+// and the transaction can still be used afterwards.
+// If the context does not contain a transaction, it behaves like WithinTx.
+func WithinSavepoint(ctx context.Context, fn func(context.Context) error) error {
+	tx := getTx(ctx)
+	if tx == nil {
+		return WithinTx(ctx, fn)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT mimsy_savepoint"); err != nil {
+		return err
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT mimsy_savepoint"); rollbackErr != nil {
+			return fmt.Errorf("error during rollback: %w", rollbackErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT mimsy_savepoint")
+	return err
+}
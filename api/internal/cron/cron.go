@@ -17,6 +17,8 @@ type Job struct {
 	Schedule string
 	Function any
 	Params   []any
+	// History returns the recent work of the job, shown along with its status. It is optional.
+	History func(ctx context.Context) (any, error)
 }
 
 type JobStatus struct {
@@ -25,12 +27,14 @@ type JobStatus struct {
 	LastRun  time.Time `json:"last_run"`
 	NextRun  time.Time `json:"next_run"`
 	IsRunning bool     `json:"is_running"`
+	History   any      `json:"history,omitempty"`
 }
 
 type Scheduler struct {
 	scheduler    gocron.Scheduler
 	jobs         map[string]gocron.Job
 	jobSchedules map[string]string
+	jobHistories map[string]func(ctx context.Context) (any, error)
 	mu           sync.RWMutex
 	ctx          context.Context
 	cancel       context.CancelFunc
@@ -59,6 +63,7 @@ func NewScheduler(db *sql.DB) (*Scheduler, error) {
 		scheduler:    scheduler,
 		jobs:         make(map[string]gocron.Job),
 		jobSchedules: make(map[string]string),
+		jobHistories: make(map[string]func(ctx context.Context) (any, error)),
 		ctx:          ctx,
 		cancel:       cancel,
 	}, nil
@@ -139,6 +144,9 @@ func (s *Scheduler) RegisterJob(job Job) error {
 
 	s.jobs[job.Name] = scheduledJob
 	s.jobSchedules[job.Name] = job.Schedule
+	if job.History != nil {
+		s.jobHistories[job.Name] = job.History
+	}
 	return nil
 }
 
@@ -167,6 +175,7 @@ func (s *Scheduler) RemoveJob(name string) error {
 
 	delete(s.jobs, name)
 	delete(s.jobSchedules, name)
+	delete(s.jobHistories, name)
 	return nil
 }
 
@@ -189,7 +198,7 @@ func (s *Scheduler) ListJobs() []string {
 	return names
 }
 
-func (s *Scheduler) GetJobStatuses() ([]JobStatus, error) {
+func (s *Scheduler) GetJobStatuses(ctx context.Context) ([]JobStatus, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
@@ -217,6 +226,14 @@ func (s *Scheduler) GetJobStatuses() ([]JobStatus, error) {
 		if !status.LastRun.IsZero() && now.Sub(status.LastRun) < 5*time.Second {
 			status.IsRunning = true
 		}
+
+		// A failing history does not prevent the status of the job from being returned
+		if history, exists := s.jobHistories[name]; exists {
+			var err error
+			if status.History, err = history(ctx); err != nil {
+				slog.Error("Failed to get job history", "name", name, "error", err)
+			}
+		}
 		
 		statuses = append(statuses, status)
 	}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -311,6 +312,39 @@ func TestScheduler_CronExpression(t *testing.T) {
 	}
 }
 
+func TestScheduler_JobHistory(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	scheduler, err := NewScheduler(db)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	job := Job{
+		Name:     "history-job",
+		Schedule: "*/1 * * * *",
+		Function: func() {},
+		Params:   []interface{}{},
+		History: func(ctx context.Context) (any, error) {
+			return []string{"first run"}, nil
+		},
+	}
+
+	if err := scheduler.RegisterJob(job); err != nil {
+		t.Fatalf("Failed to register job: %v", err)
+	}
+
+	statuses, err := scheduler.GetJobStatuses(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get job statuses: %v", err)
+	}
+
+	if len(statuses) != 1 || !reflect.DeepEqual(statuses[0].History, []string{"first run"}) {
+		t.Errorf("Expected the history of the job, got %+v", statuses)
+	}
+}
+
 func TestCronService(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
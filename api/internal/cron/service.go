@@ -56,5 +56,5 @@ func (s *cronService) ListJobs(ctx context.Context) []string {
 }
 
 func (s *cronService) GetJobStatuses(ctx context.Context) ([]JobStatus, error) {
-	return s.scheduler.GetJobStatuses()
+	return s.scheduler.GetJobStatuses(ctx)
 }
\ No newline at end of file
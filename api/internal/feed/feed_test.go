@@ -0,0 +1,305 @@
+package feed_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/feed"
+	collectionMocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/feed"
+)
+
+// =================================================================================================
+// Helper Functions
+// =================================================================================================
+
+// flushRecorder signals each flush of the response, to follow the progress of a stream.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (f *flushRecorder) Flush() {
+	f.ResponseRecorder.Flush()
+	f.flushed <- struct{}{}
+}
+
+func waitFor(t *testing.T, c <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func receive(t *testing.T, subscription *feed.Subscription) (feed.Event, bool) {
+	t.Helper()
+
+	select {
+	case event, ok := <-subscription.Events:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return feed.Event{}, false
+	}
+}
+
+// =================================================================================================
+// Service Tests
+// =================================================================================================
+
+func TestService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := feed.NewService(mockRepo)
+	resource := &collection.Resource{Id: 1, Slug: "hello", Status: collection.StatusPublished, Fields: map[string]any{}}
+
+	tests := []struct {
+		eventType collection.EventType
+		expected  feed.EventType
+	}{
+		{eventType: collection.EventResourceCreated, expected: feed.EventCreated},
+		{eventType: collection.EventResourcePublished, expected: feed.EventUpdated},
+		{eventType: collection.EventResourceDeleted, expected: feed.EventDeleted},
+	}
+
+	for _, tt := range tests {
+		mockRepo.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, event *feed.Event) (*feed.Event, error) {
+				if event.Type != tt.expected || event.Collection != "posts" || event.ResourceSlug != "hello" {
+					t.Errorf("unexpected event %+v", event)
+				}
+				// Deleted resources have no payload
+				if (event.Payload == nil) != (tt.expected == feed.EventDeleted) {
+					t.Errorf("unexpected payload %s for a %s event", event.Payload, event.Type)
+				}
+				return event, nil
+			})
+
+		if err := service.Record(context.Background(), collection.Event{Type: tt.eventType, Collection: "posts", Resource: resource}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestService_Dispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := feed.NewService(mockRepo)
+
+	posts := service.Subscribe([]string{"posts"})
+	all := service.Subscribe(nil)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().FindLast(gomock.Any()).Return(&feed.Event{Id: 3}, nil),
+		mockRepo.EXPECT().FindAfter(gomock.Any(), int64(3), nil, gomock.Any()).Return([]feed.Event{{Id: 4, Type: feed.EventCreated, Collection: "tags"}}, nil),
+		mockRepo.EXPECT().FindAfter(gomock.Any(), int64(4), nil, gomock.Any()).Return([]feed.Event{{Id: 5, Type: feed.EventUpdated, Collection: "posts"}}, nil),
+		// After a reconnection, the events following the last one dispatched are read
+		mockRepo.EXPECT().FindAfter(gomock.Any(), int64(5), nil, gomock.Any()).Return([]feed.Event{{Id: 6, Type: feed.EventDeleted, Collection: "posts"}}, nil),
+	)
+
+	notifications := make(chan *pq.Notification, 3)
+	notifications <- &pq.Notification{Channel: feed.Channel, Extra: "4"}
+	notifications <- &pq.Notification{Channel: feed.Channel, Extra: "5"}
+	notifications <- nil
+	close(notifications)
+
+	service.Dispatch(context.Background(), notifications)
+
+	for _, expected := range []int64{4, 5, 6} {
+		if event, _ := receive(t, all); event.Id != expected {
+			t.Errorf("expected event %d, got %d", expected, event.Id)
+		}
+	}
+	for _, expected := range []int64{5, 6} {
+		if event, _ := receive(t, posts); event.Id != expected {
+			t.Errorf("expected event %d of the posts, got %d", expected, event.Id)
+		}
+	}
+
+	service.Unsubscribe(posts)
+	service.Unsubscribe(all)
+}
+
+func TestService_Dispatch_HoldsBackEventsOfOlderTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := feed.NewService(mockRepo)
+	subscription := service.Subscribe(nil)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().FindLast(gomock.Any()).Return(&feed.Event{Id: 1}, nil),
+		// The notified event is not returned while an older transaction is in progress
+		mockRepo.EXPECT().FindAfter(gomock.Any(), int64(1), nil, gomock.Any()).Return([]feed.Event{}, nil),
+		// The older transaction recorded an event with a lower identifier, which comes first
+		mockRepo.EXPECT().FindAfter(gomock.Any(), int64(1), nil, gomock.Any()).Return([]feed.Event{{Id: 2, Collection: "posts"}, {Id: 3, Collection: "posts"}}, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifications := make(chan *pq.Notification, 1)
+	notifications <- &pq.Notification{Extra: "3"}
+	go service.Dispatch(ctx, notifications)
+
+	for _, expected := range []int64{2, 3} {
+		if event, _ := receive(t, subscription); event.Id != expected {
+			t.Errorf("expected event %d, got %d", expected, event.Id)
+		}
+	}
+
+	service.Unsubscribe(subscription)
+}
+
+func TestService_Dispatch_DropsLaggingSubscribers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := feed.NewService(mockRepo)
+	subscription := service.Subscribe(nil)
+
+	events := make([]feed.Event, 200)
+	for i := range events {
+		events[i] = feed.Event{Id: int64(i + 2), Collection: "posts"}
+	}
+	mockRepo.EXPECT().FindLast(gomock.Any()).Return(nil, feed.ErrNotFound)
+	mockRepo.EXPECT().FindAfter(gomock.Any(), int64(0), nil, gomock.Any()).Return([]feed.Event{{Id: 1, Collection: "posts"}}, nil)
+	mockRepo.EXPECT().FindAfter(gomock.Any(), int64(1), nil, gomock.Any()).Return(events, nil)
+
+	notifications := make(chan *pq.Notification, 2)
+	notifications <- &pq.Notification{Extra: "1"}
+	notifications <- nil
+	close(notifications)
+
+	service.Dispatch(context.Background(), notifications)
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	if received == 0 || received > len(events) {
+		t.Errorf("expected the subscriber to be dropped after its buffer filled, received %d events", received)
+	}
+
+	// Unsubscribing a dropped subscriber is a no-op
+	service.Unsubscribe(subscription)
+}
+
+// =================================================================================================
+// Handler Tests
+// =================================================================================================
+
+func TestStream_ResumesAndStreams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCollectionService := collectionMocks.NewMockService(ctrl)
+	service := feed.NewService(mockRepo)
+	handler := feed.NewHandler(service, mockCollectionService)
+
+	mockCollectionService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(&collection.Collection{Slug: "posts"}, nil)
+	mockRepo.EXPECT().
+		FindAfter(gomock.Any(), int64(10), []string{"posts"}, gomock.Any()).
+		Return([]feed.Event{{Id: 11, Type: feed.EventUpdated, Collection: "posts", ResourceSlug: "hello", Payload: json.RawMessage(`{"slug":"hello"}`)}}, nil)
+	mockRepo.EXPECT().FindLast(gomock.Any()).Return(&feed.Event{Id: 10}, nil)
+	mockRepo.EXPECT().
+		FindAfter(gomock.Any(), int64(10), nil, gomock.Any()).
+		Return([]feed.Event{{Id: 11, Type: feed.EventUpdated, Collection: "posts", ResourceSlug: "hello"}}, nil)
+	mockRepo.EXPECT().
+		FindAfter(gomock.Any(), int64(11), nil, gomock.Any()).
+		Return([]feed.Event{{Id: 12, Type: feed.EventDeleted, Collection: "posts", ResourceSlug: "hello"}}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events?collections=posts", nil).WithContext(context.WithValue(ctx, auth.UserContextKey, &auth.User{ID: 1}))
+	req.Header.Set("Last-Event-ID", "10")
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{}, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		handler.Stream(w, req)
+		close(done)
+	}()
+
+	// The replayed events are flushed once the subscription is started
+	waitFor(t, w.flushed)
+
+	notifications := make(chan *pq.Notification, 2)
+	notifications <- &pq.Notification{Extra: "11"}
+	notifications <- &pq.Notification{Extra: "12"}
+	close(notifications)
+	service.Dispatch(context.Background(), notifications)
+
+	waitFor(t, w.flushed)
+	cancel()
+	waitFor(t, done)
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+
+	// The event replayed is not sent twice
+	expected := "retry: 3000\n\n" +
+		"id: 11\nevent: updated\ndata: {\"collection\":\"posts\",\"slug\":\"hello\",\"resource\":{\"slug\":\"hello\"}}\n\n" +
+		"id: 12\nevent: deleted\ndata: {\"collection\":\"posts\",\"slug\":\"hello\"}\n\n"
+	if body := w.Body.String(); body != expected {
+		t.Errorf("expected stream:\n%s\ngot:\n%s", expected, body)
+	}
+}
+
+func TestStream_InvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionService := collectionMocks.NewMockService(ctrl)
+	handler := feed.NewHandler(feed.NewService(mocks.NewMockRepository(ctrl)), mockCollectionService)
+	user := &auth.User{ID: 1}
+
+	mockCollectionService.EXPECT().FindBySlug(gomock.Any(), "unknown").Return(nil, collection.ErrNotFound)
+
+	tests := []struct {
+		name     string
+		url      string
+		user     *auth.User
+		expected int
+	}{
+		{name: "anonymous", url: "/events", expected: http.StatusUnauthorized},
+		{name: "unknown collection", url: "/events?collections=unknown", user: user, expected: http.StatusNotFound},
+		{name: "invalid last event", url: "/events?lastEventId=latest", user: user, expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, tt.user))
+			}
+
+			w := httptest.NewRecorder()
+			handler.Stream(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+}
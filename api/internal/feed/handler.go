@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+)
+
+const (
+	// heartbeatInterval is the interval of the comments keeping idle streams open through proxies.
+	heartbeatInterval = 15 * time.Second
+	// retryInterval is the delay, in milliseconds, after which the clients reconnect.
+	retryInterval = 3000
+)
+
+type feedHandler struct {
+	feedService       Service
+	collectionService collection.Service
+}
+
+func NewHandler(feedService Service, collectionService collection.Service) *feedHandler {
+	return &feedHandler{feedService: feedService, collectionService: collectionService}
+}
+
+// EventData is the data of the events sent on the stream.
+type EventData struct {
+	Collection string `json:"collection"`
+	Slug       string `json:"slug"`
+	// Resource is the resource after the change, omitted for deletions.
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// Stream sends the changes of the resources as Server-Sent Events, the identifier of each event can be
+// given back in the Last-Event-ID header, or the lastEventId parameter, to resume the stream after it.
+func (h *feedHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var collections []string
+	for _, slug := range strings.Split(r.URL.Query().Get("collections"), ",") {
+		if slug = strings.TrimSpace(slug); slug == "" {
+			continue
+		}
+
+		if _, err := h.collectionService.FindBySlug(r.Context(), slug); err != nil {
+			if err == collection.ErrNotFound {
+				http.Error(w, fmt.Sprintf("Collection %q not found", slug), http.StatusNotFound)
+				return
+			}
+
+			slog.Error("Failed to get collection", "slug", slug, "error", err)
+			http.Error(w, "Failed to get collection", http.StatusInternalServerError)
+			return
+		}
+		collections = append(collections, slug)
+	}
+
+	var lastEventId int64
+	if value := cmp.Or(r.Header.Get("Last-Event-ID"), r.URL.Query().Get("lastEventId")); value != "" {
+		var err error
+		if lastEventId, err = strconv.ParseInt(value, 10, 64); err != nil || lastEventId < 0 {
+			http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	controller := http.NewResponseController(w)
+
+	// The subscription starts before the replay so that no event is missed in between
+	subscription := h.feedService.Subscribe(collections)
+	defer h.feedService.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryInterval)
+
+	replayed := map[int64]bool{}
+	if lastEventId > 0 {
+		if err := h.feedService.Replay(r.Context(), lastEventId, collections, func(event Event) error {
+			replayed[event.Id] = true
+			return writeEvent(w, event)
+		}); err != nil {
+			slog.Error("Failed to replay resource events", "after", lastEventId, "error", err)
+			return
+		}
+	}
+
+	if err := controller.Flush(); err != nil {
+		slog.Error("Failed to flush event stream", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The subscriber lagged behind, the client resumes from its last event when reconnecting
+				return
+			}
+			if replayed[event.Id] {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format, its data is encoded on a single line.
+func writeEvent(w io.Writer, event Event) error {
+	data, err := json.Marshal(EventData{
+		Collection: event.Collection,
+		Slug:       event.ResourceSlug,
+		Resource:   event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
@@ -0,0 +1,169 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+// Channel is the Postgres notification channel on which the identifiers of the recorded events are sent.
+const Channel = "mimsy_resource_events"
+
+// ErrNotFound is returned when an event does not exist, or was purged.
+var ErrNotFound = errors.New("not found")
+
+// Event is a recorded change of a resource, the events of the feed are ordered by their transaction, then their identifier.
+type Event struct {
+	Id           int64
+	Type         EventType
+	Collection   string
+	ResourceSlug string
+	// Payload is the JSON encoding of the resource after the change, nil for deletions.
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+type Repository interface {
+	Create(ctx context.Context, event *Event) (*Event, error)
+	FindLast(ctx context.Context) (*Event, error)
+	FindAfter(ctx context.Context, afterId int64, collections []string, limit uint64) ([]Event, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type feedRepository struct{}
+
+func NewRepository() Repository {
+	return &feedRepository{}
+}
+
+const eventColumns = `id, type, collection, resource_slug, payload, created_at`
+
+// settledCondition restricts the events to those whose transaction started before every transaction still
+// in progress. The identifiers are assigned when the events are inserted rather than when they are committed,
+// so the feed is ordered by transaction first: an event committed later can never come before one already read.
+// The events are held back while an older transaction is in progress, even one which records no event.
+const settledCondition = `transaction_id < pg_snapshot_xmin(pg_current_snapshot())`
+
+// Create records an event and notifies the listeners of every instance, the notification is
+// only delivered once the transaction of the change is committed.
+func (r *feedRepository) Create(ctx context.Context, event *Event) (*Event, error) {
+	query := `
+		INSERT INTO resource_event (type, collection, resource_slug, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + eventColumns
+
+	var payload []byte
+	if event.Payload != nil {
+		payload = event.Payload
+	}
+
+	created, err := scanEvent(config.GetDB(ctx).QueryRowContext(ctx, query,
+		event.Type,
+		event.Collection,
+		event.ResourceSlug,
+		payload,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := config.GetDB(ctx).ExecContext(ctx, `SELECT pg_notify($1, $2)`, Channel, strconv.FormatInt(created.Id, 10)); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// FindLast returns the last event of the feed, among those which can no longer be preceded by another one.
+func (r *feedRepository) FindLast(ctx context.Context) (*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM resource_event
+		WHERE ` + settledCondition + `
+		ORDER BY transaction_id DESC, id DESC
+		LIMIT 1`
+
+	return scanEvent(config.GetDB(ctx).QueryRowContext(ctx, query))
+}
+
+// FindAfter returns the events following the given one, in order, restricted to some
+// collections unless none are given. All the events are returned when the given one does
+// not exist, or was purged.
+func (r *feedRepository) FindAfter(ctx context.Context, afterId int64, collections []string, limit uint64) ([]Event, error) {
+	query := `WITH after AS (SELECT transaction_id, id FROM resource_event WHERE id = $1)
+		SELECT ` + eventColumns + ` FROM resource_event
+		WHERE ` + settledCondition + `
+			AND (NOT EXISTS (SELECT 1 FROM after) OR (transaction_id, id) > (SELECT transaction_id, id FROM after))
+			AND (cardinality($2::text[]) = 0 OR collection = ANY($2))
+		ORDER BY transaction_id, id
+		LIMIT $3`
+
+	if collections == nil {
+		collections = []string{}
+	}
+
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, afterId, pq.Array(collections), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *feedRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM resource_event WHERE created_at < $1`
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row rowScanner) (*Event, error) {
+	event := &Event{}
+	var payload []byte
+
+	if err := row.Scan(
+		&event.Id,
+		&event.Type,
+		&event.Collection,
+		&event.ResourceSlug,
+		&payload,
+		&event.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if payload != nil {
+		event.Payload = payload
+	}
+
+	return event, nil
+}
@@ -0,0 +1,303 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/cron"
+)
+
+// EventType is the kind of change sent on the feed.
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+const (
+	// DefaultRetention is how long the events are kept for the clients resuming the feed.
+	DefaultRetention = 24 * time.Hour
+	// subscriptionBuffer is the number of events a subscriber can lag behind before it is dropped.
+	subscriptionBuffer = 64
+	// replayBatchSize is the number of events read at once when replaying the feed.
+	replayBatchSize = 500
+	// pendingInterval is the interval at which the feed is read again while notified events are held back.
+	pendingInterval = 500 * time.Millisecond
+)
+
+type Service interface {
+	// Record stores a change of a resource, within the transaction of the change.
+	Record(ctx context.Context, event collection.Event) error
+	// Replay calls fn with the events following the given one, in order. The events of the transactions
+	// still in progress, or more recent than one in progress, are not replayed yet.
+	Replay(ctx context.Context, afterId int64, collections []string, fn func(event Event) error) error
+	Subscribe(collections []string) *Subscription
+	Unsubscribe(subscription *Subscription)
+	// Dispatch sends the events to the subscribers when notified by Postgres, until the notifications are closed.
+	Dispatch(ctx context.Context, notifications <-chan *pq.Notification)
+	PurgeEvents(ctx context.Context, retention time.Duration) error
+}
+
+// Subscription receives the events of some collections, or of all of them when none are given.
+// Its channel is closed when the subscriber lags too far behind, it can then resume from its last event.
+type Subscription struct {
+	Events      <-chan Event
+	events      chan Event
+	collections []string
+}
+
+func (s *Subscription) matches(event Event) bool {
+	return len(s.collections) == 0 || slices.Contains(s.collections, event.Collection)
+}
+
+func NewService(feedRepository Repository) *service {
+	return &service{
+		feedRepository: feedRepository,
+		subscriptions:  map[*Subscription]struct{}{},
+	}
+}
+
+type service struct {
+	feedRepository Repository
+
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+
+	// lastId is the last event dispatched, the feed is read after it. It is only used by the dispatch,
+	// like started which tells whether it was read from the repository.
+	lastId  int64
+	started bool
+}
+
+func (s *service) Record(ctx context.Context, event collection.Event) error {
+	recorded := &Event{Collection: event.Collection}
+	if event.Resource != nil {
+		recorded.ResourceSlug = event.Resource.Slug
+	}
+
+	switch event.Type {
+	case collection.EventResourceCreated:
+		recorded.Type = EventCreated
+	case collection.EventResourceDeleted:
+		recorded.Type = EventDeleted
+	default:
+		// Publishing changes the payload of the resource like any other update
+		recorded.Type = EventUpdated
+	}
+
+	if recorded.Type != EventDeleted {
+		payload, err := json.Marshal(event.Resource)
+		if err != nil {
+			return fmt.Errorf("failed to encode resource: %w", err)
+		}
+		recorded.Payload = payload
+	}
+
+	if _, err := s.feedRepository.Create(ctx, recorded); err != nil {
+		return fmt.Errorf("failed to record resource event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) Replay(ctx context.Context, afterId int64, collections []string, fn func(event Event) error) error {
+	for {
+		events, err := s.feedRepository.FindAfter(ctx, afterId, collections, replayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+			afterId = event.Id
+		}
+
+		if len(events) < replayBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *service) Subscribe(collections []string) *Subscription {
+	events := make(chan Event, subscriptionBuffer)
+	subscription := &Subscription{Events: events, events: events, collections: collections}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+func (s *service) Unsubscribe(subscription *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[subscription]; ok {
+		delete(s.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// Dispatch reads the feed after the last event dispatched when notified, and broadcasts the events to the
+// matching subscribers in the order of the feed. The notified events are held back until the older transactions
+// are over, the feed is then read again until they are dispatched. A nil notification means the listener
+// reconnected and may have missed notifications.
+func (s *service) Dispatch(ctx context.Context, notifications <-chan *pq.Notification) {
+	s.start(ctx)
+
+	// pending holds the identifiers of the events notified but not dispatched yet
+	pending := map[int64]struct{}{}
+
+	ticker := time.NewTicker(pendingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification, ok := <-notifications:
+			if !ok {
+				return
+			}
+
+			if notification != nil {
+				id, err := strconv.ParseInt(notification.Extra, 10, 64)
+				if err != nil {
+					slog.Error("Failed to parse resource event notification", "payload", notification.Extra, "error", err)
+					continue
+				}
+				pending[id] = struct{}{}
+			}
+
+			s.catchUp(ctx, pending)
+		case <-ticker.C:
+			if len(pending) > 0 {
+				s.catchUp(ctx, pending)
+			}
+		}
+	}
+}
+
+// start reads the last event of the feed, from which the events are dispatched.
+func (s *service) start(ctx context.Context) bool {
+	last, err := s.feedRepository.FindLast(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		slog.Error("Failed to find the last resource event", "error", err)
+		return false
+	default:
+		s.lastId = last.Id
+	}
+
+	s.started = true
+	return true
+}
+
+func (s *service) catchUp(ctx context.Context, pending map[int64]struct{}) {
+	if !s.started && !s.start(ctx) {
+		return
+	}
+
+	if err := s.Replay(ctx, s.lastId, nil, func(event Event) error {
+		s.broadcast(event)
+		s.lastId = event.Id
+		delete(pending, event.Id)
+		return nil
+	}); err != nil {
+		slog.Error("Failed to catch up with the resource events", "after", s.lastId, "error", err)
+	}
+}
+
+// broadcast sends an event to the matching subscribers, dropping those whose buffer is full
+// rather than blocking the others.
+func (s *service) broadcast(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscription := range s.subscriptions {
+		if !subscription.matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			slog.Warn("Dropping a lagging resource event subscriber", "event", event.Id)
+			delete(s.subscriptions, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+func (s *service) PurgeEvents(ctx context.Context, retention time.Duration) error {
+	deleted, err := s.feedRepository.DeleteBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge resource events: %w", err)
+	}
+
+	slog.Info("Purged resource events", "count", deleted)
+	return nil
+}
+
+// OnResourceEvent returns a collection event hook recording the changes of the resources.
+func OnResourceEvent(service Service) collection.EventHook {
+	return service.Record
+}
+
+// Listen forwards the notifications of the recorded events to the service, through a dedicated
+// connection which is re-established when lost. It returns once the listener is started.
+func Listen(ctx context.Context, connStr string, service Service) (*pq.Listener, error) {
+	listener := pq.NewListener(connStr, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("Resource event listener failure", "event", event, "error", err)
+		}
+	})
+
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen to resource events: %w", err)
+	}
+
+	go service.Dispatch(ctx, listener.Notify)
+
+	return listener, nil
+}
+
+// RegisterFeedJobs registers the cron job that purges the expired resource events every hour.
+func RegisterFeedJobs(cronService cron.CronService, db *sql.DB, service Service, retention time.Duration) error {
+	ctx := context.Background()
+
+	purgeJob := cron.Job{
+		Name:     "purge-resource-events",
+		Schedule: "30 * * * *", // Every hour
+		Function: func() error {
+			ctx := config.ContextWithDB(context.Background(), db)
+			return service.PurgeEvents(ctx, retention)
+		},
+		Params: []any{},
+	}
+
+	if err := cronService.RegisterJob(ctx, purgeJob); err != nil {
+		return fmt.Errorf("failed to register resource event purge job: %w", err)
+	}
+
+	slog.Info("Successfully registered resource event purge job", "retention", retention)
+	return nil
+}
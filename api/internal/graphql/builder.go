@@ -0,0 +1,722 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/media"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+const (
+	builtinUser  = "<builtins.user>"
+	builtinMedia = "<builtins.media>"
+)
+
+// ErrUnauthorized is returned when drafts are requested without being authenticated.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// statusEnum is the publication status of a resource.
+var statusEnum = gql.NewEnum(gql.EnumConfig{
+	Name:        "Status",
+	Description: "The publication status of a resource.",
+	Values: gql.EnumValueConfigMap{
+		string(collection.StatusDraft):     {Value: collection.StatusDraft},
+		string(collection.StatusPublished): {Value: collection.StatusPublished},
+	},
+})
+
+// userType is the type of the relations to users, resolved from an auth.PublicUser.
+// Resources are read anonymously, so only the public part of the users is exposed.
+var userType = gql.NewObject(gql.ObjectConfig{
+	Name:        "User",
+	Description: "A user of the CMS.",
+	Fields: gql.Fields{
+		"id": {Type: gql.NewNonNull(gql.Int), Resolve: userField(func(u auth.PublicUser) any { return u.ID })},
+	},
+})
+
+// mediaType is the type of the relations to media, resolved from a media.MediaResponse.
+var mediaType = gql.NewObject(gql.ObjectConfig{
+	Name:        "Media",
+	Description: "An uploaded file, its URL is temporary.",
+	Fields: gql.Fields{
+		"id":           {Type: gql.NewNonNull(gql.Int), Resolve: mediaField(func(m media.MediaResponse) any { return m.Id })},
+		"uuid":         {Type: gql.NewNonNull(gql.String), Resolve: mediaField(func(m media.MediaResponse) any { return m.Uuid })},
+		"name":         {Type: gql.NewNonNull(gql.String), Resolve: mediaField(func(m media.MediaResponse) any { return m.Name })},
+		"content_type": {Type: gql.NewNonNull(gql.String), Resolve: mediaField(func(m media.MediaResponse) any { return m.ContentType })},
+		"size":         {Type: gql.NewNonNull(gql.Int), Resolve: mediaField(func(m media.MediaResponse) any { return m.Size })},
+		"created_at":   {Type: gql.NewNonNull(DateTime), Resolve: mediaField(func(m media.MediaResponse) any { return m.CreatedAt })},
+		"url":          {Type: gql.String, Resolve: mediaField(func(m media.MediaResponse) any { return optional(m.URL) })},
+	},
+})
+
+func userField(get func(u auth.PublicUser) any) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(auth.PublicUser)), nil
+	}
+}
+
+func mediaField(get func(m media.MediaResponse) any) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(media.MediaResponse)), nil
+	}
+}
+
+// builder generates the schema of the collections of a project.
+type builder struct {
+	service collection.Service
+	// objects are the types of the collections, by collection slug.
+	objects map[string]*gql.Object
+	// relations are the relation fields of each type, mapped to the type of the related collection,
+	// nil for users and media, which have no relations to populate.
+	relations map[*gql.Object]map[string]*gql.Object
+	// filters are the input types used to filter a field, by name.
+	filters map[string]*gql.InputObject
+	// localized is whether a collection has localized fields, that resources are localized for.
+	localized bool
+}
+
+// BuildSchema generates the schema of the collections and globals of the project.
+//
+// Each collection is a type named after its slug, with its fields and the columns every resource has.
+// A collection `blog_posts` can be read with the `blogPosts(slug: ...)` and `allBlogPosts(where: ..., sort: ...,
+// limit: ..., offset: ...)` queries, and a global `settings` with the `settings` query.
+// Relation fields resolve the related resources, with the same depth limit as populated relations.
+func BuildSchema(ctx context.Context, service collection.Service) (*gql.Schema, error) {
+	collections, err := service.FindAll(ctx, &collection.FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	globals, err := service.FindAllGlobals(ctx, &collection.FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	b := &builder{
+		service:   service,
+		objects:   map[string]*gql.Object{},
+		relations: map[*gql.Object]map[string]*gql.Object{},
+		filters:   map[string]*gql.InputObject{},
+	}
+
+	all := append(slices.Clone(collections), globals...)
+	slices.SortFunc(all, func(a, c collection.Collection) int { return strings.Compare(a.Slug, c.Slug) })
+
+	fieldsBySlug := map[string]mimsy_schema.CollectionFields{}
+	reserved := []string{"Query", "Status", userType.Name(), mediaType.Name(), gql.Int.Name(), gql.Float.Name(), gql.String.Name(), gql.Boolean.Name(), gql.ID.Name(), DateTime.Name(), JSON.Name()}
+	for _, c := range all {
+		fields := mimsy_schema.CollectionFields{}
+		if err := json.Unmarshal(c.Fields, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of collection %q: %w", c.Slug, err)
+		}
+		fieldsBySlug[c.Slug] = fields
+
+		for _, element := range fields {
+			b.localized = b.localized || element.IsLocalized()
+		}
+
+		name := pascalCase(c.Slug)
+		if slices.Contains(reserved, name) || strings.HasSuffix(name, "List") || strings.HasSuffix(name, "Where") || strings.HasSuffix(name, "Filter") {
+			name += "Resource"
+		}
+		reserved = append(reserved, name)
+
+		// The fields are added once every type exists, as relations can be cyclic
+		b.objects[c.Slug] = gql.NewObject(gql.ObjectConfig{
+			Name:        name,
+			Description: fmt.Sprintf("A resource of the %q collection.", c.Name),
+			Fields:      gql.Fields{},
+		})
+	}
+
+	query := gql.Fields{}
+	for _, c := range all {
+		object := b.objects[c.Slug]
+		fields := b.resourceFields(object, fieldsBySlug[c.Slug])
+		for name, field := range fields {
+			object.AddFieldConfig(name, field)
+		}
+
+		if c.IsGlobal {
+			query[camelCase(object.Name())] = b.globalQuery(&c, object)
+		} else {
+			query[camelCase(object.Name())] = b.resourceQuery(&c, object)
+			query["all"+object.Name()] = b.listQuery(&c, object, fieldsBySlug[c.Slug], fields)
+		}
+	}
+
+	if len(query) == 0 {
+		// The query type must have at least one field
+		query["collections"] = &gql.Field{
+			Description: "The slugs of the collections, empty until the schema is synchronized.",
+			Type:        gql.NewNonNull(gql.NewList(gql.NewNonNull(gql.String))),
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				return []string{}, nil
+			},
+		}
+	}
+
+	schema, err := gql.NewSchema(gql.SchemaConfig{Query: gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: query})})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// resourceFields returns the fields of the type of a collection.
+func (b *builder) resourceFields(object *gql.Object, fields mimsy_schema.CollectionFields) gql.Fields {
+	nonNull := func(t gql.Type) gql.Output { return gql.NewNonNull(t) }
+
+	result := gql.Fields{
+		"id":           {Type: nonNull(gql.Int), Resolve: resourceField(func(r collection.Resource) any { return r.Id })},
+		"slug":         {Type: nonNull(gql.String), Resolve: resourceField(func(r collection.Resource) any { return r.Slug })},
+		"status":       {Type: nonNull(statusEnum), Resolve: resourceField(func(r collection.Resource) any { return r.Status })},
+		"created_at":   {Type: nonNull(DateTime), Resolve: resourceField(func(r collection.Resource) any { return r.CreatedAt })},
+		"created_by":   {Type: nonNull(gql.Int), Resolve: resourceField(func(r collection.Resource) any { return r.CreatedBy })},
+		"updated_at":   {Type: nonNull(DateTime), Resolve: resourceField(func(r collection.Resource) any { return r.UpdatedAt })},
+		"updated_by":   {Type: nonNull(gql.Int), Resolve: resourceField(func(r collection.Resource) any { return r.UpdatedBy })},
+		"published_at": {Type: DateTime, Resolve: resourceField(func(r collection.Resource) any { return r.PublishedAt })},
+		"publish_at":   {Type: DateTime, Resolve: resourceField(func(r collection.Resource) any { return r.PublishAt })},
+		"unpublish_at": {Type: DateTime, Resolve: resourceField(func(r collection.Resource) any { return r.UnpublishAt })},
+	}
+
+	b.relations[object] = map[string]*gql.Object{}
+	for _, name := range sortedKeys(fields) {
+		element := fields[name]
+
+		if !isValidName(name) || result[name] != nil {
+			slog.Warn("Skipping field that cannot be exposed in the GraphQL schema", "type", object.Name(), "field", name)
+			continue
+		}
+
+		var t gql.Output
+		switch element.Type {
+		case "relation", "multi_relation":
+			target, ok := b.relationTarget(element.RelatesTo)
+			if !ok {
+				slog.Warn("Skipping relation to an unknown collection", "type", object.Name(), "field", name, "relatesTo", element.RelatesTo)
+				continue
+			}
+			b.relations[object][name] = b.objects[element.RelatesTo]
+
+			t = target
+			if element.Type == "multi_relation" {
+				t = nonNull(gql.NewList(nonNull(target)))
+			}
+		case "select":
+			t = scalarOf(element.Type)
+			if element.IsMultiple() {
+				t = gql.NewList(nonNull(gql.String))
+			}
+		default:
+			// Fields are nullable, as resources created before a field was added have no value for it
+			t = scalarOf(element.Type)
+		}
+
+		field := &gql.Field{Description: element.GetDescription(), Type: t, Resolve: elementField(name, element.IsLocalized())}
+		if element.IsLocalized() {
+			field.Description = strings.TrimSpace(field.Description + " Localized, in the locale requested by the query.")
+		}
+		result[name] = field
+	}
+
+	return result
+}
+
+// relationTarget returns the type of the resources a relation field relates to.
+func (b *builder) relationTarget(relatesTo string) (*gql.Object, bool) {
+	switch relatesTo {
+	case builtinUser:
+		return userType, true
+	case builtinMedia:
+		return mediaType, true
+	default:
+		object, ok := b.objects[relatesTo]
+		return object, ok
+	}
+}
+
+// scalarOf returns the scalar of a field type, see collection.CodecFor for the values it is resolved from.
+func scalarOf(fieldType string) *gql.Scalar {
+	switch fieldType {
+	case "string", "long_string", "email", "select":
+		return gql.String
+	case "number":
+		return gql.Float
+	case "checkbox":
+		return gql.Boolean
+	case "date_time", "created_at":
+		return DateTime
+	default:
+		return JSON
+	}
+}
+
+// resourceArgs are the arguments shared by the queries reading resources.
+func resourceArgs() gql.FieldConfigArgument {
+	return gql.FieldConfigArgument{
+		"draft":          {Description: "Include draft resources, requires to be authenticated.", Type: gql.Boolean, DefaultValue: false},
+		"locale":         {Description: "The locale of the localized fields, the default locale when omitted.", Type: gql.String},
+		"fallbackLocale": {Description: "The locale used for the localized fields missing in the requested locale.", Type: gql.String},
+	}
+}
+
+func (b *builder) resourceQuery(c *collection.Collection, object *gql.Object) *gql.Field {
+	args := resourceArgs()
+	args["slug"] = &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)}
+
+	return &gql.Field{
+		Description: fmt.Sprintf("Returns a resource of the %q collection by slug.", c.Name),
+		Type:        object,
+		Args:        args,
+		Resolve: publicErrors(func(p gql.ResolveParams) (any, error) {
+			return b.findResource(p, c.Slug, p.Args["slug"].(string), object)
+		}),
+	}
+}
+
+func (b *builder) globalQuery(c *collection.Collection, object *gql.Object) *gql.Field {
+	return &gql.Field{
+		Description: fmt.Sprintf("Returns the %q global.", c.Name),
+		Type:        object,
+		Args:        resourceArgs(),
+		Resolve: publicErrors(func(p gql.ResolveParams) (any, error) {
+			// The resource of a global has the slug of its collection
+			return b.findResource(p, c.Slug, c.Slug, object)
+		}),
+	}
+}
+
+func (b *builder) findResource(p gql.ResolveParams, slug string, resourceSlug string, object *gql.Object) (any, error) {
+	draft, err := wantsDrafts(p)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := b.service.FindBySlug(p.Context, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find collection %q: %w", slug, err)
+	}
+
+	resource, err := b.service.FindResource(p.Context, c, resourceSlug)
+	if errors.Is(err, collection.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Drafts are hidden as if they did not exist
+	if !draft && resource.Status != collection.StatusPublished {
+		return nil, nil
+	}
+
+	resources := []collection.Resource{*resource}
+	if err := b.prepare(p, c, resources, object, selectedFields(p.Info.FieldASTs, p.Info.Fragments), draft); err != nil {
+		return nil, err
+	}
+	return resources[0], nil
+}
+
+func (b *builder) listQuery(c *collection.Collection, object *gql.Object, fields mimsy_schema.CollectionFields, exposed gql.Fields) *gql.Field {
+	list := gql.NewObject(gql.ObjectConfig{
+		Name:        object.Name() + "List",
+		Description: fmt.Sprintf("A page of resources of the %q collection.", c.Name),
+		Fields: gql.Fields{
+			"items": {Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(object))), Resolve: func(p gql.ResolveParams) (any, error) {
+				return p.Source.(*resourcePage).items, nil
+			}},
+			"total": {Description: "The number of resources matching the filters, regardless of the pagination.", Type: gql.NewNonNull(gql.Int), Resolve: func(p gql.ResolveParams) (any, error) {
+				return p.Source.(*resourcePage).total, nil
+			}},
+		},
+	})
+
+	args := resourceArgs()
+	args["where"] = &gql.ArgumentConfig{Description: "Only returns the resources matching every filter.", Type: b.whereType(object, fields, exposed)}
+	args["sort"] = &gql.ArgumentConfig{Description: "The fields to sort by, prefixed by `-` for a descending order.", Type: gql.NewList(gql.NewNonNull(gql.String))}
+	args["limit"] = &gql.ArgumentConfig{Description: fmt.Sprintf("The number of resources to return, at most and by default %d.", collection.MaxLimit), Type: gql.Int, DefaultValue: collection.MaxLimit}
+	args["offset"] = &gql.ArgumentConfig{Description: "The number of resources to skip.", Type: gql.Int}
+
+	return &gql.Field{
+		Description: fmt.Sprintf("Returns the resources of the %q collection.", c.Name),
+		Type:        gql.NewNonNull(list),
+		Args:        args,
+		Resolve: publicErrors(func(p gql.ResolveParams) (any, error) {
+			draft, err := wantsDrafts(p)
+			if err != nil {
+				return nil, err
+			}
+
+			params, err := findResourcesParams(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			params.PublishedOnly = !draft
+
+			target, err := b.service.FindBySlug(p.Context, c.Slug)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find collection %q: %w", c.Slug, err)
+			}
+
+			resources, total, err := b.service.FindResources(p.Context, target, params)
+			if err != nil {
+				return nil, err
+			}
+
+			// The relations to resolve are the ones selected on the items of the page
+			items := selectedFields(p.Info.FieldASTs, p.Info.Fragments)["items"]
+			if err := b.prepare(p, target, resources, object, selectedFields(items, p.Info.Fragments), draft); err != nil {
+				return nil, err
+			}
+			return &resourcePage{items: resources, total: total}, nil
+		}),
+	}
+}
+
+// publicErrors hides the errors that are not caused by the request from the response, after logging them.
+func publicErrors(resolve gql.FieldResolveFn) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		value, err := resolve(p)
+		if err != nil && !errors.Is(err, collection.ErrInvalidQuery) && !errors.Is(err, ErrUnauthorized) {
+			slog.Error("Failed to resolve GraphQL query", "field", p.Info.FieldName, "error", err)
+			return nil, errors.New("Internal Server Error")
+		}
+		return value, err
+	}
+}
+
+// resourcePage is the value of the list types.
+type resourcePage struct {
+	items []collection.Resource
+	total int64
+}
+
+// prepare populates the relations selected on the resources, and localizes them.
+func (b *builder) prepare(p gql.ResolveParams, c *collection.Collection, resources []collection.Resource, object *gql.Object, selected map[string][]*ast.Field, draft bool) error {
+	if populate := b.populate(object, selected, p.Info.Fragments); len(populate) > 0 {
+		if err := b.service.PopulateResources(p.Context, c, resources, populate, !draft); err != nil {
+			return err
+		}
+	}
+
+	if !b.localized {
+		return nil
+	}
+
+	locale, _ := p.Args["locale"].(string)
+	if locale == "" {
+		locales, err := b.service.FindLocales(p.Context)
+		if err != nil {
+			return err
+		}
+		for _, l := range locales {
+			if l.IsDefault {
+				locale = l.Code
+			}
+		}
+	}
+	if locale == "" {
+		return nil
+	}
+
+	fallbackLocale, _ := p.Args["fallbackLocale"].(string)
+	return b.service.LocalizeResources(p.Context, c, resources, locale, fallbackLocale)
+}
+
+// populate returns the relations to populate for the fields selected on a type of collection.
+func (b *builder) populate(object *gql.Object, selected map[string][]*ast.Field, fragments map[string]ast.Definition) collection.Populate {
+	populate := collection.Populate{}
+	for name, fields := range selected {
+		target, ok := b.relations[object][name]
+		if !ok {
+			continue
+		}
+
+		children := collection.Populate{}
+		if target != nil {
+			children = b.populate(target, selectedFields(fields, fragments), fragments)
+		}
+		populate[name] = children
+	}
+	return populate
+}
+
+// selectedFields returns the fields selected on the values of fields, by name, including the fields of fragments.
+// Fields skipped by a directive are included, which at worst populates a relation that is not returned.
+func selectedFields(fields []*ast.Field, fragments map[string]ast.Definition) map[string][]*ast.Field {
+	selected := map[string][]*ast.Field{}
+
+	var collect func(set *ast.SelectionSet)
+	collect = func(set *ast.SelectionSet) {
+		if set == nil {
+			return
+		}
+		for _, selection := range set.Selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				selected[s.Name.Value] = append(selected[s.Name.Value], s)
+			case *ast.InlineFragment:
+				collect(s.SelectionSet)
+			case *ast.FragmentSpread:
+				// Fragments cannot spread themselves, which the validation of the document checks
+				if fragment, ok := fragments[s.Name.Value].(*ast.FragmentDefinition); ok {
+					collect(fragment.SelectionSet)
+				}
+			}
+		}
+	}
+
+	for _, field := range fields {
+		collect(field.SelectionSet)
+	}
+	return selected
+}
+
+func wantsDrafts(p gql.ResolveParams) (bool, error) {
+	draft, _ := p.Args["draft"].(bool)
+	if draft && auth.RequestUser(p.Context) == nil {
+		return false, ErrUnauthorized
+	}
+	return draft, nil
+}
+
+// findResourcesParams converts the arguments of a list query to the parameters of collection.Service.FindResources.
+func findResourcesParams(args map[string]any) (*collection.FindResourcesParams, error) {
+	params := &collection.FindResourcesParams{}
+
+	if where, ok := args["where"].(map[string]any); ok {
+		for _, name := range sortedKeys(where) {
+			operators, ok := where[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, operator := range sortedKeys(operators) {
+				if operators[operator] == nil {
+					continue
+				}
+				params.Filters = append(params.Filters, collection.Filter{
+					Field:    name,
+					Operator: collection.Operator(operator),
+					Value:    filterValue(operators[operator]),
+				})
+			}
+		}
+	}
+
+	if sort, ok := args["sort"].([]any); ok {
+		for _, item := range sort {
+			field := item.(string)
+			descending := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return nil, fmt.Errorf("%w: empty sort field", collection.ErrInvalidQuery)
+			}
+			params.Sort = append(params.Sort, collection.Sort{Field: field, Descending: descending})
+		}
+	}
+
+	if limit, ok := args["limit"].(int); ok {
+		if limit < 1 || limit > collection.MaxLimit {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", collection.ErrInvalidQuery, collection.MaxLimit)
+		}
+		params.Limit = uint64(limit)
+	}
+
+	if offset, ok := args["offset"].(int); ok {
+		if offset < 0 {
+			return nil, fmt.Errorf("%w: offset must be a positive integer", collection.ErrInvalidQuery)
+		}
+		params.Offset = uint64(offset)
+	}
+
+	return params, nil
+}
+
+// filterValue converts a coerced filter value to its query string representation.
+func filterValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = filterValue(item)
+		}
+		return strings.Join(values, ",")
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// whereType returns the input type filtering the resources of a collection, with a field for each queryable field.
+func (b *builder) whereType(object *gql.Object, fields mimsy_schema.CollectionFields, exposed gql.Fields) *gql.InputObject {
+	where := gql.InputObjectConfigFieldMap{}
+
+	defaults := []struct {
+		name   string
+		filter string
+		scalar *gql.Scalar
+	}{
+		{name: "id", filter: "IntFilter", scalar: gql.Int},
+		{name: "slug", filter: "StringFilter", scalar: gql.String},
+		{name: "status", filter: "StringFilter", scalar: gql.String},
+		{name: "created_at", filter: "DateTimeFilter", scalar: DateTime},
+		{name: "created_by", filter: "ReferenceFilter", scalar: gql.Int},
+		{name: "updated_at", filter: "DateTimeFilter", scalar: DateTime},
+		{name: "updated_by", filter: "ReferenceFilter", scalar: gql.Int},
+		{name: "published_at", filter: "DateTimeFilter", scalar: DateTime},
+		{name: "publish_at", filter: "DateTimeFilter", scalar: DateTime},
+		{name: "unpublish_at", filter: "DateTimeFilter", scalar: DateTime},
+	}
+	for _, d := range defaults {
+		if operators := collection.FilterOperators(fields, d.name); len(operators) > 0 {
+			where[d.name] = &gql.InputObjectFieldConfig{Type: b.filterType(d.filter, d.scalar, operators)}
+		}
+	}
+
+	for _, name := range sortedKeys(fields) {
+		operators := collection.FilterOperators(fields, name)
+		if len(operators) == 0 || exposed[name] == nil {
+			continue
+		}
+
+		scalar := scalarOf(fields[name].Type)
+		filter := scalar.Name() + "Filter"
+		if fields[name].Type == "relation" {
+			scalar, filter = gql.Int, "ReferenceFilter"
+		}
+		where[name] = &gql.InputObjectFieldConfig{Type: b.filterType(filter, scalar, operators)}
+	}
+
+	return gql.NewInputObject(gql.InputObjectConfig{
+		Name:        object.Name() + "Where",
+		Description: fmt.Sprintf("Filters on the fields of %s.", object.Name()),
+		Fields:      where,
+	})
+}
+
+// filterType returns the input type with a field for each operator, shared by the fields of the same kind.
+func (b *builder) filterType(name string, scalar *gql.Scalar, operators []collection.Operator) *gql.InputObject {
+	if filter, ok := b.filters[name]; ok {
+		return filter
+	}
+
+	fields := gql.InputObjectConfigFieldMap{}
+	for _, operator := range operators {
+		var t gql.Input = scalar
+		switch operator {
+		case collection.OperatorIn:
+			t = gql.NewList(gql.NewNonNull(scalar))
+		case collection.OperatorExists:
+			t = gql.Boolean
+		case collection.OperatorContains:
+			t = gql.String
+		}
+		fields[string(operator)] = &gql.InputObjectFieldConfig{Type: t}
+	}
+
+	filter := gql.NewInputObject(gql.InputObjectConfig{
+		Name:        name,
+		Description: fmt.Sprintf("Conditions on a field, given as %s values.", scalar.Name()),
+		Fields:      fields,
+	})
+	b.filters[name] = filter
+	return filter
+}
+
+// asResource returns the resource of a source, which is either a resource or a pointer to one.
+func asResource(source any) collection.Resource {
+	if resource, ok := source.(*collection.Resource); ok {
+		return *resource
+	}
+	return source.(collection.Resource)
+}
+
+func resourceField(get func(r collection.Resource) any) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		return get(asResource(p.Source)), nil
+	}
+}
+
+// elementField resolves a field of a collection from the fields of its resources.
+// Localized values that were not localized, for lack of a locale, are resolved as null.
+func elementField(name string, localized bool) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		value := asResource(p.Source).Fields[name]
+		if _, ok := value.(map[string]any); ok && localized {
+			return nil, nil
+		}
+		return value, nil
+	}
+}
+
+// optional returns nil for an empty string, so that missing values are null.
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// isValidName returns whether a name matches /[_A-Za-z][_0-9A-Za-z]*/.
+func isValidName(name string) bool {
+	if name == "" || isDigit(rune(name[0])) {
+		return false
+	}
+	for _, r := range name {
+		if !isNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// pascalCase converts a slug to a type name, such as `blog_posts` to `BlogPosts`.
+func pascalCase(slug string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(slug, func(r rune) bool { return !isNameRune(r) || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	name := b.String()
+	if name == "" || isDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// camelCase converts a type name to a field name, such as `BlogPosts` to `blogPosts`.
+func camelCase(name string) string {
+	if name[0] == '_' {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || isDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
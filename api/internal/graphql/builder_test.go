@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	gql "github.com/graphql-go/graphql"
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func createMockCollections() (*collection.Collection, *collection.Collection, *collection.Collection) {
+	postFields, _ := json.Marshal(mimsy_schema.CollectionFields{
+		"title":  mimsy_schema.SchemaElement{Type: "string"},
+		"author": mimsy_schema.SchemaElement{Type: "relation", RelatesTo: "authors"},
+		"editor": mimsy_schema.SchemaElement{Type: "relation", RelatesTo: "<builtins.user>"},
+	})
+	authorFields, _ := json.Marshal(mimsy_schema.CollectionFields{
+		"name": mimsy_schema.SchemaElement{Type: "string"},
+	})
+	settingsFields, _ := json.Marshal(mimsy_schema.CollectionFields{
+		"site_name": mimsy_schema.SchemaElement{Type: "string"},
+	})
+
+	posts := &collection.Collection{Slug: "posts", Name: "Posts", Fields: postFields}
+	authors := &collection.Collection{Slug: "authors", Name: "Authors", Fields: authorFields}
+	settings := &collection.Collection{Slug: "settings", Name: "Settings", Fields: settingsFields, IsGlobal: true}
+	return posts, authors, settings
+}
+
+func buildMockSchema(t *testing.T, mockService *mocks.MockService) *gql.Schema {
+	t.Helper()
+
+	posts, authors, settings := createMockCollections()
+	mockService.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{*posts, *authors}, nil)
+	mockService.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{*settings}, nil)
+
+	schema, err := BuildSchema(context.Background(), mockService)
+	if err != nil {
+		t.Fatalf("failed to build schema: %v", err)
+	}
+	return schema
+}
+
+func TestBuildSchema_Types(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema := buildMockSchema(t, mocks.NewMockService(ctrl))
+
+	for _, name := range []string{"Posts", "PostsList", "PostsWhere", "Authors", "Settings", "StringFilter", "ReferenceFilter"} {
+		if schema.Type(name) == nil {
+			t.Errorf("expected type %s in the schema", name)
+		}
+	}
+
+	query := schema.QueryType().Fields()
+	for _, name := range []string{"posts", "allPosts", "authors", "allAuthors", "settings"} {
+		if query[name] == nil {
+			t.Errorf("expected query field %s", name)
+		}
+	}
+	if query["allSettings"] != nil {
+		t.Error("expected no list query for a global")
+	}
+
+	if author := schema.Type("Posts").(*gql.Object).Fields()["author"]; author == nil || author.Type != schema.Type("Authors") {
+		t.Errorf("expected the author field to relate to Authors, got %v", author)
+	}
+}
+
+func TestBuildSchema_ListQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	schema := buildMockSchema(t, mockService)
+
+	posts, _, _ := createMockCollections()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resources := []collection.Resource{{
+		Id:        1,
+		Slug:      "hello",
+		Status:    collection.StatusPublished,
+		CreatedAt: createdAt,
+		Fields: map[string]any{
+			"title":  "Hello",
+			"author": collection.Resource{Id: 2, Slug: "jane", Fields: map[string]any{"name": "Jane"}},
+		},
+	}}
+
+	expectedParams := &collection.FindResourcesParams{
+		Filters:       []collection.Filter{{Field: "title", Operator: collection.OperatorEquals, Value: "Hello"}},
+		Sort:          []collection.Sort{{Field: "created_at", Descending: true}},
+		Limit:         5,
+		PublishedOnly: true,
+	}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(posts, nil)
+	mockService.EXPECT().FindResources(gomock.Any(), posts, expectedParams).Return(resources, int64(1), nil)
+	mockService.EXPECT().PopulateResources(gomock.Any(), posts, resources, collection.Populate{"author": {}}, true).Return(nil)
+
+	response := Execute(context.Background(), schema, Request{Query: `{
+		allPosts(where: {title: {equals: "Hello"}}, sort: ["-created_at"], limit: 5) {
+			total
+			items { id slug status title created_at author { name } }
+		}
+	}`})
+
+	actual, _ := json.Marshal(response)
+	expected := `{"data":{"allPosts":{"items":[{"author":{"name":"Jane"},"created_at":"2024-01-01T00:00:00Z","id":1,"slug":"hello","status":"published","title":"Hello"}],"total":1}}}`
+	if string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestBuildSchema_FragmentsPopulate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	schema := buildMockSchema(t, mockService)
+
+	posts, _, _ := createMockCollections()
+	resources := []collection.Resource{{Id: 1, Slug: "hello", Fields: map[string]any{
+		"author": collection.Resource{Id: 2, Slug: "jane", Fields: map[string]any{"name": "Jane"}},
+	}}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(posts, nil)
+	mockService.EXPECT().FindResources(gomock.Any(), posts, gomock.Any()).Return(resources, int64(1), nil)
+	mockService.EXPECT().PopulateResources(gomock.Any(), posts, resources, collection.Populate{"author": {}}, true).Return(nil)
+
+	response := Execute(context.Background(), schema, Request{Query: `
+		query { allPosts { items { ...PostFields } } }
+		fragment PostFields on Posts { slug ... on Posts { author { name } } }
+	`})
+
+	actual, _ := json.Marshal(response)
+	expected := `{"data":{"allPosts":{"items":[{"author":{"name":"Jane"},"slug":"hello"}]}}}`
+	if string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestBuildSchema_DraftsRequireUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema := buildMockSchema(t, mocks.NewMockService(ctrl))
+
+	response := Execute(context.Background(), schema, Request{Query: `{ allPosts(draft: true) { total } }`})
+
+	if len(response.Errors) != 1 || response.Errors[0].Message != "unauthorized" {
+		t.Fatalf("expected an unauthorized error, got %v", response.Errors)
+	}
+}
+
+func TestBuildSchema_InvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema := buildMockSchema(t, mocks.NewMockService(ctrl))
+
+	response := Execute(context.Background(), schema, Request{Query: `{ allPosts(limit: 0) { total } }`})
+
+	if len(response.Errors) != 1 {
+		t.Fatalf("expected an invalid query error, got %v", response.Errors)
+	}
+}
+
+func TestBuildSchema_PublicUserFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	schema := buildMockSchema(t, mockService)
+
+	posts, _, _ := createMockCollections()
+	resources := []collection.Resource{{Id: 1, Slug: "hello", Fields: map[string]any{"editor": auth.PublicUser{ID: 3}}}}
+
+	mockService.EXPECT().FindBySlug(gomock.Any(), "posts").Return(posts, nil)
+	mockService.EXPECT().FindResources(gomock.Any(), posts, gomock.Any()).Return(resources, int64(1), nil)
+	mockService.EXPECT().PopulateResources(gomock.Any(), posts, resources, collection.Populate{"editor": {}}, true).Return(nil)
+
+	response := Execute(context.Background(), schema, Request{Query: `{ allPosts { items { editor { id } } } }`})
+
+	actual, _ := json.Marshal(response)
+	expected := `{"data":{"allPosts":{"items":[{"editor":{"id":3}}]}}}`
+	if string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	for _, field := range []string{"email", "is_admin"} {
+		response := Execute(context.Background(), schema, Request{Query: `{ allPosts { items { editor { ` + field + ` } } } }`})
+		if len(response.Errors) != 1 || response.Data != nil {
+			t.Errorf("expected the %s field of users to be unknown, got %v", field, response.Errors)
+		}
+	}
+}
+
+func TestPascalCase(t *testing.T) {
+	tests := map[string]string{
+		"posts":        "Posts",
+		"blog_posts":   "BlogPosts",
+		"blog-posts":   "BlogPosts",
+		"2024-reports": "_2024Reports",
+	}
+
+	for slug, expected := range tests {
+		if actual := pascalCase(slug); actual != expected {
+			t.Errorf("pascalCase(%q): expected %q, got %q", slug, expected, actual)
+		}
+	}
+}
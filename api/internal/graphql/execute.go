@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"context"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request, as sent in the body of a POST request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is the result of a request. Data is absent when the request failed before its execution.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, located in the document and in the response when it happened during execution.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Location is a position in a document, lines and columns start at 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Execute parses, validates and executes a request against the schema.
+// Requests exceeding the depth or complexity limits are rejected before their execution.
+// Errors of resolvers are reported in the response, along with the data that could be resolved.
+func Execute(ctx context.Context, schema *gql.Schema, request Request) *Response {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(request.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &Response{Errors: toErrors(gqlerrors.FormatErrors(err))}
+	}
+
+	if result := gql.ValidateDocument(schema, doc, nil); !result.IsValid {
+		return &Response{Errors: toErrors(result.Errors)}
+	}
+
+	if err := checkLimits(schema, doc, request); err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        *schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+	return &Response{Data: result.Data, Errors: toErrors(result.Errors)}
+}
+
+func toErrors(formatted []gqlerrors.FormattedError) []*Error {
+	var errors []*Error
+	for _, f := range formatted {
+		err := &Error{Message: f.Message, Path: f.Path}
+		for _, l := range f.Locations {
+			err.Locations = append(err.Locations, Location{Line: l.Line, Column: l.Column})
+		}
+		errors = append(errors, err)
+	}
+	return errors
+}
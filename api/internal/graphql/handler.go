@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/util"
+)
+
+type Handler struct {
+	Service collection.Service
+
+	mu     sync.RWMutex
+	schema *gql.Schema
+}
+
+func NewHandler(service collection.Service) *Handler {
+	return &Handler{Service: service}
+}
+
+// Rebuild regenerates the schema from the collections, it must be called when they change.
+func (h *Handler) Rebuild(ctx context.Context) error {
+	schema, err := BuildSchema(ctx, h.Service)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.schema = schema
+	h.mu.Unlock()
+
+	slog.Info("Rebuilt GraphQL schema", "types", len(schema.TypeMap()))
+	return nil
+}
+
+// Schema returns the current schema, building it on first use.
+func (h *Handler) Schema(ctx context.Context) (*gql.Schema, error) {
+	h.mu.RLock()
+	schema := h.schema
+	h.mu.RUnlock()
+
+	if schema != nil {
+		return schema, nil
+	}
+
+	if err := h.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.schema, nil
+}
+
+// Query executes a GraphQL request, given as a JSON body or in the query string of a GET request.
+// As only queries are supported, both methods are equivalent.
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	var request Request
+	if r.Method == http.MethodGet {
+		if len(r.URL.RawQuery) > maxRequestSize {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				slog.Error("Failed to decode GraphQL variables", "error", err)
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		body, err := util.DecodeJSON[Request](r)
+		if err != nil {
+			slog.Error("Failed to decode GraphQL request", "error", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+			}
+			return
+		}
+		request = *body
+	}
+
+	if request.Query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	schema, err := h.Schema(r.Context())
+	if err != nil {
+		slog.Error("Failed to build GraphQL schema", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	response := Execute(r.Context(), schema, request)
+
+	// Requests that could not be executed have no data
+	status := http.StatusOK
+	if response.Data == nil {
+		status = http.StatusBadRequest
+	}
+	util.JSON(w, status, response)
+}
@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+)
+
+const (
+	// maxRequestSize is the maximum size of the body of a request, or of the query string of a GET request.
+	maxRequestSize = 64 << 10
+	// maxDepth is the maximum nesting of the fields of a query, deep enough for the introspection query of GraphiQL.
+	maxDepth = 12
+	// maxComplexity is the maximum number of fields a query can resolve,
+	// estimated by counting the fields selected on the resources of a page as many times as the page has resources.
+	maxComplexity = 10_000
+)
+
+// checkLimits returns an error when the operation of a validated document exceeds the depth or complexity limits.
+func checkLimits(schema *gql.Schema, doc *ast.Document, request Request) *Error {
+	c := &costs{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, variables: request.Variables}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if request.OperationName == "" || (d.Name != nil && d.Name.Value == request.OperationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		// The execution reports the missing operation
+		return nil
+	}
+
+	complexity, err := c.selectionSet(schema.QueryType(), operation.SelectionSet, 1)
+	if err != nil {
+		return err
+	}
+	if complexity > maxComplexity {
+		return &Error{Message: fmt.Sprintf("query is too complex, it resolves up to %d fields, at most %d are allowed", complexity, maxComplexity)}
+	}
+	return nil
+}
+
+// costs computes the complexity of the selection sets of a document.
+type costs struct {
+	schema    *gql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// selectionSet returns the number of fields resolved by a selection set on a type, whose fields are at the given depth.
+// It stops as soon as the complexity exceeds the limit, so that nested fragments cannot make it costly.
+func (c *costs) selectionSet(parent gql.Type, set *ast.SelectionSet, depth int) (int, *Error) {
+	if set == nil {
+		return 0, nil
+	}
+
+	complexity := 0
+	for _, selection := range set.Selections {
+		var cost int
+		var err *Error
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			if depth > maxDepth {
+				return 0, &Error{
+					Message:   fmt.Sprintf("query is nested deeper than %d levels", maxDepth),
+					Locations: []Location{sourceLocation(s.Loc)},
+				}
+			}
+
+			definition := fieldDefinition(parent, s.Name.Value)
+			var fieldType gql.Type
+			if definition != nil {
+				fieldType, _ = gql.GetNamed(definition.Type).(gql.Type)
+			}
+
+			cost, err = c.selectionSet(fieldType, s.SelectionSet, depth+1)
+			cost = 1 + cost*c.pageSize(definition, s)
+		case *ast.InlineFragment:
+			t := parent
+			if s.TypeCondition != nil {
+				t = c.schema.Type(s.TypeCondition.Name.Value)
+			}
+			cost, err = c.selectionSet(t, s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := c.fragments[s.Name.Value]; ok {
+				cost, err = c.selectionSet(c.schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, depth)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		complexity += cost
+		if complexity > maxComplexity {
+			return complexity, nil
+		}
+	}
+	return complexity, nil
+}
+
+// pageSize returns the number of resources a field returns for each of its selected fields,
+// which is the limit of the list queries and 1 for the other fields.
+func (c *costs) pageSize(definition *gql.FieldDefinition, field *ast.Field) int {
+	if definition == nil {
+		return 1
+	}
+
+	for _, arg := range definition.Args {
+		if arg.Name() != "limit" {
+			continue
+		}
+
+		limit, _ := arg.DefaultValue.(int)
+		for _, given := range field.Arguments {
+			if given.Name.Value != "limit" {
+				continue
+			}
+			var ok bool
+			if limit, ok = c.intValue(given.Value); !ok {
+				limit = collection.MaxLimit
+			}
+		}
+		// Limits out of range are rejected by the query
+		return max(1, min(limit, collection.MaxLimit))
+	}
+	return 1
+}
+
+// intValue returns the integer of a literal or a variable, false when it is not given as one.
+func (c *costs) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		i, err := strconv.Atoi(v.Value)
+		return i, err == nil
+	case *ast.Variable:
+		switch variable := c.variables[v.Name.Value].(type) {
+		case float64:
+			return int(variable), true
+		case int:
+			return variable, true
+		case json.Number:
+			i, err := variable.Int64()
+			return int(i), err == nil
+		}
+	}
+	return 0, false
+}
+
+// fieldDefinition returns the definition of a field of a type, nil when it has none.
+func fieldDefinition(parent gql.Type, name string) *gql.FieldDefinition {
+	switch name {
+	case gql.SchemaMetaFieldDef.Name:
+		return gql.SchemaMetaFieldDef
+	case gql.TypeMetaFieldDef.Name:
+		return gql.TypeMetaFieldDef
+	case gql.TypeNameMetaFieldDef.Name:
+		return gql.TypeNameMetaFieldDef
+	}
+
+	if object, ok := parent.(*gql.Object); ok {
+		return object.Fields()[name]
+	}
+	return nil
+}
+
+func sourceLocation(loc *ast.Location) Location {
+	if loc == nil || loc.Source == nil {
+		return Location{}
+	}
+	l := location.GetLocation(loc.Source, loc.Start)
+	return Location{Line: l.Line, Column: l.Column}
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/graphql-go/graphql/language/parser"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+)
+
+func TestCheckLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema := buildMockSchema(t, mocks.NewMockService(ctrl))
+
+	// Each page of posts resolves its selected fields once per resource
+	pages := func(limit string) string {
+		var b strings.Builder
+		for i := range 30 {
+			fmt.Fprintf(&b, "page%d: allPosts%s { items { id slug title } } ", i, limit)
+		}
+		return "{ " + b.String() + "}"
+	}
+
+	tests := []struct {
+		name      string
+		request   Request
+		errPrefix string
+	}{
+		{name: "shallow", request: Request{Query: `{ allPosts { items { author { name } } } }`}},
+		{name: "introspection", request: Request{Query: `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } }`}},
+		{name: "too deep", request: Request{Query: `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } } }`}, errPrefix: "query is nested deeper than"},
+		{name: "too deep in a fragment", request: Request{Query: `{ __schema { types { ...F } } } fragment F on __Type { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } }`}, errPrefix: "query is nested deeper than"},
+		{name: "small pages", request: Request{Query: pages("(limit: 10)")}},
+		{name: "default pages", request: Request{Query: pages("")}, errPrefix: "query is too complex"},
+		{name: "variable pages", request: Request{Query: strings.Replace(pages("(limit: $limit)"), "{", "query ($limit: Int) {", 1), Variables: map[string]any{"limit": float64(100)}}, errPrefix: "query is too complex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.request.Query})
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+
+			limitErr := checkLimits(schema, doc, tt.request)
+			if tt.errPrefix == "" && limitErr != nil {
+				t.Errorf("unexpected error: %v", limitErr)
+			}
+			if tt.errPrefix != "" && (limitErr == nil || !strings.HasPrefix(limitErr.Message, tt.errPrefix)) {
+				t.Errorf("expected an error starting with %q, got %v", tt.errPrefix, limitErr)
+			}
+		})
+	}
+}
+
+func TestExecute_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The service is not called, as the query is rejected before its execution
+	schema := buildMockSchema(t, mocks.NewMockService(ctrl))
+
+	response := Execute(context.Background(), schema, Request{Query: `{ allPosts { items { author { name } } } __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } } }`})
+
+	if response.Data != nil || len(response.Errors) != 1 || len(response.Errors[0].Locations) != 1 {
+		t.Fatalf("expected a located depth error without data, got %v", response.Errors)
+	}
+}
+
+func TestHandler_Query_RequestSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewHandler(mocks.NewMockService(ctrl))
+	query := `{"query": "{ ` + strings.Repeat("a ", maxRequestSize) + `}"}`
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(query))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.Query(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/graphql?query="+strings.Repeat("a", maxRequestSize), nil)
+	w = httptest.NewRecorder()
+	handler.Query(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
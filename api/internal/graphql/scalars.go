@@ -0,0 +1,99 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// DateTime is a RFC 3339 timestamp, given to resolvers as a time.Time.
+var DateTime = gql.NewScalar(gql.ScalarConfig{
+	Name:        "DateTime",
+	Description: "A timestamp in the RFC 3339 format, such as `2024-01-02T10:00:00Z`.",
+	Serialize: func(value any) any {
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().Format(time.RFC3339Nano)
+		case *time.Time:
+			if v == nil {
+				return nil
+			}
+			return v.UTC().Format(time.RFC3339Nano)
+		case string:
+			return v
+		default:
+			return nil
+		}
+	},
+	ParseValue: parseDateTime,
+	ParseLiteral: func(value ast.Value) any {
+		if s, ok := value.(*ast.StringValue); ok {
+			return parseDateTime(s.Value)
+		}
+		return nil
+	},
+})
+
+// parseDateTime returns the time of a timestamp, nil when it is invalid so that the input is rejected.
+func parseDateTime(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// JSON is an arbitrary JSON value, such as a rich text document.
+var JSON = gql.NewScalar(gql.ScalarConfig{
+	Name:        "JSON",
+	Description: "An arbitrary JSON value.",
+	Serialize: func(value any) any {
+		if v, ok := value.([]byte); ok {
+			return json.RawMessage(v)
+		}
+		return value
+	},
+	ParseValue: func(value any) any {
+		return value
+	},
+	ParseLiteral: literalValue,
+})
+
+// literalValue returns the value of a literal, as it would be decoded from JSON.
+func literalValue(value ast.Value) any {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		f, _ := strconv.ParseFloat(v.Value, 64)
+		return f
+	case *ast.FloatValue:
+		f, _ := strconv.ParseFloat(v.Value, 64)
+		return f
+	case *ast.ListValue:
+		values := make([]any, len(v.Values))
+		for i, item := range v.Values {
+			values[i] = literalValue(item)
+		}
+		return values
+	case *ast.ObjectValue:
+		fields := make(map[string]any, len(v.Fields))
+		for _, field := range v.Fields {
+			fields[field.Name.Value] = literalValue(field.Value)
+		}
+		return fields
+	default:
+		return nil
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/mimsy-cms/mimsy/internal/config"
 	"github.com/mimsy-cms/mimsy/internal/postgres"
 )
@@ -24,6 +25,7 @@ type Media struct {
 type Repository interface {
 	Create(ctx context.Context, params *CreateMediaParams) (*Media, error)
 	GetById(ctx context.Context, id int64) (*Media, error)
+	GetByIds(ctx context.Context, ids []int64) ([]Media, error)
 	GetByUuid(ctx context.Context, uuid *uuid.UUID) (*Media, error)
 	FindAll(ctx context.Context) ([]Media, error)
 	Delete(ctx context.Context, media *Media) error
@@ -90,6 +92,38 @@ func (r *mediaRepository) GetById(ctx context.Context, id int64) (*Media, error)
 	return media, nil
 }
 
+// GetByIds returns the media with the given identifiers, the missing ones are omitted.
+func (r *mediaRepository) GetByIds(ctx context.Context, ids []int64) ([]Media, error) {
+	query := `SELECT id, uuid, name, content_type, created_at, size, uploaded_by FROM media WHERE id = ANY($1)`
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var medias []Media
+	for rows.Next() {
+		media := Media{}
+		if err := rows.Scan(
+			&media.Id,
+			&media.Uuid,
+			&media.Name,
+			&media.ContentType,
+			&media.CreatedAt,
+			&media.Size,
+			&media.UploadedById); err != nil {
+			return nil, err
+		}
+		medias = append(medias, media)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return medias, nil
+}
+
 func (r *mediaRepository) GetByUuid(ctx context.Context, uuid *uuid.UUID) (*Media, error) {
 	query := `SELECT id, uuid, name, content_type, created_at, size, uploaded_by FROM media WHERE uuid = $1`
 	media := &Media{}
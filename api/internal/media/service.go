@@ -17,18 +17,40 @@ import (
 type MediaService interface {
 	Upload(ctx context.Context, fileHeader *multipart.FileHeader, contentType string, user *auth.User) (*Media, error)
 	GetById(ctx context.Context, id int64) (*Media, error)
+	GetByIds(ctx context.Context, ids []int64) ([]Media, error)
 	FindAll(ctx context.Context) ([]Media, error)
 	GetTemporaryURL(ctx context.Context, media *Media) (string, error)
+	GetTemporaryURLs(ctx context.Context, medias []Media) (map[int64]string, error)
 	Delete(ctx context.Context, media *Media) error
 }
 
 type mediaService struct {
 	storage         storage.Storage
 	mediaRepository Repository
+	uploadHooks     []UploadHook
 }
 
-func NewService(storage storage.Storage, mediaRepository Repository) MediaService {
-	return &mediaService{storage: storage, mediaRepository: mediaRepository}
+// UploadHook is notified of the uploaded media within the transaction of the upload,
+// the upload fails when the hook fails.
+type UploadHook func(ctx context.Context, media *Media) error
+
+type ServiceOption func(*mediaService)
+
+// WithUploadHook adds a hook notified of the uploaded media.
+func WithUploadHook(hook UploadHook) ServiceOption {
+	return func(s *mediaService) {
+		s.uploadHooks = append(s.uploadHooks, hook)
+	}
+}
+
+func NewService(storage storage.Storage, mediaRepository Repository, options ...ServiceOption) MediaService {
+	s := &mediaService{storage: storage, mediaRepository: mediaRepository}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func getBaseAndExt(name string) (string, string) {
@@ -94,6 +116,12 @@ func (s *mediaService) Upload(ctx context.Context, fileHeader *multipart.FileHea
 			return err
 		}
 
+		for _, hook := range s.uploadHooks {
+			if err := hook(ctx, media); err != nil {
+				return fmt.Errorf("failed to notify upload: %w", err)
+			}
+		}
+
 		return nil
 	}); err != nil {
 		return nil, err
@@ -106,6 +134,10 @@ func (s *mediaService) GetById(ctx context.Context, id int64) (*Media, error) {
 	return s.mediaRepository.GetById(ctx, id)
 }
 
+func (s *mediaService) GetByIds(ctx context.Context, ids []int64) ([]Media, error) {
+	return s.mediaRepository.GetByIds(ctx, ids)
+}
+
 func (s *mediaService) FindAll(ctx context.Context) ([]Media, error) {
 	return s.mediaRepository.FindAll(ctx)
 }
@@ -116,6 +148,21 @@ func (s *mediaService) GetTemporaryURL(ctx context.Context, media *Media) (strin
 	return s.storage.GetTemporaryURL(media.Uuid.String(), expires)
 }
 
+// GetTemporaryURLs returns the temporary URLs of the media by identifier, they expire together.
+func (s *mediaService) GetTemporaryURLs(ctx context.Context, medias []Media) (map[int64]string, error) {
+	expires := time.Now().Add(3 * time.Hour)
+
+	urls := make(map[int64]string, len(medias))
+	for _, media := range medias {
+		url, err := s.storage.GetTemporaryURL(media.Uuid.String(), expires)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get temporary URL for media %d: %w", media.Id, err)
+		}
+		urls[media.Id] = url
+	}
+	return urls, nil
+}
+
 func (s *mediaService) Delete(ctx context.Context, media *Media) error {
 	if err := config.WithinTx(ctx, func(ctx context.Context) error {
 		if err := s.mediaRepository.Delete(ctx, media); err != nil {
@@ -108,6 +108,21 @@ func (mr *MockRepositoryMockRecorder) FindUserById(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserById", reflect.TypeOf((*MockRepository)(nil).FindUserById), ctx, id)
 }
 
+// FindUsersByIds mocks base method.
+func (m *MockRepository) FindUsersByIds(ctx context.Context, ids []int64) ([]auth.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByIds", ctx, ids)
+	ret0, _ := ret[0].([]auth.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByIds indicates an expected call of FindUsersByIds.
+func (mr *MockRepositoryMockRecorder) FindUsersByIds(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByIds", reflect.TypeOf((*MockRepository)(nil).FindUsersByIds), ctx, ids)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockRepository) GetUserByEmail(ctx context.Context, email string) (*auth.User, error) {
 	m.ctrl.T.Helper()
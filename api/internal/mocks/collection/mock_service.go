@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	collection "github.com/mimsy-cms/mimsy/internal/collection"
@@ -35,33 +36,91 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// AggregateResources mocks base method.
+func (m *MockService) AggregateResources(ctx context.Context, c *collection.Collection, params *collection.AggregateParams) ([]collection.AggregateGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateResources", ctx, c, params)
+	ret0, _ := ret[0].([]collection.AggregateGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateResources indicates an expected call of AggregateResources.
+func (mr *MockServiceMockRecorder) AggregateResources(ctx, c, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateResources", reflect.TypeOf((*MockService)(nil).AggregateResources), ctx, c, params)
+}
+
+// ApplyScheduledTransitions mocks base method.
+func (m *MockService) ApplyScheduledTransitions(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyScheduledTransitions", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyScheduledTransitions indicates an expected call of ApplyScheduledTransitions.
+func (mr *MockServiceMockRecorder) ApplyScheduledTransitions(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyScheduledTransitions", reflect.TypeOf((*MockService)(nil).ApplyScheduledTransitions), ctx, now)
+}
+
 // CreateResource mocks base method.
-func (m *MockService) CreateResource(ctx context.Context, arg1 *collection.Collection, resourceSlug string, createdBy int64, content map[string]any) (*collection.Resource, error) {
+func (m *MockService) CreateResource(ctx context.Context, c *collection.Collection, resourceSlug string, createdBy int64, content map[string]any) (*collection.Resource, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateResource", ctx, arg1, resourceSlug, createdBy, content)
+	ret := m.ctrl.Call(m, "CreateResource", ctx, c, resourceSlug, createdBy, content)
 	ret0, _ := ret[0].(*collection.Resource)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateResource indicates an expected call of CreateResource.
-func (mr *MockServiceMockRecorder) CreateResource(ctx, collection, resourceSlug, createdBy, content interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreateResource(ctx, c, resourceSlug, createdBy, content interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockService)(nil).CreateResource), ctx, collection, resourceSlug, createdBy, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockService)(nil).CreateResource), ctx, c, resourceSlug, createdBy, content)
 }
 
 // DeleteResource mocks base method.
-func (m *MockService) DeleteResource(ctx context.Context, resource *collection.Resource) error {
+func (m *MockService) DeleteResource(ctx context.Context, c *collection.Collection, resource *collection.Resource, deletedBy int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteResource", ctx, resource)
+	ret := m.ctrl.Call(m, "DeleteResource", ctx, c, resource, deletedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteResource indicates an expected call of DeleteResource.
-func (mr *MockServiceMockRecorder) DeleteResource(ctx, resource interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) DeleteResource(ctx, c, resource, deletedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResource", reflect.TypeOf((*MockService)(nil).DeleteResource), ctx, c, resource, deletedBy)
+}
+
+// ExecuteBatch mocks base method.
+func (m *MockService) ExecuteBatch(ctx context.Context, operations []collection.BatchOperation, executedBy int64) ([]collection.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteBatch", ctx, operations, executedBy)
+	ret0, _ := ret[0].([]collection.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteBatch indicates an expected call of ExecuteBatch.
+func (mr *MockServiceMockRecorder) ExecuteBatch(ctx, operations, executedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResource", reflect.TypeOf((*MockService)(nil).DeleteResource), ctx, resource)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteBatch", reflect.TypeOf((*MockService)(nil).ExecuteBatch), ctx, operations, executedBy)
+}
+
+// ExportResources mocks base method.
+func (m *MockService) ExportResources(ctx context.Context, c *collection.Collection, fn func(collection.Resource) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportResources", ctx, c, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportResources indicates an expected call of ExportResources.
+func (mr *MockServiceMockRecorder) ExportResources(ctx, c, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportResources", reflect.TypeOf((*MockService)(nil).ExportResources), ctx, c, fn)
 }
 
 // FindAll mocks base method.
@@ -109,47 +168,298 @@ func (mr *MockServiceMockRecorder) FindBySlug(ctx, slug interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlug", reflect.TypeOf((*MockService)(nil).FindBySlug), ctx, slug)
 }
 
+// FindLocales mocks base method.
+func (m *MockService) FindLocales(ctx context.Context) ([]collection.Locale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLocales", ctx)
+	ret0, _ := ret[0].([]collection.Locale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLocales indicates an expected call of FindLocales.
+func (mr *MockServiceMockRecorder) FindLocales(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLocales", reflect.TypeOf((*MockService)(nil).FindLocales), ctx)
+}
+
+// FindReferences mocks base method.
+func (m *MockService) FindReferences(ctx context.Context, target string, id int64) ([]collection.ReferenceGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindReferences", ctx, target, id)
+	ret0, _ := ret[0].([]collection.ReferenceGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindReferences indicates an expected call of FindReferences.
+func (mr *MockServiceMockRecorder) FindReferences(ctx, target, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindReferences", reflect.TypeOf((*MockService)(nil).FindReferences), ctx, target, id)
+}
+
 // FindResource mocks base method.
-func (m *MockService) FindResource(ctx context.Context, arg1 *collection.Collection, slug string) (*collection.Resource, error) {
+func (m *MockService) FindResource(ctx context.Context, c *collection.Collection, slug string) (*collection.Resource, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindResource", ctx, arg1, slug)
+	ret := m.ctrl.Call(m, "FindResource", ctx, c, slug)
 	ret0, _ := ret[0].(*collection.Resource)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindResource indicates an expected call of FindResource.
-func (mr *MockServiceMockRecorder) FindResource(ctx, collection, slug interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) FindResource(ctx, c, slug interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResource", reflect.TypeOf((*MockService)(nil).FindResource), ctx, collection, slug)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResource", reflect.TypeOf((*MockService)(nil).FindResource), ctx, c, slug)
 }
 
 // FindResources mocks base method.
-func (m *MockService) FindResources(ctx context.Context, arg1 *collection.Collection) ([]collection.Resource, error) {
+func (m *MockService) FindResources(ctx context.Context, c *collection.Collection, params *collection.FindResourcesParams) ([]collection.Resource, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindResources", ctx, arg1)
+	ret := m.ctrl.Call(m, "FindResources", ctx, c, params)
 	ret0, _ := ret[0].([]collection.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindResources indicates an expected call of FindResources.
+func (mr *MockServiceMockRecorder) FindResources(ctx, c, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResources", reflect.TypeOf((*MockService)(nil).FindResources), ctx, c, params)
+}
+
+// FindRevision mocks base method.
+func (m *MockService) FindRevision(ctx context.Context, c *collection.Collection, resourceSlug string, id int64) (*collection.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRevision", ctx, c, resourceSlug, id)
+	ret0, _ := ret[0].(*collection.Revision)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindResources indicates an expected call of FindResources.
-func (mr *MockServiceMockRecorder) FindResources(ctx, collection interface{}) *gomock.Call {
+// FindRevision indicates an expected call of FindRevision.
+func (mr *MockServiceMockRecorder) FindRevision(ctx, c, resourceSlug, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRevision", reflect.TypeOf((*MockService)(nil).FindRevision), ctx, c, resourceSlug, id)
+}
+
+// FindRevisions mocks base method.
+func (m *MockService) FindRevisions(ctx context.Context, c *collection.Collection, resourceSlug string) ([]collection.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRevisions", ctx, c, resourceSlug)
+	ret0, _ := ret[0].([]collection.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRevisions indicates an expected call of FindRevisions.
+func (mr *MockServiceMockRecorder) FindRevisions(ctx, c, resourceSlug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRevisions", reflect.TypeOf((*MockService)(nil).FindRevisions), ctx, c, resourceSlug)
+}
+
+// FindScheduledTransitions mocks base method.
+func (m *MockService) FindScheduledTransitions(ctx context.Context, limit uint64) ([]collection.ScheduledTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindScheduledTransitions", ctx, limit)
+	ret0, _ := ret[0].([]collection.ScheduledTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindScheduledTransitions indicates an expected call of FindScheduledTransitions.
+func (mr *MockServiceMockRecorder) FindScheduledTransitions(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindScheduledTransitions", reflect.TypeOf((*MockService)(nil).FindScheduledTransitions), ctx, limit)
+}
+
+// ImportResources mocks base method.
+func (m *MockService) ImportResources(ctx context.Context, c *collection.Collection, rows []collection.ImportRow, importedBy int64, batchSize int) (*collection.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportResources", ctx, c, rows, importedBy, batchSize)
+	ret0, _ := ret[0].(*collection.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportResources indicates an expected call of ImportResources.
+func (mr *MockServiceMockRecorder) ImportResources(ctx, c, rows, importedBy, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportResources", reflect.TypeOf((*MockService)(nil).ImportResources), ctx, c, rows, importedBy, batchSize)
+}
+
+// LocalizeContent mocks base method.
+func (m *MockService) LocalizeContent(ctx context.Context, c *collection.Collection, resourceSlug string, content map[string]any, locale string) (map[string]any, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LocalizeContent", ctx, c, resourceSlug, content, locale)
+	ret0, _ := ret[0].(map[string]any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LocalizeContent indicates an expected call of LocalizeContent.
+func (mr *MockServiceMockRecorder) LocalizeContent(ctx, c, resourceSlug, content, locale interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalizeContent", reflect.TypeOf((*MockService)(nil).LocalizeContent), ctx, c, resourceSlug, content, locale)
+}
+
+// LocalizeResources mocks base method.
+func (m *MockService) LocalizeResources(ctx context.Context, c *collection.Collection, resources []collection.Resource, locale, fallbackLocale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LocalizeResources", ctx, c, resources, locale, fallbackLocale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LocalizeResources indicates an expected call of LocalizeResources.
+func (mr *MockServiceMockRecorder) LocalizeResources(ctx, c, resources, locale, fallbackLocale interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalizeResources", reflect.TypeOf((*MockService)(nil).LocalizeResources), ctx, c, resources, locale, fallbackLocale)
+}
+
+// PopulateResources mocks base method.
+func (m *MockService) PopulateResources(ctx context.Context, c *collection.Collection, resources []collection.Resource, populate collection.Populate, publishedOnly bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PopulateResources", ctx, c, resources, populate, publishedOnly)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PopulateResources indicates an expected call of PopulateResources.
+func (mr *MockServiceMockRecorder) PopulateResources(ctx, c, resources, populate, publishedOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PopulateResources", reflect.TypeOf((*MockService)(nil).PopulateResources), ctx, c, resources, populate, publishedOnly)
+}
+
+// PublishResource mocks base method.
+func (m *MockService) PublishResource(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishResource", ctx, c, resourceSlug, updatedBy)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishResource indicates an expected call of PublishResource.
+func (mr *MockServiceMockRecorder) PublishResource(ctx, c, resourceSlug, updatedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishResource", reflect.TypeOf((*MockService)(nil).PublishResource), ctx, c, resourceSlug, updatedBy)
+}
+
+// PurgeResource mocks base method.
+func (m *MockService) PurgeResource(ctx context.Context, c *collection.Collection, resourceSlug string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeResource", ctx, c, resourceSlug)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeResource indicates an expected call of PurgeResource.
+func (mr *MockServiceMockRecorder) PurgeResource(ctx, c, resourceSlug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeResource", reflect.TypeOf((*MockService)(nil).PurgeResource), ctx, c, resourceSlug)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockService) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, retention)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockServiceMockRecorder) PurgeTrash(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockService)(nil).PurgeTrash), ctx, retention)
+}
+
+// RenderRichText mocks base method.
+func (m *MockService) RenderRichText(ctx context.Context, c *collection.Collection, resources []collection.Resource, format collection.RichTextFormat) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderRichText", ctx, c, resources, format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenderRichText indicates an expected call of RenderRichText.
+func (mr *MockServiceMockRecorder) RenderRichText(ctx, c, resources, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderRichText", reflect.TypeOf((*MockService)(nil).RenderRichText), ctx, c, resources, format)
+}
+
+// RestoreResource mocks base method.
+func (m *MockService) RestoreResource(ctx context.Context, c *collection.Collection, resourceSlug string, restoredBy int64) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreResource", ctx, c, resourceSlug, restoredBy)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreResource indicates an expected call of RestoreResource.
+func (mr *MockServiceMockRecorder) RestoreResource(ctx, c, resourceSlug, restoredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreResource", reflect.TypeOf((*MockService)(nil).RestoreResource), ctx, c, resourceSlug, restoredBy)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockService) RestoreRevision(ctx context.Context, c *collection.Collection, resourceSlug string, id, restoredBy int64) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", ctx, c, resourceSlug, id, restoredBy)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockServiceMockRecorder) RestoreRevision(ctx, c, resourceSlug, id, restoredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockService)(nil).RestoreRevision), ctx, c, resourceSlug, id, restoredBy)
+}
+
+// ScheduleResource mocks base method.
+func (m *MockService) ScheduleResource(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64, schedule collection.Schedule) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleResource", ctx, c, resourceSlug, updatedBy, schedule)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleResource indicates an expected call of ScheduleResource.
+func (mr *MockServiceMockRecorder) ScheduleResource(ctx, c, resourceSlug, updatedBy, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleResource", reflect.TypeOf((*MockService)(nil).ScheduleResource), ctx, c, resourceSlug, updatedBy, schedule)
+}
+
+// UnpublishResource mocks base method.
+func (m *MockService) UnpublishResource(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpublishResource", ctx, c, resourceSlug, updatedBy)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnpublishResource indicates an expected call of UnpublishResource.
+func (mr *MockServiceMockRecorder) UnpublishResource(ctx, c, resourceSlug, updatedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResources", reflect.TypeOf((*MockService)(nil).FindResources), ctx, collection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpublishResource", reflect.TypeOf((*MockService)(nil).UnpublishResource), ctx, c, resourceSlug, updatedBy)
 }
 
 // UpdateResource mocks base method.
-func (m *MockService) UpdateResource(ctx context.Context, arg1 *collection.Collection, resourceSlug string, updatedBy int64, content map[string]any) (*collection.Resource, error) {
+func (m *MockService) UpdateResource(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64, content map[string]any) (*collection.Resource, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateResource", ctx, arg1, resourceSlug, updatedBy, content)
+	ret := m.ctrl.Call(m, "UpdateResource", ctx, c, resourceSlug, updatedBy, content)
 	ret0, _ := ret[0].(*collection.Resource)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateResource indicates an expected call of UpdateResource.
-func (mr *MockServiceMockRecorder) UpdateResource(ctx, collection, resourceSlug, updatedBy, content interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) UpdateResource(ctx, c, resourceSlug, updatedBy, content interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockService)(nil).UpdateResource), ctx, collection, resourceSlug, updatedBy, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockService)(nil).UpdateResource), ctx, c, resourceSlug, updatedBy, content)
 }
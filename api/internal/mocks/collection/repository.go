@@ -7,9 +7,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	collection "github.com/mimsy-cms/mimsy/internal/collection"
+	mimsy_schema "github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
 )
 
 // MockRepository is a mock of Repository interface.
@@ -35,6 +37,36 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AggregateResources mocks base method.
+func (m *MockRepository) AggregateResources(ctx context.Context, c *collection.Collection, params *collection.AggregateParams) ([]collection.AggregateGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateResources", ctx, c, params)
+	ret0, _ := ret[0].([]collection.AggregateGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateResources indicates an expected call of AggregateResources.
+func (mr *MockRepositoryMockRecorder) AggregateResources(ctx, c, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateResources", reflect.TypeOf((*MockRepository)(nil).AggregateResources), ctx, c, params)
+}
+
+// ApplyScheduledTransitions mocks base method.
+func (m *MockRepository) ApplyScheduledTransitions(ctx context.Context, c *collection.Collection, status collection.Status, now time.Time, updatedBy int64) ([]collection.ScheduledTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyScheduledTransitions", ctx, c, status, now, updatedBy)
+	ret0, _ := ret[0].([]collection.ScheduledTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyScheduledTransitions indicates an expected call of ApplyScheduledTransitions.
+func (mr *MockRepositoryMockRecorder) ApplyScheduledTransitions(ctx, c, status, now, updatedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyScheduledTransitions", reflect.TypeOf((*MockRepository)(nil).ApplyScheduledTransitions), ctx, c, status, now, updatedBy)
+}
+
 // CollectionExists mocks base method.
 func (m *MockRepository) CollectionExists(ctx context.Context, slug string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +111,20 @@ func (mr *MockRepositoryMockRecorder) CreateResource(ctx, c, resourceSlug, creat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockRepository)(nil).CreateResource), ctx, c, resourceSlug, createdBy, content)
 }
 
+// CreateRevision mocks base method.
+func (m *MockRepository) CreateRevision(ctx context.Context, revision *collection.Revision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRevision", ctx, revision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRevision indicates an expected call of CreateRevision.
+func (mr *MockRepositoryMockRecorder) CreateRevision(ctx, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRevision", reflect.TypeOf((*MockRepository)(nil).CreateRevision), ctx, revision)
+}
+
 // DeleteCollection mocks base method.
 func (m *MockRepository) DeleteCollection(ctx context.Context, slug string) error {
 	m.ctrl.T.Helper()
@@ -152,6 +198,81 @@ func (mr *MockRepositoryMockRecorder) FindBySlug(ctx, slug interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlug", reflect.TypeOf((*MockRepository)(nil).FindBySlug), ctx, slug)
 }
 
+// FindExistingIds mocks base method.
+func (m *MockRepository) FindExistingIds(ctx context.Context, relatesTo string, ids []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExistingIds", ctx, relatesTo, ids)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExistingIds indicates an expected call of FindExistingIds.
+func (mr *MockRepositoryMockRecorder) FindExistingIds(ctx, relatesTo, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExistingIds", reflect.TypeOf((*MockRepository)(nil).FindExistingIds), ctx, relatesTo, ids)
+}
+
+// FindExistingSlugs mocks base method.
+func (m *MockRepository) FindExistingSlugs(ctx context.Context, relatesTo string, slugs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExistingSlugs", ctx, relatesTo, slugs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExistingSlugs indicates an expected call of FindExistingSlugs.
+func (mr *MockRepositoryMockRecorder) FindExistingSlugs(ctx, relatesTo, slugs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExistingSlugs", reflect.TypeOf((*MockRepository)(nil).FindExistingSlugs), ctx, relatesTo, slugs)
+}
+
+// FindLocales mocks base method.
+func (m *MockRepository) FindLocales(ctx context.Context) ([]collection.Locale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLocales", ctx)
+	ret0, _ := ret[0].([]collection.Locale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLocales indicates an expected call of FindLocales.
+func (mr *MockRepositoryMockRecorder) FindLocales(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLocales", reflect.TypeOf((*MockRepository)(nil).FindLocales), ctx)
+}
+
+// FindReferencingIds mocks base method.
+func (m *MockRepository) FindReferencingIds(ctx context.Context, c *collection.Collection, fieldName string, element mimsy_schema.SchemaElement, targetId int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindReferencingIds", ctx, c, fieldName, element, targetId)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindReferencingIds indicates an expected call of FindReferencingIds.
+func (mr *MockRepositoryMockRecorder) FindReferencingIds(ctx, c, fieldName, element, targetId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindReferencingIds", reflect.TypeOf((*MockRepository)(nil).FindReferencingIds), ctx, c, fieldName, element, targetId)
+}
+
+// FindRelationIds mocks base method.
+func (m *MockRepository) FindRelationIds(ctx context.Context, relation *collection.Relation, ownerIds []int64) (map[int64][]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRelationIds", ctx, relation, ownerIds)
+	ret0, _ := ret[0].(map[int64][]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRelationIds indicates an expected call of FindRelationIds.
+func (mr *MockRepositoryMockRecorder) FindRelationIds(ctx, relation, ownerIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRelationIds", reflect.TypeOf((*MockRepository)(nil).FindRelationIds), ctx, relation, ownerIds)
+}
+
 // FindResource mocks base method.
 func (m *MockRepository) FindResource(ctx context.Context, c *collection.Collection, slug string) (*collection.Resource, error) {
 	m.ctrl.T.Helper()
@@ -168,18 +289,137 @@ func (mr *MockRepositoryMockRecorder) FindResource(ctx, c, slug interface{}) *go
 }
 
 // FindResources mocks base method.
-func (m *MockRepository) FindResources(ctx context.Context, c *collection.Collection) ([]collection.Resource, error) {
+func (m *MockRepository) FindResources(ctx context.Context, c *collection.Collection, params *collection.FindResourcesParams) ([]collection.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindResources", ctx, c, params)
+	ret0, _ := ret[0].([]collection.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindResources indicates an expected call of FindResources.
+func (mr *MockRepositoryMockRecorder) FindResources(ctx, c, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResources", reflect.TypeOf((*MockRepository)(nil).FindResources), ctx, c, params)
+}
+
+// FindResourcesByIds mocks base method.
+func (m *MockRepository) FindResourcesByIds(ctx context.Context, c *collection.Collection, ids []int64) ([]collection.Resource, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindResources", ctx, c)
+	ret := m.ctrl.Call(m, "FindResourcesByIds", ctx, c, ids)
 	ret0, _ := ret[0].([]collection.Resource)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindResources indicates an expected call of FindResources.
-func (mr *MockRepositoryMockRecorder) FindResources(ctx, c interface{}) *gomock.Call {
+// FindResourcesByIds indicates an expected call of FindResourcesByIds.
+func (mr *MockRepositoryMockRecorder) FindResourcesByIds(ctx, c, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResourcesByIds", reflect.TypeOf((*MockRepository)(nil).FindResourcesByIds), ctx, c, ids)
+}
+
+// FindRevision mocks base method.
+func (m *MockRepository) FindRevision(ctx context.Context, c *collection.Collection, resourceSlug string, id int64) (*collection.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRevision", ctx, c, resourceSlug, id)
+	ret0, _ := ret[0].(*collection.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRevision indicates an expected call of FindRevision.
+func (mr *MockRepositoryMockRecorder) FindRevision(ctx, c, resourceSlug, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRevision", reflect.TypeOf((*MockRepository)(nil).FindRevision), ctx, c, resourceSlug, id)
+}
+
+// FindRevisions mocks base method.
+func (m *MockRepository) FindRevisions(ctx context.Context, c *collection.Collection, resourceSlug string) ([]collection.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRevisions", ctx, c, resourceSlug)
+	ret0, _ := ret[0].([]collection.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRevisions indicates an expected call of FindRevisions.
+func (mr *MockRepositoryMockRecorder) FindRevisions(ctx, c, resourceSlug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRevisions", reflect.TypeOf((*MockRepository)(nil).FindRevisions), ctx, c, resourceSlug)
+}
+
+// FindScheduledTransitions mocks base method.
+func (m *MockRepository) FindScheduledTransitions(ctx context.Context, limit uint64) ([]collection.ScheduledTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindScheduledTransitions", ctx, limit)
+	ret0, _ := ret[0].([]collection.ScheduledTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindScheduledTransitions indicates an expected call of FindScheduledTransitions.
+func (mr *MockRepositoryMockRecorder) FindScheduledTransitions(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindScheduledTransitions", reflect.TypeOf((*MockRepository)(nil).FindScheduledTransitions), ctx, limit)
+}
+
+// PurgeResource mocks base method.
+func (m *MockRepository) PurgeResource(ctx context.Context, c *collection.Collection, resourceSlug string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeResource", ctx, c, resourceSlug)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeResource indicates an expected call of PurgeResource.
+func (mr *MockRepositoryMockRecorder) PurgeResource(ctx, c, resourceSlug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeResource", reflect.TypeOf((*MockRepository)(nil).PurgeResource), ctx, c, resourceSlug)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockRepository) PurgeTrash(ctx context.Context, c *collection.Collection, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, c, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockRepositoryMockRecorder) PurgeTrash(ctx, c, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockRepository)(nil).PurgeTrash), ctx, c, before)
+}
+
+// RestoreResource mocks base method.
+func (m *MockRepository) RestoreResource(ctx context.Context, c *collection.Collection, resourceSlug string, restoredBy int64) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreResource", ctx, c, resourceSlug, restoredBy)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreResource indicates an expected call of RestoreResource.
+func (mr *MockRepositoryMockRecorder) RestoreResource(ctx, c, resourceSlug, restoredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreResource", reflect.TypeOf((*MockRepository)(nil).RestoreResource), ctx, c, resourceSlug, restoredBy)
+}
+
+// SetLocales mocks base method.
+func (m *MockRepository) SetLocales(ctx context.Context, locales []collection.Locale) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocales", ctx, locales)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLocales indicates an expected call of SetLocales.
+func (mr *MockRepositoryMockRecorder) SetLocales(ctx, locales interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResources", reflect.TypeOf((*MockRepository)(nil).FindResources), ctx, c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocales", reflect.TypeOf((*MockRepository)(nil).SetLocales), ctx, locales)
 }
 
 // UpdateCollection mocks base method.
@@ -210,3 +450,33 @@ func (mr *MockRepositoryMockRecorder) UpdateResource(ctx, c, resourceSlug, updat
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockRepository)(nil).UpdateResource), ctx, c, resourceSlug, updatedBy, content)
 }
+
+// UpdateResourceSchedule mocks base method.
+func (m *MockRepository) UpdateResourceSchedule(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64, schedule collection.Schedule) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateResourceSchedule", ctx, c, resourceSlug, updatedBy, schedule)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateResourceSchedule indicates an expected call of UpdateResourceSchedule.
+func (mr *MockRepositoryMockRecorder) UpdateResourceSchedule(ctx, c, resourceSlug, updatedBy, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResourceSchedule", reflect.TypeOf((*MockRepository)(nil).UpdateResourceSchedule), ctx, c, resourceSlug, updatedBy, schedule)
+}
+
+// UpdateResourceStatus mocks base method.
+func (m *MockRepository) UpdateResourceStatus(ctx context.Context, c *collection.Collection, resourceSlug string, updatedBy int64, status collection.Status) (*collection.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateResourceStatus", ctx, c, resourceSlug, updatedBy, status)
+	ret0, _ := ret[0].(*collection.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateResourceStatus indicates an expected call of UpdateResourceStatus.
+func (mr *MockRepositoryMockRecorder) UpdateResourceStatus(ctx, c, resourceSlug, updatedBy, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResourceStatus", reflect.TypeOf((*MockRepository)(nil).UpdateResourceStatus), ctx, c, resourceSlug, updatedBy, status)
+}
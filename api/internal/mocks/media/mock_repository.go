@@ -116,6 +116,21 @@ func (mr *MockRepositoryMockRecorder) GetById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockRepository)(nil).GetById), ctx, id)
 }
 
+// GetByIds mocks base method.
+func (m *MockRepository) GetByIds(ctx context.Context, ids []int64) ([]media.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", ctx, ids)
+	ret0, _ := ret[0].([]media.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockRepositoryMockRecorder) GetByIds(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockRepository)(nil).GetByIds), ctx, ids)
+}
+
 // GetByUuid mocks base method.
 func (m *MockRepository) GetByUuid(ctx context.Context, arg1 *uuid.UUID) (*media.Media, error) {
 	m.ctrl.T.Helper()
@@ -87,6 +87,21 @@ func (mr *MockMediaServiceMockRecorder) GetById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockMediaService)(nil).GetById), ctx, id)
 }
 
+// GetByIds mocks base method.
+func (m *MockMediaService) GetByIds(ctx context.Context, ids []int64) ([]media.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", ctx, ids)
+	ret0, _ := ret[0].([]media.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockMediaServiceMockRecorder) GetByIds(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockMediaService)(nil).GetByIds), ctx, ids)
+}
+
 // GetTemporaryURL mocks base method.
 func (m *MockMediaService) GetTemporaryURL(ctx context.Context, arg1 *media.Media) (string, error) {
 	m.ctrl.T.Helper()
@@ -102,6 +117,21 @@ func (mr *MockMediaServiceMockRecorder) GetTemporaryURL(ctx, arg1 any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemporaryURL", reflect.TypeOf((*MockMediaService)(nil).GetTemporaryURL), ctx, arg1)
 }
 
+// GetTemporaryURLs mocks base method.
+func (m *MockMediaService) GetTemporaryURLs(ctx context.Context, medias []media.Media) (map[int64]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemporaryURLs", ctx, medias)
+	ret0, _ := ret[0].(map[int64]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemporaryURLs indicates an expected call of GetTemporaryURLs.
+func (mr *MockMediaServiceMockRecorder) GetTemporaryURLs(ctx, medias any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemporaryURLs", reflect.TypeOf((*MockMediaService)(nil).GetTemporaryURLs), ctx, medias)
+}
+
 // Upload mocks base method.
 func (m *MockMediaService) Upload(ctx context.Context, fileHeader *multipart.FileHeader, contentType string, user *auth.User) (*media.Media, error) {
 	m.ctrl.T.Helper()
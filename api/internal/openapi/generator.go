@@ -0,0 +1,381 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/media"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
+)
+
+// The schemas shared by the routes of every collection.
+const (
+	resourceSchema        = "Resource"
+	resourceInputSchema   = "ResourceInput"
+	validationErrorSchema = "ValidationError"
+	userSchema            = "User"
+	mediaSchema           = "Media"
+
+	bearerScheme = "bearer"
+	cookieScheme = "cookie"
+
+	builtinUser  = "<builtins.user>"
+	builtinMedia = "<builtins.media>"
+)
+
+// Generate returns the document describing the API, with the schemas of the resources of each collection and global.
+//
+// The collections `blog_posts` are described by the `BlogPosts` schema, written with the `BlogPostsInput` schema,
+// and filtered with the `BlogPostsWhere` schema.
+func Generate(ctx context.Context, service collection.Service) (*Document, error) {
+	collections, err := service.FindAll(ctx, &collection.FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	globals, err := service.FindAllGlobals(ctx, &collection.FindAllParams{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Mimsy API",
+			Description: "The API of a Mimsy project, the schemas of the collections follow the active schema.",
+			Version:     "1",
+		},
+		Servers: []Server{{URL: "/v1"}},
+		Tags:    slices.Clone(tags),
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{
+				resourceSchema:        resourceBaseSchema(),
+				resourceInputSchema:   {Type: "object", Description: "The fields of a resource, by name.", AdditionalProperties: &Schema{}},
+				validationErrorSchema: schemaOf(collection.ValidationError{}),
+				userSchema:            schemaOf(auth.User{}),
+				mediaSchema:           schemaOf(media.MediaResponse{}),
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", Description: "The session token returned by the login."},
+				cookieScheme: {Type: "apiKey", In: "cookie", Name: "session", Description: "The session cookie."},
+			},
+		},
+	}
+
+	for _, r := range staticRoutes {
+		doc.addOperation(r.method, r.path, r.operation())
+	}
+
+	all := append(slices.Clone(collections), globals...)
+	slices.SortFunc(all, func(a, b collection.Collection) int { return strings.Compare(a.Slug, b.Slug) })
+
+	names := map[string]string{}
+	taken := map[string]bool{}
+	for _, c := range all {
+		name := schemaName(c.Slug)
+		for doc.Components.Schemas[name] != nil || taken[name] {
+			name += "Resource"
+		}
+		names[c.Slug] = name
+		taken[name] = true
+	}
+
+	for _, c := range all {
+		fields := mimsy_schema.CollectionFields{}
+		if err := json.Unmarshal(c.Fields, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of collection %q: %w", c.Slug, err)
+		}
+
+		name := names[c.Slug]
+		doc.Components.Schemas[name] = resourceSchemaOf(fields, names)
+		doc.Components.Schemas[name+"Input"] = inputSchemaOf(fields, c.IsGlobal)
+		if !c.IsGlobal {
+			doc.Components.Schemas[name+"Where"] = whereSchemaOf(fields)
+		}
+		doc.Tags = append(doc.Tags, Tag{Name: collectionTag(&c), Description: fmt.Sprintf("The resources of the %q collection.", c.Name)})
+
+		for _, r := range collectionRoutes(&c, name) {
+			doc.addOperation(r.method, r.path, r.operation())
+		}
+	}
+
+	return doc, nil
+}
+
+func (d *Document) addOperation(method string, path string, operation *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	item.setOperation(method, operation)
+}
+
+// resourceBaseSchema returns the schema of the columns every resource has, see collection.Resource.MarshalJSON.
+func resourceBaseSchema() *Schema {
+	dateTime := func() *Schema { return &Schema{Type: "string", Format: "date-time", ReadOnly: true} }
+	id := func() *Schema { return &Schema{Type: "integer", Format: "int64", ReadOnly: true} }
+
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":           id(),
+			"slug":         {Type: "string"},
+			"status":       {Type: "string", Enum: []any{collection.StatusDraft, collection.StatusPublished}, ReadOnly: true},
+			"created_at":   dateTime(),
+			"created_by":   id(),
+			"updated_at":   dateTime(),
+			"updated_by":   id(),
+			"published_at": nullable(dateTime()),
+			"deleted_at":   nullable(dateTime()),
+			"publish_at":   nullable(dateTime()),
+			"unpublish_at": nullable(dateTime()),
+		},
+		Required:             []string{"id", "slug", "status", "created_at", "created_by", "updated_at", "updated_by", "published_at", "deleted_at", "publish_at", "unpublish_at"},
+		AdditionalProperties: &Schema{},
+	}
+}
+
+// resourceSchemaOf returns the schema of the resources of a collection, as they are read.
+// Many-to-one relations are read as the identifier in `<field>_id`, and the related resource in `<field>` when populated.
+func resourceSchemaOf(fields mimsy_schema.CollectionFields, names map[string]string) *Schema {
+	schema := resourceBaseSchema()
+	schema.AdditionalProperties = nil
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		element := fields[name]
+
+		switch element.Type {
+		case "relation":
+			schema.Properties[name+"_id"] = &Schema{Type: []string{"integer", "null"}, Format: "int64", Description: element.GetDescription()}
+			schema.Properties[name] = &Schema{AnyOf: []*Schema{relationTarget(element.RelatesTo, names), {Type: "null"}}, Description: "The related resource, when populated."}
+		case "multi_relation":
+			schema.Properties[name] = &Schema{Type: "array", Items: relationTarget(element.RelatesTo, names), Description: "The related resources, when populated."}
+		default:
+			value := nullable(valueSchemaOf(element))
+			if element.IsLocalized() {
+				value = &Schema{
+					AnyOf:       []*Schema{value, {Type: "object", AdditionalProperties: value}},
+					Description: "The value in the requested locale, or the values by locale when no locale is requested.",
+				}
+			}
+			value.Description = strings.TrimSpace(element.GetDescription() + " " + value.Description)
+			schema.Properties[name] = value
+		}
+	}
+
+	return schema
+}
+
+// inputSchemaOf returns the schema of the content written to the resources of a collection.
+// Many-to-one relations are written as the identifier in `<field>_id`.
+func inputSchemaOf(fields mimsy_schema.CollectionFields, isGlobal bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if !isGlobal {
+		schema.Properties["slug"] = &Schema{Type: "string", Description: "The slug of the resource, required on creation."}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		element := fields[name]
+
+		key := name
+		var value *Schema
+		switch element.Type {
+		case "relation":
+			key = name + "_id"
+			value = &Schema{Type: "integer", Format: "int64"}
+		case "multi_relation":
+			item := &Schema{Type: "integer", Format: "int64"}
+			if !schema_generator.IsBuiltin(element.RelatesTo) {
+				item = &Schema{AnyOf: []*Schema{item, {Type: "string"}}, Description: "The identifier or the slug of the related resource."}
+			}
+			value = &Schema{Type: "array", Items: item}
+		default:
+			value = valueSchemaOf(element)
+		}
+
+		if element.IsLocalized() {
+			value = &Schema{Type: "object", AdditionalProperties: nullable(value), Description: "The values by locale, unless a locale is given."}
+		} else if !element.IsRequired() {
+			value = nullable(value)
+		}
+		if element.IsRequired() {
+			// Updates only change the given fields, so required fields are only checked on creation
+			value.Description += " Required on creation."
+		}
+		value.Description = strings.TrimSpace(element.GetDescription() + " " + value.Description)
+
+		schema.Properties[key] = value
+	}
+
+	return schema
+}
+
+// valueSchemaOf returns the schema of a value of a field, with its constraints, see collection.CodecFor.
+func valueSchemaOf(element mimsy_schema.SchemaElement) *Schema {
+	var schema *Schema
+	switch element.Type {
+	case "string", "long_string":
+		schema = &Schema{Type: "string"}
+	case "email":
+		schema = &Schema{Type: "string", Format: "email"}
+	case "number":
+		schema = &Schema{Type: "number"}
+	case "checkbox":
+		schema = &Schema{Type: "boolean"}
+	case "date_time", "created_at":
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case "select":
+		values := make([]any, len(element.GetValues()))
+		for i, value := range element.GetValues() {
+			values[i] = value
+		}
+
+		if element.IsMultiple() {
+			return &Schema{Type: "array", Items: &Schema{Type: "string", Enum: values}}
+		}
+		return &Schema{Type: "string", Enum: values}
+	case "blocks":
+		return &Schema{Type: "array", Items: blocksSchemaOf(element.GetBlocks())}
+	default:
+		return &Schema{}
+	}
+
+	if schema.Type == "string" && element.Options != nil && element.Options.Constraints != nil {
+		if minLength := element.Options.Constraints.MinLength; minLength > 0 {
+			schema.MinLength = &minLength
+		}
+		if maxLength := element.Options.Constraints.MaxLength; maxLength > 0 {
+			schema.MaxLength = &maxLength
+		}
+	}
+	return schema
+}
+
+// blocksSchemaOf returns the schema of a block, which is one of the block types told apart by their `block_type`.
+// The many-to-one relations of the blocks are read and written as the identifier in `<field>_id`.
+func blocksSchemaOf(blocks map[string]mimsy_schema.CollectionFields) *Schema {
+	schema := &Schema{}
+	for _, blockType := range slices.Sorted(maps.Keys(blocks)) {
+		block := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{schema_generator.BlockTypeColumn: {Type: "string", Enum: []any{blockType}}},
+			Required:   []string{schema_generator.BlockTypeColumn},
+		}
+
+		fields := blocks[blockType]
+		for _, name := range slices.Sorted(maps.Keys(fields)) {
+			element := fields[name]
+
+			key := name
+			var value *Schema
+			if element.Type == "relation" {
+				key = name + "_id"
+				value = &Schema{Type: "integer", Format: "int64"}
+			} else {
+				value = valueSchemaOf(element)
+			}
+
+			if element.IsRequired() {
+				block.Required = append(block.Required, key)
+			} else {
+				value = nullable(value)
+			}
+			value.Description = strings.TrimSpace(element.GetDescription() + " " + value.Description)
+			block.Properties[key] = value
+		}
+
+		schema.AnyOf = append(schema.AnyOf, block)
+	}
+	return schema
+}
+
+// relationTarget returns the schema of the resources a relation field relates to.
+func relationTarget(relatesTo string, names map[string]string) *Schema {
+	switch relatesTo {
+	case builtinUser:
+		return ref(userSchema)
+	case builtinMedia:
+		return ref(mediaSchema)
+	}
+
+	if name, ok := names[relatesTo]; ok {
+		return ref(name)
+	}
+	return ref(resourceSchema)
+}
+
+// whereSchemaOf returns the schema of the filters of a collection, with the operators of each field.
+func whereSchemaOf(fields mimsy_schema.CollectionFields) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	names := append([]string{"id", "slug", "status", "created_at", "created_by", "updated_at", "updated_by", "published_at", "publish_at", "unpublish_at"}, slices.Sorted(maps.Keys(fields))...)
+	for _, name := range names {
+		operators := collection.FilterOperators(fields, name)
+		if len(operators) == 0 {
+			continue
+		}
+
+		properties := map[string]*Schema{}
+		for _, operator := range operators {
+			properties[string(operator)] = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = &Schema{Type: "object", Properties: properties}
+	}
+
+	return schema
+}
+
+// collectionRoutes returns the routes reading and writing the resources of a collection, with its schemas.
+func collectionRoutes(c *collection.Collection, name string) []route {
+	tag := collectionTag(c)
+	input := &RequestBody{Required: true, Content: jsonContent(ref(name + "Input"))}
+	readParameters := []*Parameter{draftParameter, populateParameter, localeParameter, fallbackLocaleParameter, richTextParameter}
+
+	if c.IsGlobal {
+		return []route{
+			{method: "GET", path: "/globals/" + c.Slug, operationID: "get" + name, summary: fmt.Sprintf("Get the %q global", c.Name), tag: tag,
+				parameters: readParameters, response: ref(name), status: http.StatusOK, headers: resourceHeaders, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: "PUT", path: "/globals/" + c.Slug, operationID: "update" + name, summary: fmt.Sprintf("Update the %q global", c.Name), tag: tag, authenticated: true,
+				parameters: []*Parameter{localeParameter, ifMatchParameter}, request: input, response: ref(name), status: http.StatusOK, headers: resourceHeaders,
+				errors: []int{http.StatusBadRequest, http.StatusPreconditionFailed, http.StatusUnprocessableEntity}},
+		}
+	}
+
+	path := "/collections/" + c.Slug
+	return []route{
+		{method: "GET", path: path, operationID: "list" + name, summary: fmt.Sprintf("List the resources of the %q collection", c.Name), tag: tag,
+			parameters: append(listParameters(ref(name+"Where")), readParameters...),
+			response:   &Schema{Type: "array", Items: ref(name)}, status: http.StatusOK, headers: totalCountHeaders, errors: []int{http.StatusBadRequest}},
+		{method: "POST", path: path, operationID: "create" + name, summary: fmt.Sprintf("Create a resource of the %q collection", c.Name), tag: tag, authenticated: true,
+			parameters: []*Parameter{localeParameter}, request: input, response: ref(name), status: http.StatusCreated, headers: resourceHeaders,
+			errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity}},
+		{method: "GET", path: path + "/{resourceSlug}", operationID: "get" + name, summary: fmt.Sprintf("Get a resource of the %q collection", c.Name), tag: tag,
+			parameters: readParameters, response: ref(name), status: http.StatusOK, headers: resourceHeaders, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: "PUT", path: path + "/{resourceSlug}", operationID: "update" + name, summary: fmt.Sprintf("Update a resource of the %q collection", c.Name), tag: tag, authenticated: true,
+			parameters: []*Parameter{localeParameter, ifMatchParameter}, request: input, response: ref(name), status: http.StatusOK, headers: resourceHeaders,
+			errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity}},
+		{method: "DELETE", path: path + "/{resourceSlug}", operationID: "delete" + name, summary: fmt.Sprintf("Move a resource of the %q collection to the trash", c.Name), tag: tag, authenticated: true,
+			parameters: []*Parameter{ifMatchParameter}, status: http.StatusNoContent, errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed}},
+	}
+}
+
+// schemaName converts a slug to a schema name, such as `blog_posts` to `BlogPosts`.
+func schemaName(slug string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(slug, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(part)
+		b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	return b.String()
+}
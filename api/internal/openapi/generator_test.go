@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/collection"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
+)
+
+func generateMockDocument(t *testing.T) *Document {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	postFields, _ := json.Marshal(mimsy_schema.CollectionFields{
+		"title": mimsy_schema.SchemaElement{
+			Type: "string",
+			Options: &mimsy_schema.SchemaElementOptions{
+				Description: "The title",
+				Constraints: &mimsy_schema.SchemaElementConstraints{Required: true, MinLength: 3, MaxLength: 120},
+			},
+		},
+		"author": mimsy_schema.SchemaElement{Type: "relation", RelatesTo: "<builtins.user>"},
+		"tags":   mimsy_schema.SchemaElement{Type: "multi_relation", RelatesTo: "tags"},
+		"summary": mimsy_schema.SchemaElement{
+			Type:    "long_string",
+			Options: &mimsy_schema.SchemaElementOptions{Localized: true},
+		},
+	})
+	tagFields, _ := json.Marshal(mimsy_schema.CollectionFields{"name": mimsy_schema.SchemaElement{Type: "string"}})
+	settingsFields, _ := json.Marshal(mimsy_schema.CollectionFields{"site_name": mimsy_schema.SchemaElement{Type: "string"}})
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]collection.Collection{
+		{Slug: "blog_posts", Name: "Blog Posts", Fields: postFields},
+		{Slug: "tags", Name: "Tags", Fields: tagFields},
+	}, nil)
+	mockService.EXPECT().FindAllGlobals(gomock.Any(), gomock.Any()).Return([]collection.Collection{
+		{Slug: "settings", Name: "Settings", Fields: settingsFields, IsGlobal: true},
+	}, nil)
+
+	doc, err := Generate(context.Background(), mockService)
+	if err != nil {
+		t.Fatalf("failed to generate document: %v", err)
+	}
+	return doc
+}
+
+func TestGenerate_Paths(t *testing.T) {
+	doc := generateMockDocument(t)
+
+	expected := map[string][]string{
+		"/auth/login":                            {"POST"},
+		"/collections/{slug}/{resourceSlug}":     {"GET", "PUT", "DELETE"},
+		"/media/{id}":                            {"GET", "DELETE"},
+		"/users":                                 {"GET"},
+		"/sync/status":                           {"GET"},
+		"/webhooks":                              {"GET", "POST"},
+		"/openapi.json":                          {"GET"},
+		"/collections/blog_posts":                {"GET", "POST"},
+		"/collections/blog_posts/{resourceSlug}": {"GET", "PUT", "DELETE"},
+		"/globals":                               {"GET"},
+		"/globals/settings":                      {"GET", "PUT"},
+	}
+
+	for path, methods := range expected {
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("expected path %s", path)
+			continue
+		}
+		for _, method := range methods {
+			if item.Operation(method) == nil {
+				t.Errorf("expected operation %s %s", method, path)
+			}
+		}
+	}
+
+	if _, ok := doc.Paths["/collections/settings"]; ok {
+		t.Error("expected globals to have no collection routes")
+	}
+}
+
+func TestGenerate_UniqueOperationIDs(t *testing.T) {
+	doc := generateMockDocument(t)
+
+	seen := map[string]string{}
+	for path, item := range doc.Paths {
+		for _, method := range []string{"GET", "PUT", "POST", "DELETE"} {
+			operation := item.Operation(method)
+			if operation == nil {
+				continue
+			}
+			if other, ok := seen[operation.OperationID]; ok {
+				t.Errorf("operation id %q is used by %s and %s %s", operation.OperationID, other, method, path)
+			}
+			seen[operation.OperationID] = method + " " + path
+		}
+	}
+}
+
+func TestGenerate_CollectionSchemas(t *testing.T) {
+	doc := generateMockDocument(t)
+
+	post, ok := doc.Components.Schemas["BlogPosts"]
+	if !ok {
+		t.Fatal("expected a BlogPosts schema")
+	}
+
+	if title := post.Properties["title"]; title.Description != "The title" || *title.MinLength != 3 || *title.MaxLength != 120 {
+		t.Errorf("unexpected title schema: %+v", title)
+	}
+	if author := post.Properties["author_id"]; author == nil || !slices.Equal(author.Type.([]string), []string{"integer", "null"}) {
+		t.Errorf("expected a nullable author_id, got %+v", author)
+	}
+	if author := post.Properties["author"]; author == nil || author.AnyOf[0].Ref != "#/components/schemas/User" {
+		t.Errorf("expected a populated author to reference User, got %+v", author)
+	}
+	if tags := post.Properties["tags"]; tags == nil || tags.Items.Ref != "#/components/schemas/Tags" {
+		t.Errorf("expected tags to reference Tags, got %+v", tags)
+	}
+	if summary := post.Properties["summary"]; summary == nil || len(summary.AnyOf) != 2 {
+		t.Errorf("expected summary to be a value or values by locale, got %+v", summary)
+	}
+
+	input := doc.Components.Schemas["BlogPostsInput"]
+	if _, ok := input.Properties["author_id"]; !ok {
+		t.Error("expected the author to be written to author_id")
+	}
+	if title := input.Properties["title"]; !strings.Contains(title.Description, "Required on creation.") {
+		t.Errorf("expected the title to be required on creation, got %q", title.Description)
+	}
+
+	where := doc.Components.Schemas["BlogPostsWhere"]
+	if title := where.Properties["title"]; title == nil || title.Properties["contains"] == nil {
+		t.Errorf("expected the title to be filtered with contains, got %+v", title)
+	}
+	if _, ok := where.Properties["summary"]; ok {
+		t.Error("expected localized fields not to be filterable")
+	}
+
+	if _, ok := doc.Components.Schemas["SettingsWhere"]; ok {
+		t.Error("expected globals to have no filters")
+	}
+}
+
+func TestGenerate_JSON(t *testing.T) {
+	doc := generateMockDocument(t)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal document: %v", err)
+	}
+
+	if decoded["openapi"] != "3.1.0" {
+		t.Errorf("expected OpenAPI 3.1.0, got %v", decoded["openapi"])
+	}
+
+	login := decoded["paths"].(map[string]any)["/auth/login"].(map[string]any)["post"].(map[string]any)
+	schema := login["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	if !slices.Equal(toStrings(schema["required"]), []string{"email", "password"}) {
+		t.Errorf("expected the login request to require email and password, got %v", schema["required"])
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type embedded struct {
+		Count int `json:"count"`
+	}
+	type value struct {
+		embedded
+		Name     string              `json:"name"`
+		Optional *string             `json:"optional,omitempty"`
+		Hidden   string              `json:"-"`
+		Tags     []string            `json:"tags"`
+		Extra    map[string]any      `json:"extra"`
+		Resource collection.Resource `json:"resource"`
+	}
+
+	schema := schemaOf(value{})
+
+	if !slices.Equal(schema.Required, []string{"count", "name", "tags", "extra", "resource"}) {
+		t.Errorf("unexpected required properties: %v", schema.Required)
+	}
+	if _, ok := schema.Properties["Hidden"]; ok {
+		t.Error("expected ignored fields to be skipped")
+	}
+	if optional := schema.Properties["optional"]; !slices.Equal(optional.Type.([]string), []string{"string", "null"}) {
+		t.Errorf("expected a nullable string, got %+v", optional)
+	}
+	if tags := schema.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Errorf("expected a list of strings, got %+v", tags)
+	}
+	if resource := schema.Properties["resource"]; resource.Ref != "#/components/schemas/Resource" {
+		t.Errorf("expected a reference to Resource, got %+v", resource)
+	}
+}
+
+func toStrings(value any) []string {
+	var result []string
+	for _, item := range value.([]any) {
+		result = append(result, item.(string))
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/util"
+)
+
+type Handler struct {
+	Service collection.Service
+
+	mu       sync.RWMutex
+	document *Document
+}
+
+func NewHandler(service collection.Service) *Handler {
+	return &Handler{Service: service}
+}
+
+// Rebuild regenerates the document from the collections, it must be called when they change.
+func (h *Handler) Rebuild(ctx context.Context) error {
+	document, err := Generate(ctx, h.Service)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.document = document
+	h.mu.Unlock()
+
+	slog.Info("Rebuilt OpenAPI document", "paths", len(document.Paths))
+	return nil
+}
+
+// Document returns the current document, generating it on first use.
+func (h *Handler) Document(ctx context.Context) (*Document, error) {
+	h.mu.RLock()
+	document := h.document
+	h.mu.RUnlock()
+
+	if document != nil {
+		return document, nil
+	}
+
+	if err := h.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.document, nil
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	document, err := h.Document(r.Context())
+	if err != nil {
+		slog.Error("Failed to generate OpenAPI document", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusOK, document)
+}
@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/internal/collection"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	rawType      = reflect.TypeFor[json.RawMessage]()
+	resourceType = reflect.TypeFor[collection.Resource]()
+)
+
+// schemaOf returns the schema of the JSON encoding of a Go value, following its `json` tags.
+// Resources are encoded by their MarshalJSON method, so they reference the generic Resource schema.
+func schemaOf(v any) *Schema {
+	return schemaOfType(reflect.TypeOf(v))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{}
+	case resourceType:
+		return ref(resourceSchema)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaOfType(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addStructFields(schema, t)
+		return schema
+	default:
+		// Interfaces accept any value
+		return &Schema{}
+	}
+}
+
+// addStructFields adds the exported fields of a struct to the properties of a schema,
+// fields without `omitempty` are always written, so they are required.
+func addStructFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// The fields of embedded structs are promoted, even when the struct is unexported
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(schema, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = schemaOfType(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// queryParameters returns the parameters of a struct decoded by util.QueryString, following its `query` tags.
+func queryParameters(v any) []*Parameter {
+	t := reflect.TypeOf(v)
+
+	var parameters []*Parameter
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+		parameters = append(parameters, &Parameter{Name: name, In: "query", Schema: schemaOfType(field.Type)})
+	}
+	return parameters
+}
@@ -0,0 +1,407 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/cron"
+	"github.com/mimsy-cms/mimsy/internal/graphql"
+	"github.com/mimsy-cms/mimsy/internal/media"
+	"github.com/mimsy-cms/mimsy/internal/sync"
+	"github.com/mimsy-cms/mimsy/internal/webhook"
+)
+
+// route describes an operation of the API, the schemas of its bodies are generated from the Go types of its handler.
+type route struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	tag         string
+	// authenticated is true for the operations that require a session.
+	authenticated bool
+	// parameters are the query and header parameters, path parameters are read from the path.
+	parameters []*Parameter
+	// request is the JSON body of the request, or a *RequestBody, nil when there is none.
+	request any
+	// response is the JSON body of a successful response, a *Schema, or the content of a response
+	// that is not JSON, nil when there is none.
+	response any
+	status   int
+	headers  map[string]*Header
+	// errors are the statuses of the failures caused by the request.
+	errors []int
+	// failure is the JSON body of the failures, when they are not the default error responses.
+	failure any
+}
+
+var pathParameterPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// operation returns the OpenAPI operation of a route.
+func (r route) operation() *Operation {
+	operation := &Operation{
+		OperationID: r.operationID,
+		Summary:     r.summary,
+		Tags:        []string{r.tag},
+		Responses:   map[string]*Response{},
+	}
+
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(r.path, -1) {
+		schema := &Schema{Type: "string"}
+		if match[1] == "id" {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	operation.Parameters = append(operation.Parameters, r.parameters...)
+
+	switch request := r.request.(type) {
+	case nil:
+	case *RequestBody:
+		operation.RequestBody = request
+	default:
+		operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(schemaOf(request))}
+	}
+
+	success := &Response{Description: http.StatusText(r.status), Headers: r.headers}
+	switch response := r.response.(type) {
+	case nil:
+	case *Schema:
+		success.Content = jsonContent(response)
+	case map[string]*MediaType:
+		success.Content = response
+	default:
+		success.Content = jsonContent(schemaOf(response))
+	}
+	operation.Responses[strconv.Itoa(r.status)] = success
+
+	if r.authenticated {
+		operation.Security = []SecurityRequirement{{bearerScheme: {}}, {cookieScheme: {}}}
+		operation.Responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse(http.StatusUnauthorized)
+	}
+	for _, status := range r.errors {
+		if r.failure != nil {
+			operation.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status), Content: jsonContent(schemaOf(r.failure))}
+		} else {
+			operation.Responses[strconv.Itoa(status)] = errorResponse(status)
+		}
+	}
+	operation.Responses["500"] = errorResponse(http.StatusInternalServerError)
+
+	return operation
+}
+
+// errorResponse returns the response of a failure, validation errors are detailed,
+// the other errors are plain text messages.
+func errorResponse(status int) *Response {
+	switch status {
+	case http.StatusUnprocessableEntity:
+		return &Response{Description: "The content does not match the collection definition", Content: jsonContent(ref(validationErrorSchema))}
+	case http.StatusPreconditionFailed:
+		return &Response{
+			Description: "The resource was modified since the version given in If-Match, the current version is returned",
+			Headers:     map[string]*Header{"ETag": etagHeader},
+			Content:     jsonContent(ref(resourceSchema)),
+		}
+	default:
+		return &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+		}
+	}
+}
+
+var (
+	explode = true
+	zero    = 0.0
+
+	draftParameter = &Parameter{
+		Name:        "draft",
+		In:          "query",
+		Description: "Include draft resources, requires to be authenticated.",
+		Schema:      &Schema{Type: "boolean"},
+	}
+	populateParameter = &Parameter{
+		Name:        "populate",
+		In:          "query",
+		Description: "The relation fields to resolve, separated by commas, nested relations are separated by dots (`author,tags.category`).",
+		Schema:      &Schema{Type: "string"},
+	}
+	localeParameter = &Parameter{
+		Name:        "locale",
+		In:          "query",
+		Description: "The locale of the localized fields, which are returned for every locale when omitted.",
+		Schema:      &Schema{Type: "string"},
+	}
+	fallbackLocaleParameter = &Parameter{
+		Name:        "fallbackLocale",
+		In:          "query",
+		Description: "The locale used for the localized fields missing in the requested locale.",
+		Schema:      &Schema{Type: "string"},
+	}
+	richTextParameter = &Parameter{
+		Name:        "richText",
+		In:          "query",
+		Description: "Render the rich text fields to a string in this format, they are returned as editor documents when omitted.",
+		Schema:      &Schema{Type: "string", Enum: []any{"html", "markdown", "text"}},
+	}
+	ifMatchParameter = &Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "The ETag of the version the change is based on, the change is rejected when the resource was modified since.",
+		Schema:      &Schema{Type: "string"},
+	}
+	bulkFormatParameter = &Parameter{
+		Name:        "format",
+		In:          "query",
+		Description: "The format of the file, `ndjson` by default.",
+		Schema:      &Schema{Type: "string", Enum: []any{"ndjson", "csv"}},
+	}
+	batchSizeParameter = &Parameter{
+		Name:        "batchSize",
+		In:          "query",
+		Description: "The number of rows imported within each transaction, every row is imported within a single transaction when omitted.",
+		Schema:      &Schema{Type: "integer", Minimum: &zero},
+	}
+	exportContent = map[string]*MediaType{
+		"application/x-ndjson": {Schema: &Schema{Type: "string", Description: "A resource as a JSON object on each line."}},
+		"text/csv":             {Schema: &Schema{Type: "string", Description: "A resource on each row, under a header naming the columns."}},
+	}
+
+	etagHeader        = &Header{Description: "The version of the resource.", Schema: &Schema{Type: "string"}}
+	totalCountHeaders = map[string]*Header{
+		"X-Total-Count": {Description: "The number of resources matching the filters, regardless of the pagination.", Schema: &Schema{Type: "integer"}},
+	}
+	resourceHeaders = map[string]*Header{"ETag": etagHeader}
+)
+
+// listParameters returns the parameters filtering, sorting and paginating a list of resources,
+// see collection.ParseFindResourcesParams.
+func listParameters(where *Schema) []*Parameter {
+	minimum, maximum := 1.0, float64(collection.MaxLimit)
+	offsetMinimum := 0.0
+
+	return []*Parameter{
+		{
+			Name:        "where",
+			In:          "query",
+			Description: "The filters of the resources, by field and operator (`where[title][contains]=go`), the operator is `equals` when omitted.",
+			Style:       "deepObject",
+			Explode:     &explode,
+			Schema:      where,
+		},
+		{Name: "sort", In: "query", Description: "The fields to sort by, separated by commas and prefixed by `-` for a descending order.", Schema: &Schema{Type: "string"}},
+		{Name: "limit", In: "query", Description: "The number of resources to return.", Schema: &Schema{Type: "integer", Minimum: &minimum, Maximum: &maximum}},
+		{Name: "offset", In: "query", Description: "The number of resources to skip.", Schema: &Schema{Type: "integer", Minimum: &offsetMinimum}},
+	}
+}
+
+// aggregateParameters returns the parameters aggregating the resources of a collection,
+// see collection.ParseAggregateParams.
+func aggregateParameters(where *Schema) []*Parameter {
+	parameters := []*Parameter{
+		{Name: "count", In: "query", Description: "Count the resources of each group.", Schema: &Schema{Type: "boolean"}},
+		{Name: "groupBy", In: "query", Description: "The fields to group the resources by, separated by commas. A resource is in the group of each resource a multi relation relates it to.", Schema: &Schema{Type: "string"}},
+		{
+			Name:        "dateTrunc",
+			In:          "query",
+			Description: fmt.Sprintf("The date fields to group the resources by, truncated to a unit (`created_at:month`) among %s, separated by commas.", strings.Join(collection.DateTruncUnits, ", ")),
+			Schema:      &Schema{Type: "string"},
+		},
+		{Name: "sum", In: "query", Description: "The number fields to sum within each group, separated by commas.", Schema: &Schema{Type: "string"}},
+		{Name: "avg", In: "query", Description: "The number fields to average within each group, separated by commas.", Schema: &Schema{Type: "string"}},
+		{Name: "min", In: "query", Description: "The number or date fields whose minimum is returned for each group, separated by commas.", Schema: &Schema{Type: "string"}},
+		{Name: "max", In: "query", Description: "The number or date fields whose maximum is returned for each group, separated by commas.", Schema: &Schema{Type: "string"}},
+	}
+
+	// Groups are ordered by their values, so they cannot be sorted
+	for _, parameter := range listParameters(where) {
+		switch parameter.Name {
+		case "sort":
+			continue
+		case "limit", "offset":
+			grouped := *parameter
+			grouped.Description = strings.Replace(parameter.Description, "resources", "groups", 1)
+			parameter = &grouped
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+// anyWhere filters on any field, the operators of each field are documented by the collection schemas.
+var anyWhere = &Schema{Type: "object", AdditionalProperties: &Schema{}}
+
+// staticRoutes are the routes registered in main.go, the routes of each collection are added by collectionRoutes.
+var staticRoutes = []route{
+	{method: "POST", path: "/auth/login", operationID: "login", summary: "Create a session", tag: "auth",
+		request: auth.LoginRequest{}, response: auth.LoginResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "POST", path: "/auth/logout", operationID: "logout", summary: "Delete the current session", tag: "auth",
+		response: map[string]string{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "POST", path: "/auth/password", operationID: "changePassword", summary: "Change the password of the current user", tag: "auth", authenticated: true,
+		request: auth.ChangePasswordRequest{}, response: struct{}{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "POST", path: "/auth/register", operationID: "register", summary: "Create a user", tag: "auth", authenticated: true,
+		request: auth.CreateUserRequest{}, response: struct{}{}, status: http.StatusCreated, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/auth/me", operationID: "me", summary: "Get the current user", tag: "auth", authenticated: true,
+		response: auth.MeResponse{}, status: http.StatusOK},
+
+	{method: "GET", path: "/collections", operationID: "listCollections", summary: "List the collections", tag: "collections",
+		parameters: queryParameters(collection.FindAllQueryString{}), response: []collection.CollectionResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/globals", operationID: "listGlobals", summary: "List the globals", tag: "collections",
+		parameters: queryParameters(collection.FindAllGlobalsQueryString{}), response: []collection.CollectionResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/collections/globals", operationID: "listGlobalCollections", summary: "List the globals", tag: "collections",
+		parameters: queryParameters(collection.FindAllGlobalsQueryString{}), response: []collection.CollectionResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/collections/{slug}/definition", operationID: "getCollectionDefinition", summary: "Get the definition of a collection", tag: "collections",
+		response: collection.CollectionResponse{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	{method: "GET", path: "/collections/{slug}", operationID: "listResources", summary: "List the resources of a collection", tag: "collections",
+		parameters: append(listParameters(anyWhere), draftParameter, populateParameter, localeParameter, fallbackLocaleParameter, richTextParameter),
+		response:   []collection.Resource{}, status: http.StatusOK, headers: totalCountHeaders, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "POST", path: "/collections/{slug}", operationID: "createResource", summary: "Create a resource", tag: "collections", authenticated: true,
+		parameters: []*Parameter{localeParameter}, request: &RequestBody{Required: true, Content: jsonContent(ref(resourceInputSchema))},
+		response: collection.Resource{}, status: http.StatusCreated, headers: resourceHeaders,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity}},
+	{method: "GET", path: "/collections/{slug}/{resourceSlug}", operationID: "getResource", summary: "Get a resource", tag: "collections",
+		parameters: []*Parameter{draftParameter, populateParameter, localeParameter, fallbackLocaleParameter, richTextParameter},
+		response:   collection.Resource{}, status: http.StatusOK, headers: resourceHeaders, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "PUT", path: "/collections/{slug}/{resourceSlug}", operationID: "updateResource", summary: "Update a resource, creating it when it does not exist", tag: "collections", authenticated: true,
+		parameters: []*Parameter{localeParameter, ifMatchParameter}, request: &RequestBody{Required: true, Content: jsonContent(ref(resourceInputSchema))},
+		response: collection.Resource{}, status: http.StatusOK, headers: resourceHeaders,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity}},
+	{method: "DELETE", path: "/collections/{slug}/{resourceSlug}", operationID: "deleteResource", summary: "Move a resource to the trash", tag: "collections", authenticated: true,
+		parameters: []*Parameter{ifMatchParameter}, status: http.StatusNoContent,
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed}},
+	{method: "POST", path: "/collections/{slug}/{resourceSlug}/publish", operationID: "publishResource", summary: "Publish a resource", tag: "collections", authenticated: true,
+		response: collection.Resource{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	{method: "POST", path: "/collections/{slug}/{resourceSlug}/unpublish", operationID: "unpublishResource", summary: "Unpublish a resource", tag: "collections", authenticated: true,
+		response: collection.Resource{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/collections/{slug}/{resourceSlug}/schedule", operationID: "scheduleResource", summary: "Schedule the publication of a resource", tag: "collections", authenticated: true,
+		request: collection.Schedule{}, response: collection.Resource{}, status: http.StatusOK,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity}},
+	{method: "GET", path: "/collections/{slug}/{resourceSlug}/revisions", operationID: "listRevisions", summary: "List the revisions of a resource", tag: "collections", authenticated: true,
+		response: []collection.Revision{}, status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	{method: "GET", path: "/collections/{slug}/{resourceSlug}/revisions/diff", operationID: "diffRevisions", summary: "Compare two revisions of a resource", tag: "collections", authenticated: true,
+		parameters: queryParameters(collection.DiffRevisionsQueryString{}), response: collection.DiffRevisionsResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "POST", path: "/collections/{slug}/{resourceSlug}/revisions/{id}/restore", operationID: "restoreRevision", summary: "Restore the content of a revision", tag: "collections", authenticated: true,
+		response: collection.Resource{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity}},
+	{method: "GET", path: "/collections/{slug}/aggregate", operationID: "aggregateResources", summary: "Count and aggregate the resources of a collection, optionally by group", tag: "collections",
+		parameters: append(aggregateParameters(anyWhere), draftParameter), response: []collection.AggregateGroup{}, status: http.StatusOK,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "GET", path: "/collections/{slug}/export", operationID: "exportResources", summary: "Export every resource of a collection", tag: "collections", authenticated: true,
+		parameters: []*Parameter{bulkFormatParameter}, response: exportContent, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "POST", path: "/collections/{slug}/import", operationID: "importResources", summary: "Create or update resources by slug from a file", tag: "collections", authenticated: true,
+		parameters: []*Parameter{bulkFormatParameter, batchSizeParameter},
+		request:    &RequestBody{Required: true, Content: exportContent}, response: collection.ImportReport{}, status: http.StatusOK,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/collections/{slug}/{resourceSlug}/references", operationID: "listReferences", summary: "List the resources referencing a resource, by collection and field", tag: "collections", authenticated: true,
+		response: []collection.ReferenceGroup{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "GET", path: "/collections/{slug}/trash", operationID: "listTrash", summary: "List the resources in the trash", tag: "collections", authenticated: true,
+		parameters: listParameters(anyWhere), response: []collection.Resource{}, status: http.StatusOK, headers: totalCountHeaders,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "POST", path: "/collections/{slug}/trash/{resourceSlug}/restore", operationID: "restoreResource", summary: "Restore a resource from the trash", tag: "collections", authenticated: true,
+		response: collection.Resource{}, status: http.StatusOK, errors: []int{http.StatusNotFound, http.StatusConflict}},
+	{method: "DELETE", path: "/collections/{slug}/trash/{resourceSlug}", operationID: "purgeResource", summary: "Permanently delete a resource from the trash", tag: "collections", authenticated: true,
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound, http.StatusConflict}},
+	{method: "GET", path: "/globals/{slug}", operationID: "getGlobal", summary: "Get a global", tag: "collections",
+		parameters: []*Parameter{draftParameter, populateParameter, localeParameter, fallbackLocaleParameter, richTextParameter},
+		response:   collection.Resource{}, status: http.StatusOK, headers: resourceHeaders, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "PUT", path: "/globals/{slug}", operationID: "updateGlobal", summary: "Update a global, creating its resource when it does not exist", tag: "collections", authenticated: true,
+		parameters: []*Parameter{localeParameter, ifMatchParameter}, request: &RequestBody{Required: true, Content: jsonContent(ref(resourceInputSchema))},
+		response: collection.Resource{}, status: http.StatusOK, headers: resourceHeaders,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnprocessableEntity}},
+	{method: "POST", path: "/batch", operationID: "executeBatch", summary: "Create, update and delete resources within a single transaction", tag: "collections", authenticated: true,
+		request: collection.BatchRequest{}, response: collection.BatchResponse{}, status: http.StatusOK,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity}, failure: collection.BatchResponse{}},
+	{method: "GET", path: "/locales", operationID: "listLocales", summary: "List the locales of the localized fields", tag: "collections",
+		response: []collection.Locale{}, status: http.StatusOK},
+
+	{method: "POST", path: "/media", operationID: "uploadMedia", summary: "Upload a file", tag: "media", authenticated: true,
+		request: &RequestBody{Required: true, Content: map[string]*MediaType{"multipart/form-data": {Schema: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}},
+			Required:   []string{"file"},
+		}}}},
+		response: struct{}{}, status: http.StatusCreated, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/media", operationID: "listMedia", summary: "List the uploaded files", tag: "media",
+		response: []media.MediaResponse{}, status: http.StatusOK},
+	{method: "GET", path: "/media/{id}", operationID: "getMedia", summary: "Get an uploaded file", tag: "media",
+		response: media.MediaResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{method: "DELETE", path: "/media/{id}", operationID: "deleteMedia", summary: "Delete an uploaded file", tag: "media", authenticated: true,
+		status: http.StatusNoContent, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+
+	{method: "GET", path: "/users", operationID: "listUsers", summary: "List the users", tag: "users", authenticated: true,
+		response: []auth.User{}, status: http.StatusOK},
+	{method: "GET", path: "/users/{id}", operationID: "getUser", summary: "Get a user", tag: "users", authenticated: true,
+		response: auth.User{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	{method: "GET", path: "/events", operationID: "streamEvents", summary: "Stream the changes of the resources as Server-Sent Events", tag: "events", authenticated: true,
+		parameters: []*Parameter{
+			{Name: "collections", In: "query", Description: "The comma separated slugs of the collections to follow, all of them when omitted.", Schema: &Schema{Type: "string"}},
+			{Name: "Last-Event-ID", In: "header", Description: "The identifier of the last event received, to resume the stream after it.", Schema: &Schema{Type: "string"}},
+			{Name: "lastEventId", In: "query", Description: "The identifier of the last event received, for the clients which cannot set the Last-Event-ID header.", Schema: &Schema{Type: "string"}},
+		},
+		response: map[string]*MediaType{"text/event-stream": {Schema: &Schema{
+			Type:        "string",
+			Description: "The created, updated and deleted events, each with its identifier and, as JSON data, the collection and slug of the resource and the resource itself unless it was deleted.",
+		}}},
+		status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	{method: "GET", path: "/webhooks", operationID: "listWebhooks", summary: "List the webhook endpoints", tag: "webhooks", authenticated: true,
+		response: []webhook.EndpointResponse{}, status: http.StatusOK, errors: []int{http.StatusForbidden}},
+	{method: "POST", path: "/webhooks", operationID: "createWebhook", summary: "Register a webhook endpoint, its signing secret is only returned here", tag: "webhooks", authenticated: true,
+		request: webhook.CreateEndpointRequest{}, response: webhook.EndpointResponse{}, status: http.StatusCreated, errors: []int{http.StatusBadRequest, http.StatusForbidden}},
+	{method: "DELETE", path: "/webhooks/{id}", operationID: "deleteWebhook", summary: "Delete a webhook endpoint and its deliveries", tag: "webhooks", authenticated: true,
+		status: http.StatusNoContent, errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+	{method: "GET", path: "/webhooks/{id}/deliveries", operationID: "listWebhookDeliveries", summary: "List the latest deliveries of a webhook endpoint", tag: "webhooks", authenticated: true,
+		parameters: []*Parameter{
+			{Name: "status", In: "query", Schema: &Schema{Type: "string", Enum: []any{"pending", "succeeded", "failed"}}},
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}},
+		},
+		response: []webhook.DeliveryResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+	{method: "POST", path: "/webhooks/deliveries/{id}/redeliver", operationID: "redeliverWebhook", summary: "Send a delivery again", tag: "webhooks", authenticated: true,
+		response: webhook.DeliveryResponse{}, status: http.StatusCreated, errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+
+	{method: "GET", path: "/sync/status", operationID: "getSyncStatus", summary: "List the last synchronizations of the schema", tag: "sync", authenticated: true,
+		parameters: queryParameters(sync.StatusQueryString{}), response: sync.StatusResponse{}, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+	{method: "GET", path: "/sync/jobs", operationID: "listJobs", summary: "List the scheduled jobs", tag: "sync", authenticated: true,
+		response: []cron.JobStatus{}, status: http.StatusOK},
+	{method: "GET", path: "/sync/active-migration", operationID: "getActiveMigration", summary: "Get the synchronization of the active schema", tag: "sync", authenticated: true,
+		response: struct {
+			ActiveMigration *sync.SyncStatus `json:"active_migration"`
+		}{}, status: http.StatusOK},
+
+	{method: "GET", path: "/graphql", operationID: "queryGraphQL", summary: "Execute a GraphQL query given in the query string", tag: "graphql",
+		parameters: []*Parameter{
+			{Name: "query", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+			{Name: "operationName", In: "query", Schema: &Schema{Type: "string"}},
+			{Name: "variables", In: "query", Description: "The variables, encoded as a JSON object.", Schema: &Schema{Type: "string"}},
+		},
+		response: graphql.Response{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge}},
+	{method: "POST", path: "/graphql", operationID: "postGraphQL", summary: "Execute a GraphQL query", tag: "graphql",
+		request: graphql.Request{}, response: graphql.Response{}, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge}},
+
+	{method: "GET", path: "/openapi.json", operationID: "getOpenAPI", summary: "Get this document", tag: "openapi",
+		response: &Schema{Type: "object"}, status: http.StatusOK},
+}
+
+var tags = []Tag{
+	{Name: "auth", Description: "Sessions and accounts."},
+	{Name: "collections", Description: "The resources of any collection, see the tag of each collection for their schemas."},
+	{Name: "media", Description: "Uploaded files."},
+	{Name: "users", Description: "The users of the CMS."},
+	{Name: "events", Description: "The feed of the changes of the resources."},
+	{Name: "webhooks", Description: "The endpoints notified of the changes, reserved to the admins."},
+	{Name: "sync", Description: "The synchronization of the schema from the repository."},
+	{Name: "graphql", Description: "The GraphQL endpoint, see its introspection for the schema."},
+	{Name: "openapi", Description: "This document."},
+}
+
+// collectionTag is the tag of the operations of a collection.
+func collectionTag(c *collection.Collection) string {
+	return fmt.Sprintf("collection:%s", c.Slug)
+}
@@ -20,13 +20,57 @@ import (
 type Migrator struct {
 	generator            schema_generator.SchemaGenerator
 	collectionRepository collection.Repository
+	// hooks are called after the collections were updated.
+	hooks []func(ctx context.Context) error
+	// syncHooks are called once the sync of a commit succeeded or failed.
+	syncHooks []func(ctx context.Context, event SyncEvent) error
 }
 
-func NewMigrator(collectionRepository collection.Repository) *Migrator {
-	return &Migrator{
+// SyncEvent is the outcome of the sync of a commit of the repository.
+type SyncEvent struct {
+	Repository string `json:"repository"`
+	Commit     string `json:"commit"`
+	// Error is the reason why the sync failed, empty when it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+type MigratorOption func(*Migrator)
+
+// OnSyncFinished adds a function called once the sync of a commit succeeded or failed.
+func OnSyncFinished(hook func(ctx context.Context, event SyncEvent) error) MigratorOption {
+	return func(m *Migrator) {
+		m.syncHooks = append(m.syncHooks, hook)
+	}
+}
+
+// syncFinished notifies the sync hooks, their errors are logged as the sync is already over.
+func (m *Migrator) syncFinished(ctx context.Context, event SyncEvent) {
+	for _, hook := range m.syncHooks {
+		if err := hook(ctx, event); err != nil {
+			slog.Error("Failed to notify sync", "repository", event.Repository, "commit", event.Commit, "error", err)
+		}
+	}
+}
+
+// OnCollectionsUpdated adds a function called after the collections were updated by a sync,
+// to refresh what is derived from their definitions.
+func OnCollectionsUpdated(hook func(ctx context.Context) error) MigratorOption {
+	return func(m *Migrator) {
+		m.hooks = append(m.hooks, hook)
+	}
+}
+
+func NewMigrator(collectionRepository collection.Repository, options ...MigratorOption) *Migrator {
+	m := &Migrator{
 		generator:            schema_generator.New(),
 		collectionRepository: collectionRepository,
 	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
 }
 
 func (m *Migrator) GenerateSchema(ctx context.Context, schema *mimsy_schema.Schema) (*schema_generator.SqlSchema, error) {
@@ -87,6 +131,24 @@ func (m *Migrator) UpdateCollections(ctx context.Context, schema *mimsy_schema.S
 		}
 	}
 
+	// The collections are up to date even when a hook fails, so the sync is not failed
+	for _, hook := range m.hooks {
+		if err := hook(ctx); err != nil {
+			slog.Error("Failed to run collections hook", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateLocales replaces the locales of the project by the ones declared in its configuration.
+func (m *Migrator) UpdateLocales(ctx context.Context, config *mimsy_schema.MimsyConfig) error {
+	locales := collection.NewLocales(config)
+	if err := m.collectionRepository.SetLocales(ctx, locales); err != nil {
+		return fmt.Errorf("Failed to update locales: %w", err)
+	}
+
+	slog.Info("Updated locales", "locales", config.Locales, "default", config.GetDefaultLocale())
 	return nil
 }
 
@@ -112,6 +174,10 @@ func (m *Migrator) Migrate(ctx context.Context, activeSync *SyncStatus, newSql *
 		return fmt.Errorf("Failed to unmarshal active schema: %w", err)
 	}
 
+	if err := schema_diff.CheckColumnChanges(*activeSql, *newSql); err != nil {
+		return fmt.Errorf("Failed to diff schemas: %w", err)
+	}
+
 	// Make the diff operation
 	operations := schema_diff.Diff(*activeSql, *newSql)
 
@@ -111,6 +111,39 @@ func TestMigrator_UpdateCollections_NewCollection(t *testing.T) {
 	}
 }
 
+func TestMigrator_UpdateCollections_RunsHooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calls := 0
+	mockCollectionRepo := mocks_collection.NewMockRepository(ctrl)
+	migrator := sync.NewMigrator(
+		mockCollectionRepo,
+		sync.OnCollectionsUpdated(func(ctx context.Context) error {
+			calls++
+			return errors.New("hook failed")
+		}),
+		sync.OnCollectionsUpdated(func(ctx context.Context) error {
+			calls++
+			return nil
+		}),
+	)
+
+	mockCollectionRepo.EXPECT().
+		FindAll(gomock.Any(), &collection.FindAllParams{Search: ""}).
+		Return([]collection.Collection{}, nil).
+		Times(1)
+
+	// A failing hook does not fail the update, nor prevents the next hooks from running
+	err := migrator.UpdateCollections(context.Background(), &mimsy_schema.Schema{})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected both hooks to be called, got %d calls", calls)
+	}
+}
+
 func TestMigrator_UpdateCollections_ExistingCollection(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
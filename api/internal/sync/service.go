@@ -39,7 +39,7 @@ type syncProvider struct {
 	migrator             Migrator
 }
 
-func New(db *sql.DB, pemKey string, appId int64, repositoryName string) (SyncProvider, error) {
+func New(db *sql.DB, pemKey string, appId int64, repositoryName string, options ...MigratorOption) (SyncProvider, error) {
 	githubClient, err := github_fetcher.New(appId, []byte(pemKey))
 	if err != nil {
 		return nil, err
@@ -51,7 +51,7 @@ func New(db *sql.DB, pemKey string, appId int64, repositoryName string) (SyncPro
 		repositoryName:       repositoryName,
 		pathToProject:        "",
 		syncStatusRepository: NewRepository(),
-		migrator:             *NewMigrator(collection.NewRepository()),
+		migrator:             *NewMigrator(collection.NewRepository(), options...),
 	}, nil
 }
 
@@ -89,6 +89,7 @@ func (s *syncProvider) markErrorAndReturn(ctx context.Context, repositoryName, c
 	if markErr := s.syncStatusRepository.MarkError(ctx, repositoryName, commitSha, err); markErr != nil {
 		return fmt.Errorf("failed to mark error for repository %s: %w", repositoryName, markErr)
 	}
+	s.migrator.syncFinished(ctx, SyncEvent{Repository: repositoryName, Commit: commitSha, Error: err.Error()})
 	return fmt.Errorf(message+": %w", repositoryName, err)
 }
 
@@ -140,6 +141,11 @@ func (s *syncProvider) SyncRepository(ctx context.Context) error {
 		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to unmarshal config file for repository %s")
 	}
 
+	// Locales are applied once the schema is migrated, so that a failed sync leaves them unchanged
+	if err := config.ValidateLocales(); err != nil {
+		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "invalid locales for repository %s")
+	}
+
 	var path string
 	if config.SchemaPath != "" {
 		path = config.SchemaPath
@@ -177,6 +183,11 @@ func (s *syncProvider) SyncRepository(ctx context.Context) error {
 			if string(currentSchemaBytes) == string(activeSchemaBytes) {
 				slog.Info("Schema is identical to active migration, marking as skipped", "repository", s.repositoryName, "commit", contents.Sha)
 
+				// Locales are applied even when the schema is unchanged
+				if err := s.migrator.UpdateLocales(ctx, &config); err != nil {
+					return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to update locales for repository %s")
+				}
+
 				// Set the manifest and mark as skipped
 				if err := s.syncStatusRepository.SetManifest(ctx, s.repositoryName, contents.Sha, schemaStruct); err != nil {
 					return fmt.Errorf("failed to set manifest for repository %s: %w", s.repositoryName, err)
@@ -219,6 +230,10 @@ func (s *syncProvider) SyncRepository(ctx context.Context) error {
 		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to run migration for repository %s")
 	}
 
+	if err := s.migrator.UpdateLocales(ctx, &config); err != nil {
+		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to update locales for repository %s")
+	}
+
 	// Now, add the collections to the main collections table
 	if err := s.migrator.UpdateCollections(ctx, &schemaStruct); err != nil {
 		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to update collections %s")
@@ -227,6 +242,7 @@ func (s *syncProvider) SyncRepository(ctx context.Context) error {
 	if err := s.syncStatusRepository.MarkAsActive(ctx, s.repositoryName, contents.Sha); err != nil {
 		return s.markErrorAndReturn(ctx, s.repositoryName, contents.Sha, err, "failed to mark migration as active %s")
 	}
+	s.migrator.syncFinished(ctx, SyncEvent{Repository: s.repositoryName, Commit: contents.Sha})
 
 	// Generate the diff between the schema and the active migration
 	slog.Info("Completed sync for repository", "repository", s.repositoryName)
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	"github.com/mimsy-cms/mimsy/internal/util"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type webhookHandler struct {
+	webhookService Service
+}
+
+func NewHandler(webhookService Service) *webhookHandler {
+	return &webhookHandler{webhookService: webhookService}
+}
+
+type CreateEndpointRequest struct {
+	URL    string      `json:"url"`
+	Events []EventType `json:"events"`
+}
+
+// requireAdmin writes an error response and returns nil unless the request is made by an admin.
+func requireAdmin(w http.ResponseWriter, r *http.Request) *auth.User {
+	user := auth.RequestUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+
+	if !user.IsAdmin {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil
+	}
+
+	return user
+}
+
+func (h *webhookHandler) FindEndpoints(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	endpoints, err := h.webhookService.FindEndpoints(r.Context())
+	if err != nil {
+		slog.Error("Failed to retrieve webhook endpoints", "error", err)
+		http.Error(w, "Failed to retrieve webhook endpoints", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]EndpointResponse, len(endpoints))
+	for i := range endpoints {
+		response[i] = NewEndpointResponse(&endpoints[i])
+	}
+
+	util.JSON(w, http.StatusOK, response)
+}
+
+func (h *webhookHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	user := requireAdmin(w, r)
+	if user == nil {
+		return
+	}
+
+	var req CreateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(r.Context(), req.URL, req.Events, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidEndpoint) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		slog.Error("Failed to create webhook endpoint", "error", err)
+		http.Error(w, "Failed to create webhook endpoint", http.StatusInternalServerError)
+		return
+	}
+
+	response := NewEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+
+	util.JSON(w, http.StatusCreated, response)
+}
+
+func (h *webhookHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	id, err := util.PathInt(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook endpoint ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(r.Context(), id); err != nil {
+		if err == ErrNotFound {
+			http.Error(w, "Webhook endpoint not found", http.StatusNotFound)
+			return
+		}
+
+		slog.Error("Failed to delete webhook endpoint", "id", id, "error", err)
+		http.Error(w, "Failed to delete webhook endpoint", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *webhookHandler) FindDeliveries(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	id, err := util.PathInt(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook endpoint ID", http.StatusBadRequest)
+		return
+	}
+
+	params := &FindDeliveriesParams{EndpointId: id, Limit: defaultDeliveriesLimit}
+
+	query := r.URL.Query()
+	if status := DeliveryStatus(query.Get("status")); status != "" {
+		if status != DeliveryPending && status != DeliverySucceeded && status != DeliveryFailed {
+			http.Error(w, "Invalid delivery status", http.StatusBadRequest)
+			return
+		}
+		params.Status = status
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if params.Limit, err = strconv.ParseUint(limit, 10, 64); err != nil || params.Limit == 0 || params.Limit > maxDeliveriesLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := h.webhookService.FindDeliveries(r.Context(), params)
+	if err != nil {
+		if err == ErrNotFound {
+			http.Error(w, "Webhook endpoint not found", http.StatusNotFound)
+			return
+		}
+
+		slog.Error("Failed to retrieve webhook deliveries", "endpoint", id, "error", err)
+		http.Error(w, "Failed to retrieve webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]DeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		response[i] = NewDeliveryResponse(&deliveries[i])
+	}
+
+	util.JSON(w, http.StatusOK, response)
+}
+
+func (h *webhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	if requireAdmin(w, r) == nil {
+		return
+	}
+
+	id, err := util.PathInt(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), id)
+	if err != nil {
+		if err == ErrNotFound {
+			http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+			return
+		}
+
+		slog.Error("Failed to redeliver webhook", "delivery", id, "error", err)
+		http.Error(w, "Failed to redeliver webhook", http.StatusInternalServerError)
+		return
+	}
+
+	util.JSON(w, http.StatusCreated, NewDeliveryResponse(delivery))
+}
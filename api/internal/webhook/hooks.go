@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/media"
+	"github.com/mimsy-cms/mimsy/internal/sync"
+)
+
+// OnResourceEvent returns a collection event hook dispatching the changes of the resources.
+func OnResourceEvent(service Service) collection.EventHook {
+	return func(ctx context.Context, event collection.Event) error {
+		return service.Dispatch(ctx, EventType(event.Type), event)
+	}
+}
+
+// OnMediaUploaded returns a media upload hook dispatching the uploaded media.
+func OnMediaUploaded(service Service) media.UploadHook {
+	return func(ctx context.Context, m *media.Media) error {
+		return service.Dispatch(ctx, EventMediaUploaded, media.NewMediaResponse(m))
+	}
+}
+
+// OnSyncFinished returns a sync hook dispatching the outcome of the syncs.
+func OnSyncFinished(service Service) func(ctx context.Context, event sync.SyncEvent) error {
+	return func(ctx context.Context, event sync.SyncEvent) error {
+		if event.Error != "" {
+			return service.Dispatch(ctx, EventSyncFailed, event)
+		}
+		return service.Dispatch(ctx, EventSyncSucceeded, event)
+	}
+}
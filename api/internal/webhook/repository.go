@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/internal/config"
+)
+
+// ErrNotFound is returned when a webhook endpoint or delivery does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Endpoint is a URL notified of the events it subscribed to.
+type Endpoint struct {
+	Id     int64
+	URL    string
+	Events []EventType
+	// Secret signs the deliveries, it is only shown when the endpoint is created.
+	Secret    string
+	CreatedAt time.Time
+	CreatedBy int64
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is an event sent to an endpoint, retried until it succeeds or runs out of attempts.
+type Delivery struct {
+	Id            int64
+	EndpointId    int64
+	Event         EventType
+	Payload       json.RawMessage
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt *time.Time
+	LastAttemptAt *time.Time
+	// ResponseStatus is the status code of the last attempt, nil when no response was received.
+	ResponseStatus *int
+	LastError      *string
+	CreatedAt      time.Time
+}
+
+type FindDeliveriesParams struct {
+	EndpointId int64
+	// Status filters the deliveries, all of them are listed when empty.
+	Status DeliveryStatus
+	Limit  uint64
+}
+
+type Repository interface {
+	CreateEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error)
+	FindEndpoint(ctx context.Context, id int64) (*Endpoint, error)
+	FindEndpoints(ctx context.Context) ([]Endpoint, error)
+	FindEndpointsByEvent(ctx context.Context, event EventType) ([]Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, endpointId int64, event EventType, payload json.RawMessage, nextAttemptAt time.Time) (*Delivery, error)
+	FindDelivery(ctx context.Context, id int64) (*Delivery, error)
+	FindDeliveries(ctx context.Context, params *FindDeliveriesParams) ([]Delivery, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, claimedUntil time.Time, limit uint64) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+}
+
+type webhookRepository struct{}
+
+func NewRepository() Repository {
+	return &webhookRepository{}
+}
+
+const endpointColumns = `id, url, events, secret, created_at, created_by`
+
+const deliveryColumns = `id, endpoint_id, event, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at`
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error) {
+	query := `
+		INSERT INTO webhook_endpoint (url, events, secret, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + endpointColumns
+
+	return scanEndpoint(config.GetDB(ctx).QueryRowContext(ctx, query,
+		endpoint.URL,
+		pq.Array(endpoint.Events),
+		endpoint.Secret,
+		endpoint.CreatedBy,
+	))
+}
+
+func (r *webhookRepository) FindEndpoint(ctx context.Context, id int64) (*Endpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoint WHERE id = $1`
+
+	return scanEndpoint(config.GetDB(ctx).QueryRowContext(ctx, query, id))
+}
+
+func (r *webhookRepository) FindEndpoints(ctx context.Context) ([]Endpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoint ORDER BY id`
+
+	return r.findEndpoints(ctx, query)
+}
+
+func (r *webhookRepository) FindEndpointsByEvent(ctx context.Context, event EventType) ([]Endpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoint WHERE $1 = ANY(events) ORDER BY id`
+
+	return r.findEndpoints(ctx, query, event)
+}
+
+func (r *webhookRepository) findEndpoints(ctx context.Context, query string, args ...any) ([]Endpoint, error) {
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []Endpoint{}
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	query := `DELETE FROM webhook_endpoint WHERE id = $1`
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, endpointId int64, event EventType, payload json.RawMessage, nextAttemptAt time.Time) (*Delivery, error) {
+	query := `
+		INSERT INTO webhook_delivery (endpoint_id, event, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + deliveryColumns
+
+	return scanDelivery(config.GetDB(ctx).QueryRowContext(ctx, query,
+		endpointId,
+		event,
+		[]byte(payload),
+		DeliveryPending,
+		nextAttemptAt,
+	))
+}
+
+func (r *webhookRepository) FindDelivery(ctx context.Context, id int64) (*Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_delivery WHERE id = $1`
+
+	return scanDelivery(config.GetDB(ctx).QueryRowContext(ctx, query, id))
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, params *FindDeliveriesParams) ([]Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_delivery
+		WHERE endpoint_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	return r.findDeliveries(ctx, query, params.EndpointId, params.Status, params.Limit)
+}
+
+// ClaimDueDeliveries returns the pending deliveries whose next attempt is due, moving it to claimedUntil
+// so that concurrent runs of the delivery job do not send them twice while they are being sent.
+// The claim is a single statement, the rows are not locked while the deliveries are sent.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, claimedUntil time.Time, limit uint64) ([]Delivery, error) {
+	query := `
+		UPDATE webhook_delivery SET next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM webhook_delivery
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+
+	return r.findDeliveries(ctx, query, DeliveryPending, now, claimedUntil, limit)
+}
+
+func (r *webhookRepository) findDeliveries(ctx context.Context, query string, args ...any) ([]Delivery, error) {
+	rows, err := config.GetDB(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+	query := `
+		UPDATE webhook_delivery
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5, response_status = $6, last_error = $7
+		WHERE id = $1`
+
+	result, err := config.GetDB(ctx).ExecContext(ctx, query,
+		delivery.Id,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastAttemptAt,
+		delivery.ResponseStatus,
+		delivery.LastError,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEndpoint(row rowScanner) (*Endpoint, error) {
+	endpoint := &Endpoint{}
+	var events pq.StringArray
+
+	if err := row.Scan(
+		&endpoint.Id,
+		&endpoint.URL,
+		&events,
+		&endpoint.Secret,
+		&endpoint.CreatedAt,
+		&endpoint.CreatedBy,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	endpoint.Events = make([]EventType, len(events))
+	for i, event := range events {
+		endpoint.Events[i] = EventType(event)
+	}
+
+	return endpoint, nil
+}
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	delivery := &Delivery{}
+	var payload []byte
+	var responseStatus sql.NullInt64
+
+	if err := row.Scan(
+		&delivery.Id,
+		&delivery.EndpointId,
+		&delivery.Event,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&responseStatus,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+
+	return delivery, nil
+}
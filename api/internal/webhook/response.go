@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EndpointResponse struct {
+	Id     int64       `json:"id"`
+	URL    string      `json:"url"`
+	Events []EventType `json:"events"`
+	// Secret is only returned when the endpoint is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy int64     `json:"created_by"`
+}
+
+func NewEndpointResponse(endpoint *Endpoint) EndpointResponse {
+	return EndpointResponse{
+		Id:        endpoint.Id,
+		URL:       endpoint.URL,
+		Events:    endpoint.Events,
+		CreatedAt: endpoint.CreatedAt,
+		CreatedBy: endpoint.CreatedBy,
+	}
+}
+
+type DeliveryResponse struct {
+	Id             int64           `json:"id"`
+	EndpointId     int64           `json:"endpoint_id"`
+	Event          EventType       `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func NewDeliveryResponse(delivery *Delivery) DeliveryResponse {
+	return DeliveryResponse{
+		Id:             delivery.Id,
+		EndpointId:     delivery.EndpointId,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,374 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mimsy-cms/mimsy/internal/collection"
+	"github.com/mimsy-cms/mimsy/internal/config"
+	"github.com/mimsy-cms/mimsy/internal/cron"
+)
+
+// EventType is the kind of event an endpoint can subscribe to.
+type EventType string
+
+const (
+	EventResourceCreated   = EventType(collection.EventResourceCreated)
+	EventResourceUpdated   = EventType(collection.EventResourceUpdated)
+	EventResourceDeleted   = EventType(collection.EventResourceDeleted)
+	EventResourcePublished = EventType(collection.EventResourcePublished)
+	EventMediaUploaded     = EventType("media.uploaded")
+	EventSyncSucceeded     = EventType("sync.succeeded")
+	EventSyncFailed        = EventType("sync.failed")
+)
+
+// EventTypes are the events an endpoint can subscribe to.
+var EventTypes = []EventType{
+	EventResourceCreated,
+	EventResourceUpdated,
+	EventResourceDeleted,
+	EventResourcePublished,
+	EventMediaUploaded,
+	EventSyncSucceeded,
+	EventSyncFailed,
+}
+
+const (
+	// MaxAttempts is the number of attempts after which a delivery is marked as failed.
+	MaxAttempts = 8
+	// baseRetryDelay is the delay before the first retry, doubled after each failed attempt.
+	baseRetryDelay = 30 * time.Second
+	// dueDeliveriesBatchSize is the number of deliveries sent by each run of the delivery job.
+	dueDeliveriesBatchSize = 100
+	// deliveryTimeout bounds the time an endpoint has to answer a delivery.
+	deliveryTimeout = 10 * time.Second
+	// claimDuration is the time a run of the delivery job has to send the deliveries it claimed,
+	// longer than sending a whole batch to endpoints that time out.
+	claimDuration = 2 * dueDeliveriesBatchSize * deliveryTimeout
+	// secretBytes is the number of random bytes of a secret, hex encoded to fit the column.
+	secretBytes = 32
+)
+
+// The headers sent with each delivery.
+const (
+	HeaderEvent     = "X-Mimsy-Event"
+	HeaderDelivery  = "X-Mimsy-Delivery"
+	HeaderSignature = "X-Mimsy-Signature"
+)
+
+// ErrInvalidEndpoint is returned when an endpoint has an invalid URL or subscribes to unknown events.
+var ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+
+type Service interface {
+	CreateEndpoint(ctx context.Context, endpointURL string, events []EventType, createdBy int64) (*Endpoint, error)
+	FindEndpoint(ctx context.Context, id int64) (*Endpoint, error)
+	FindEndpoints(ctx context.Context) ([]Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	Dispatch(ctx context.Context, event EventType, data any) error
+	FindDeliveries(ctx context.Context, params *FindDeliveriesParams) ([]Delivery, error)
+	Redeliver(ctx context.Context, id int64) (*Delivery, error)
+	DeliverDue(ctx context.Context, now time.Time) error
+}
+
+type ServiceOption func(*service)
+
+// WithHTTPClient sets the client used to send the deliveries.
+func WithHTTPClient(client *http.Client) ServiceOption {
+	return func(s *service) {
+		s.client = client
+	}
+}
+
+// WithClock sets the function returning the current time, used to timestamp the deliveries.
+func WithClock(now func() time.Time) ServiceOption {
+	return func(s *service) {
+		s.now = now
+	}
+}
+
+func NewService(webhookRepository Repository, options ...ServiceOption) *service {
+	s := &service{
+		webhookRepository: webhookRepository,
+		client:            &http.Client{Timeout: deliveryTimeout},
+		now:               time.Now,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+type service struct {
+	webhookRepository Repository
+	client            *http.Client
+	now               func() time.Time
+}
+
+func (s *service) CreateEndpoint(ctx context.Context, endpointURL string, events []EventType, createdBy int64) (*Endpoint, error) {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: the URL must be an absolute http or https URL", ErrInvalidEndpoint)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", ErrInvalidEndpoint)
+	}
+	for _, event := range events {
+		if !slices.Contains(EventTypes, event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidEndpoint, event)
+		}
+	}
+
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return s.webhookRepository.CreateEndpoint(ctx, &Endpoint{
+		URL:       endpointURL,
+		Events:    slices.Compact(slices.Sorted(slices.Values(events))),
+		Secret:    hex.EncodeToString(secret),
+		CreatedBy: createdBy,
+	})
+}
+
+func (s *service) FindEndpoint(ctx context.Context, id int64) (*Endpoint, error) {
+	return s.webhookRepository.FindEndpoint(ctx, id)
+}
+
+func (s *service) FindEndpoints(ctx context.Context) ([]Endpoint, error) {
+	return s.webhookRepository.FindEndpoints(ctx)
+}
+
+func (s *service) DeleteEndpoint(ctx context.Context, id int64) error {
+	return s.webhookRepository.DeleteEndpoint(ctx, id)
+}
+
+// Dispatch queues a delivery of the event to each endpoint subscribed to it. Called within the
+// transaction of the change, the deliveries are only sent once the change is committed.
+func (s *service) Dispatch(ctx context.Context, event EventType, data any) error {
+	endpoints, err := s.webhookRepository.FindEndpointsByEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", event, err)
+	}
+
+	now := s.now()
+	for _, endpoint := range endpoints {
+		if _, err := s.webhookRepository.CreateDelivery(ctx, endpoint.Id, event, payload, now); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) FindDeliveries(ctx context.Context, params *FindDeliveriesParams) ([]Delivery, error) {
+	if _, err := s.webhookRepository.FindEndpoint(ctx, params.EndpointId); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.FindDeliveries(ctx, params)
+}
+
+// Redeliver queues a copy of a delivery and attempts it immediately, it is retried like any
+// other delivery when the attempt fails.
+func (s *service) Redeliver(ctx context.Context, id int64) (*Delivery, error) {
+	previous, err := s.webhookRepository.FindDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.webhookRepository.FindEndpoint(ctx, previous.EndpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The copy is created claimed, so that the delivery job does not send it while it is attempted
+	delivery, err := s.webhookRepository.CreateDelivery(ctx, endpoint.Id, previous.Event, previous.Payload, s.now().Add(claimDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attempt(ctx, endpoint, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// DeliverDue sends the pending deliveries whose next attempt is due. The deliveries are claimed before
+// being sent, so that no transaction stays open while the endpoints answer, and the outcome of each one
+// is recorded on its own. A delivery whose outcome could not be recorded is sent again once its claim expires.
+func (s *service) DeliverDue(ctx context.Context, now time.Time) error {
+	deliveries, err := s.webhookRepository.ClaimDueDeliveries(ctx, now, now.Add(claimDuration), dueDeliveriesBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim due webhook deliveries: %w", err)
+	}
+
+	var errs []error
+	endpoints := map[int64]*Endpoint{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		endpoint, ok := endpoints[delivery.EndpointId]
+		if !ok {
+			if endpoint, err = s.webhookRepository.FindEndpoint(ctx, delivery.EndpointId); err != nil {
+				errs = append(errs, fmt.Errorf("failed to find webhook endpoint %d: %w", delivery.EndpointId, err))
+				continue
+			}
+			endpoints[delivery.EndpointId] = endpoint
+		}
+
+		if err := s.attempt(ctx, endpoint, delivery); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// attempt sends a delivery and records its outcome, scheduling a retry when it failed.
+func (s *service) attempt(ctx context.Context, endpoint *Endpoint, delivery *Delivery) error {
+	now := s.now()
+	status, err := s.send(ctx, endpoint, delivery, now)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.LastError = nil
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case err == nil:
+		delivery.Status = DeliverySucceeded
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = DeliveryFailed
+	default:
+		next := now.Add(RetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err != nil {
+		message := err.Error()
+		delivery.LastError = &message
+		slog.Warn("Failed to deliver webhook", "endpoint", endpoint.Id, "delivery", delivery.Id, "attempts", delivery.Attempts, "error", err)
+	}
+
+	if err := s.webhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", delivery.Id, err)
+	}
+
+	return nil
+}
+
+// deliveryBody is the JSON body posted to the endpoints.
+type deliveryBody struct {
+	Id        int64           `json:"id"`
+	Event     EventType       `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// send posts a delivery to its endpoint, returning the status code of the response when one was received.
+func (s *service) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery, now time.Time) (*int, error) {
+	body, err := json.Marshal(deliveryBody{
+		Id:        delivery.Id,
+		Event:     delivery.Event,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mimsy-Webhook")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, now, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	// The body is drained so that the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	status := res.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, fmt.Errorf("endpoint responded with status %d", status)
+	}
+
+	return &status, nil
+}
+
+// Sign computes the signature header of a delivery: "t=<unix timestamp>,v1=<signature>", where the
+// signature is the hex encoded HMAC-SHA256 of "<unix timestamp>.<body>" keyed with the endpoint secret.
+// Receivers recompute it to authenticate the delivery, and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay is the delay before the next attempt of a delivery which failed the given number of times.
+func RetryDelay(attempts int) time.Duration {
+	return baseRetryDelay << (attempts - 1)
+}
+
+// RegisterDeliveryJobs registers the cron job that sends the due webhook deliveries every minute.
+func RegisterDeliveryJobs(cronService cron.CronService, db *sql.DB, service Service) error {
+	ctx := context.Background()
+
+	deliveryJob := cron.Job{
+		Name:     "deliver-webhooks",
+		Schedule: "* * * * *", // Every minute
+		Function: func() error {
+			ctx := config.ContextWithDB(context.Background(), db)
+			return service.DeliverDue(ctx, time.Now())
+		},
+		Params: []any{},
+	}
+
+	if err := cronService.RegisterJob(ctx, deliveryJob); err != nil {
+		return fmt.Errorf("failed to register webhook delivery job: %w", err)
+	}
+
+	slog.Info("Successfully registered webhook delivery job")
+	return nil
+}
@@ -0,0 +1,456 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mimsy-cms/mimsy/internal/auth"
+	mocks "github.com/mimsy-cms/mimsy/internal/mocks/webhook"
+	"github.com/mimsy-cms/mimsy/internal/sync"
+	"github.com/mimsy-cms/mimsy/internal/webhook"
+)
+
+// =================================================================================================
+// Helper Functions
+// =================================================================================================
+
+var testNow = time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo webhook.Repository, client *http.Client) webhook.Service {
+	return webhook.NewService(repo, webhook.WithHTTPClient(client), webhook.WithClock(func() time.Time { return testNow }))
+}
+
+func addUserToContext(req *http.Request, user *auth.User) *http.Request {
+	ctx := context.WithValue(req.Context(), auth.UserContextKey, user)
+	return req.WithContext(ctx)
+}
+
+func createMockEndpoint(url string) *webhook.Endpoint {
+	return &webhook.Endpoint{
+		Id:     1,
+		URL:    url,
+		Events: []webhook.EventType{webhook.EventResourcePublished},
+		Secret: "secret",
+	}
+}
+
+func createMockDelivery() *webhook.Delivery {
+	next := testNow
+	return &webhook.Delivery{
+		Id:            7,
+		EndpointId:    1,
+		Event:         webhook.EventResourcePublished,
+		Payload:       json.RawMessage(`{"collection":"posts"}`),
+		Status:        webhook.DeliveryPending,
+		NextAttemptAt: &next,
+		CreatedAt:     testNow,
+	}
+}
+
+// =================================================================================================
+// Service Tests
+// =================================================================================================
+
+func TestService_CreateEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, http.DefaultClient)
+
+	mockRepo.EXPECT().
+		CreateEndpoint(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, endpoint *webhook.Endpoint) (*webhook.Endpoint, error) {
+			return endpoint, nil
+		})
+
+	endpoint, err := service.CreateEndpoint(context.Background(), "https://example.com/hook",
+		[]webhook.EventType{webhook.EventSyncFailed, webhook.EventMediaUploaded, webhook.EventSyncFailed}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []webhook.EventType{webhook.EventMediaUploaded, webhook.EventSyncFailed}
+	if !reflect.DeepEqual(endpoint.Events, expected) {
+		t.Errorf("expected events %v, got %v", expected, endpoint.Events)
+	}
+	if len(endpoint.Secret) != 64 {
+		t.Errorf("expected a 64 characters secret, got %q", endpoint.Secret)
+	}
+	if endpoint.CreatedBy != 3 {
+		t.Errorf("expected the endpoint to be created by 3, got %d", endpoint.CreatedBy)
+	}
+}
+
+func TestService_CreateEndpoint_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := newTestService(mocks.NewMockRepository(ctrl), http.DefaultClient)
+
+	tests := []struct {
+		name   string
+		url    string
+		events []webhook.EventType
+	}{
+		{name: "relative URL", url: "/hook", events: []webhook.EventType{webhook.EventMediaUploaded}},
+		{name: "unsupported scheme", url: "ftp://example.com", events: []webhook.EventType{webhook.EventMediaUploaded}},
+		{name: "no events", url: "https://example.com"},
+		{name: "unknown event", url: "https://example.com", events: []webhook.EventType{"resource.renamed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateEndpoint(context.Background(), tt.url, tt.events, 1); !errors.Is(err, webhook.ErrInvalidEndpoint) {
+				t.Errorf("expected ErrInvalidEndpoint, got %v", err)
+			}
+		})
+	}
+}
+
+func TestService_Dispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, http.DefaultClient)
+
+	mockRepo.EXPECT().
+		FindEndpointsByEvent(gomock.Any(), webhook.EventSyncSucceeded).
+		Return([]webhook.Endpoint{{Id: 1}, {Id: 2}}, nil)
+	for _, id := range []int64{1, 2} {
+		mockRepo.EXPECT().
+			CreateDelivery(gomock.Any(), id, webhook.EventSyncSucceeded, json.RawMessage(`{"repository":"blog","commit":"abc"}`), testNow).
+			Return(&webhook.Delivery{}, nil)
+	}
+
+	hook := webhook.OnSyncFinished(service)
+	if err := hook(context.Background(), sync.SyncEvent{Repository: "blog", Commit: "abc"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without subscribed endpoints, nothing is queued
+	mockRepo.EXPECT().FindEndpointsByEvent(gomock.Any(), webhook.EventSyncFailed).Return([]webhook.Endpoint{}, nil)
+
+	if err := hook(context.Background(), sync.SyncEvent{Repository: "blog", Commit: "abc", Error: "invalid schema"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestService_DeliverDue_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, server.Client())
+	delivery := createMockDelivery()
+
+	mockRepo.EXPECT().ClaimDueDeliveries(gomock.Any(), testNow, gomock.Any(), gomock.Any()).Return([]webhook.Delivery{*delivery}, nil)
+	mockRepo.EXPECT().FindEndpoint(gomock.Any(), int64(1)).Return(createMockEndpoint(server.URL), nil)
+	mockRepo.EXPECT().
+		UpdateDelivery(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d *webhook.Delivery) error {
+			if d.Status != webhook.DeliverySucceeded || d.Attempts != 1 || d.NextAttemptAt != nil {
+				t.Errorf("unexpected delivery %+v", d)
+			}
+			if d.ResponseStatus == nil || *d.ResponseStatus != http.StatusNoContent {
+				t.Errorf("expected the response status to be recorded, got %v", d.ResponseStatus)
+			}
+			return nil
+		})
+
+	if err := service.DeliverDue(context.Background(), testNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received == nil {
+		t.Fatal("expected the delivery to be sent")
+	}
+	if event := received.Header.Get(webhook.HeaderEvent); event != string(webhook.EventResourcePublished) {
+		t.Errorf("unexpected event header %q", event)
+	}
+	if id := received.Header.Get(webhook.HeaderDelivery); id != "7" {
+		t.Errorf("unexpected delivery header %q", id)
+	}
+	if signature := received.Header.Get(webhook.HeaderSignature); signature != webhook.Sign("secret", testNow, body) {
+		t.Errorf("unexpected signature %q", signature)
+	}
+
+	expected := `{"id":7,"event":"resource.published","created_at":"2025-03-01T12:00:00Z","data":{"collection":"posts"}}`
+	if string(body) != expected {
+		t.Errorf("expected body %s, got %s", expected, body)
+	}
+}
+
+func TestService_DeliverDue_Retries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, server.Client())
+
+	retried := createMockDelivery()
+	retried.Attempts = 2
+	exhausted := createMockDelivery()
+	exhausted.Id = 8
+	exhausted.Attempts = webhook.MaxAttempts - 1
+
+	mockRepo.EXPECT().ClaimDueDeliveries(gomock.Any(), testNow, gomock.Any(), gomock.Any()).Return([]webhook.Delivery{*retried, *exhausted}, nil)
+	// The endpoint is looked up once for both deliveries
+	mockRepo.EXPECT().FindEndpoint(gomock.Any(), int64(1)).Return(createMockEndpoint(server.URL), nil)
+
+	var updated []webhook.Delivery
+	mockRepo.EXPECT().
+		UpdateDelivery(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d *webhook.Delivery) error {
+			updated = append(updated, *d)
+			return nil
+		}).
+		Times(2)
+
+	if err := service.DeliverDue(context.Background(), testNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if updated[0].Status != webhook.DeliveryPending || updated[0].Attempts != 3 {
+		t.Errorf("expected the delivery to stay pending, got %+v", updated[0])
+	}
+	if next := testNow.Add(2 * time.Minute); updated[0].NextAttemptAt == nil || !updated[0].NextAttemptAt.Equal(next) {
+		t.Errorf("expected the next attempt at %v, got %v", next, updated[0].NextAttemptAt)
+	}
+	if updated[0].LastError == nil || !strings.Contains(*updated[0].LastError, "502") {
+		t.Errorf("expected the error to be recorded, got %v", updated[0].LastError)
+	}
+
+	if updated[1].Status != webhook.DeliveryFailed || updated[1].NextAttemptAt != nil {
+		t.Errorf("expected the delivery to fail, got %+v", updated[1])
+	}
+}
+
+func TestService_DeliverDue_RecordsEachOutcome(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, server.Client())
+
+	orphaned := createMockDelivery()
+	orphaned.EndpointId = 2
+	sent := createMockDelivery()
+	sent.Id = 8
+
+	mockRepo.EXPECT().
+		ClaimDueDeliveries(gomock.Any(), testNow, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, now time.Time, claimedUntil time.Time, limit uint64) ([]webhook.Delivery, error) {
+			if !claimedUntil.After(now) {
+				t.Errorf("expected the deliveries to be claimed after %v, got %v", now, claimedUntil)
+			}
+			return []webhook.Delivery{*orphaned, *sent}, nil
+		})
+	mockRepo.EXPECT().FindEndpoint(gomock.Any(), int64(2)).Return(nil, webhook.ErrNotFound)
+	mockRepo.EXPECT().FindEndpoint(gomock.Any(), int64(1)).Return(createMockEndpoint(server.URL), nil)
+	// The delivery is recorded although the previous one failed
+	mockRepo.EXPECT().
+		UpdateDelivery(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d *webhook.Delivery) error {
+			if d.Id != 8 || d.Status != webhook.DeliverySucceeded {
+				t.Errorf("unexpected delivery %+v", d)
+			}
+			return nil
+		})
+
+	if err := service.DeliverDue(context.Background(), testNow); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 5: 8 * time.Minute} {
+		if delay := webhook.RetryDelay(attempts); delay != expected {
+			t.Errorf("expected a delay of %v after %d attempts, got %v", expected, attempts, delay)
+		}
+	}
+}
+
+func TestService_Redeliver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	service := newTestService(mockRepo, server.Client())
+
+	previous := createMockDelivery()
+	previous.Status = webhook.DeliveryFailed
+	copied := createMockDelivery()
+	copied.Id = 9
+
+	mockRepo.EXPECT().FindDelivery(gomock.Any(), int64(7)).Return(previous, nil)
+	mockRepo.EXPECT().FindEndpoint(gomock.Any(), int64(1)).Return(createMockEndpoint(server.URL), nil)
+	// The copy is not due before it is attempted
+	mockRepo.EXPECT().
+		CreateDelivery(gomock.Any(), int64(1), previous.Event, previous.Payload, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, endpointId int64, event webhook.EventType, payload json.RawMessage, nextAttemptAt time.Time) (*webhook.Delivery, error) {
+			if !nextAttemptAt.After(testNow) {
+				t.Errorf("expected the copy to be claimed, its next attempt is at %v", nextAttemptAt)
+			}
+			return copied, nil
+		})
+	mockRepo.EXPECT().UpdateDelivery(gomock.Any(), copied).Return(nil)
+
+	delivery, err := service.Redeliver(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if delivery.Id != 9 || delivery.Status != webhook.DeliverySucceeded {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+}
+
+// =================================================================================================
+// Handler Tests
+// =================================================================================================
+
+func TestHandler_RequiresAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := webhook.NewHandler(mocks.NewMockService(ctrl))
+
+	req := httptest.NewRequest("GET", "/webhooks", nil)
+	w := httptest.NewRecorder()
+	handler.FindEndpoints(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status Unauthorized, got %v", w.Code)
+	}
+
+	req = addUserToContext(httptest.NewRequest("GET", "/webhooks", nil), &auth.User{ID: 2})
+	w = httptest.NewRecorder()
+	handler.FindEndpoints(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status Forbidden, got %v", w.Code)
+	}
+}
+
+func TestHandler_CreateEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := webhook.NewHandler(mockService)
+
+	events := []webhook.EventType{webhook.EventResourcePublished}
+	mockService.EXPECT().
+		CreateEndpoint(gomock.Any(), "https://example.com/hook", events, int64(1)).
+		Return(createMockEndpoint("https://example.com/hook"), nil)
+
+	body := `{"url":"https://example.com/hook","events":["resource.published"]}`
+	req := addUserToContext(httptest.NewRequest("POST", "/webhooks", strings.NewReader(body)), &auth.User{ID: 1, IsAdmin: true})
+	w := httptest.NewRecorder()
+	handler.CreateEndpoint(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status Created, got %v", w.Code)
+	}
+
+	var response webhook.EndpointResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Secret != "secret" {
+		t.Errorf("expected the secret to be returned on creation, got %q", response.Secret)
+	}
+}
+
+func TestHandler_CreateEndpoint_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := webhook.NewHandler(mockService)
+
+	mockService.EXPECT().
+		CreateEndpoint(gomock.Any(), "/hook", gomock.Any(), int64(1)).
+		Return(nil, webhook.ErrInvalidEndpoint)
+
+	req := addUserToContext(httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url":"/hook"}`)), &auth.User{ID: 1, IsAdmin: true})
+	w := httptest.NewRecorder()
+	handler.CreateEndpoint(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+}
+
+func TestHandler_FindDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := webhook.NewHandler(mockService)
+
+	mockService.EXPECT().
+		FindDeliveries(gomock.Any(), &webhook.FindDeliveriesParams{EndpointId: 1, Status: webhook.DeliveryFailed, Limit: 10}).
+		Return([]webhook.Delivery{*createMockDelivery()}, nil)
+	mockService.EXPECT().
+		FindDeliveries(gomock.Any(), gomock.Any()).
+		Return(nil, webhook.ErrNotFound)
+
+	admin := &auth.User{ID: 1, IsAdmin: true}
+
+	req := addUserToContext(httptest.NewRequest("GET", "/webhooks/1/deliveries?status=failed&limit=10", nil), admin)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	handler.FindDeliveries(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status OK, got %v", w.Code)
+	}
+
+	req = addUserToContext(httptest.NewRequest("GET", "/webhooks/2/deliveries", nil), admin)
+	req.SetPathValue("id", "2")
+	w = httptest.NewRecorder()
+	handler.FindDeliveries(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status Not Found, got %v", w.Code)
+	}
+
+	req = addUserToContext(httptest.NewRequest("GET", "/webhooks/1/deliveries?status=lost", nil), admin)
+	req.SetPathValue("id", "1")
+	w = httptest.NewRecorder()
+	handler.FindDeliveries(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request, got %v", w.Code)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/lib/pq"
@@ -20,12 +21,16 @@ import (
 	"github.com/mimsy-cms/mimsy/internal/collection"
 	"github.com/mimsy-cms/mimsy/internal/config"
 	"github.com/mimsy-cms/mimsy/internal/cron"
+	"github.com/mimsy-cms/mimsy/internal/feed"
+	"github.com/mimsy-cms/mimsy/internal/graphql"
 	"github.com/mimsy-cms/mimsy/internal/logger"
 	"github.com/mimsy-cms/mimsy/internal/media"
 	"github.com/mimsy-cms/mimsy/internal/migrations"
+	"github.com/mimsy-cms/mimsy/internal/openapi"
 	"github.com/mimsy-cms/mimsy/internal/storage"
 	"github.com/mimsy-cms/mimsy/internal/sync"
 	"github.com/mimsy-cms/mimsy/internal/util"
+	"github.com/mimsy-cms/mimsy/internal/webhook"
 )
 
 func main() {
@@ -73,7 +78,35 @@ func main() {
 	cronService := initCron(db)
 	collectionRepository := collection.NewRepository()
 
-	initSync(db, cronService)
+	feedRepository := feed.NewRepository()
+	feedService := feed.NewService(feedRepository)
+
+	webhookRepository := webhook.NewRepository()
+	webhookService := webhook.NewService(webhookRepository)
+	webhookHandler := webhook.NewHandler(webhookService)
+
+	mediaRepository := media.NewRepository()
+	mediaService := media.NewService(storage, mediaRepository, media.WithUploadHook(webhook.OnMediaUploaded(webhookService)))
+	mediaHandler := media.NewHandler(mediaService)
+
+	collectionService := collection.NewService(
+		collectionRepository,
+		collection.WithMediaService(mediaService),
+		collection.WithUserService(authService),
+		collection.WithMaxPopulateDepth(getMaxPopulateDepth()),
+		collection.WithEventHook(webhook.OnResourceEvent(webhookService)),
+		collection.WithEventHook(feed.OnResourceEvent(feedService)),
+	)
+	collectionHandler := collection.NewHandler(collectionService)
+	feedHandler := feed.NewHandler(feedService, collectionService)
+	graphqlHandler := graphql.NewHandler(collectionService)
+	openapiHandler := openapi.NewHandler(collectionService)
+
+	initSync(db, cronService,
+		sync.OnCollectionsUpdated(graphqlHandler.Rebuild),
+		sync.OnCollectionsUpdated(openapiHandler.Rebuild),
+		sync.OnSyncFinished(webhook.OnSyncFinished(webhookService)),
+	)
 	syncHandler := sync.NewHandler(cronService)
 
 	// Start the cron scheduler
@@ -87,12 +120,28 @@ func main() {
 		return
 	}
 
-	collectionService := collection.NewService(collectionRepository)
-	collectionHandler := collection.NewHandler(collectionService)
+	if err := collection.RegisterTrashJobs(cronService, db, collectionService, getTrashRetention()); err != nil {
+		slog.Error("Failed to register trash jobs", "error", err)
+	}
 
-	mediaRepository := media.NewRepository()
-	mediaService := media.NewService(storage, mediaRepository)
-	mediaHandler := media.NewHandler(mediaService)
+	if err := collection.RegisterScheduleJobs(cronService, db, collectionService); err != nil {
+		slog.Error("Failed to register schedule jobs", "error", err)
+	}
+
+	if err := feed.RegisterFeedJobs(cronService, db, feedService, feed.DefaultRetention); err != nil {
+		slog.Error("Failed to register feed jobs", "error", err)
+	}
+
+	listener, err := feed.Listen(config.ContextWithDB(ctx, db), getPgURL()+"&search_path=mimsy_internal,mimsy_collections", feedService)
+	if err != nil {
+		slog.Error("Failed to listen to resource events", "error", err)
+		return
+	}
+	defer listener.Close()
+
+	if err := webhook.RegisterDeliveryJobs(cronService, db, webhookService); err != nil {
+		slog.Error("Failed to register webhook delivery jobs", "error", err)
+	}
 
 	mux := http.NewServeMux()
 	v1 := http.NewServeMux()
@@ -105,23 +154,49 @@ func main() {
 	v1.HandleFunc("POST /auth/register", authHandler.Register)
 	v1.HandleFunc("GET /auth/me", authHandler.Me)
 	v1.HandleFunc("GET /collections", collectionHandler.FindAll)
-	v1.HandleFunc("GET /globals/{slug}", collectionHandler.GetResource)
+	v1.HandleFunc("GET /globals", collectionHandler.FindAllGlobals)
+	v1.HandleFunc("GET /globals/{slug}", collectionHandler.GetGlobal)
+	v1.HandleFunc("PUT /globals/{slug}", collectionHandler.UpdateGlobal)
 	v1.HandleFunc("GET /collections/{slug}", collectionHandler.GetResources)
 	v1.HandleFunc("GET /collections/{slug}/{resourceSlug}", collectionHandler.GetResource)
 	v1.HandleFunc("PUT /collections/{slug}/{resourceSlug}", collectionHandler.UpdateResource)
 	v1.HandleFunc("POST /collections/{slug}", collectionHandler.CreateResource)
 	v1.HandleFunc("GET /collections/{slug}/definition", collectionHandler.Definition)
 	v1.HandleFunc("DELETE /collections/{slug}/{resourceSlug}", collectionHandler.DeleteResource)
+	v1.HandleFunc("POST /collections/{slug}/{resourceSlug}/publish", collectionHandler.PublishResource)
+	v1.HandleFunc("POST /collections/{slug}/{resourceSlug}/unpublish", collectionHandler.UnpublishResource)
+	v1.HandleFunc("PUT /collections/{slug}/{resourceSlug}/schedule", collectionHandler.ScheduleResource)
+	v1.HandleFunc("GET /collections/{slug}/{resourceSlug}/revisions", collectionHandler.GetRevisions)
+	v1.HandleFunc("GET /collections/{slug}/{resourceSlug}/revisions/diff", collectionHandler.DiffRevisions)
+	v1.HandleFunc("POST /collections/{slug}/{resourceSlug}/revisions/{id}/restore", collectionHandler.RestoreRevision)
+	v1.HandleFunc("GET /collections/{slug}/{resourceSlug}/references", collectionHandler.GetReferences)
+	v1.HandleFunc("GET /collections/{slug}/aggregate", collectionHandler.AggregateResources)
+	v1.HandleFunc("GET /collections/{slug}/export", collectionHandler.ExportResources)
+	v1.HandleFunc("POST /collections/{slug}/import", collectionHandler.ImportResources)
+	v1.HandleFunc("GET /collections/{slug}/trash", collectionHandler.GetTrash)
+	v1.HandleFunc("POST /collections/{slug}/trash/{resourceSlug}/restore", collectionHandler.RestoreResource)
+	v1.HandleFunc("DELETE /collections/{slug}/trash/{resourceSlug}", collectionHandler.PurgeResource)
 	v1.HandleFunc("GET /collections/globals", collectionHandler.FindAllGlobals)
+	v1.HandleFunc("POST /batch", collectionHandler.Batch)
+	v1.HandleFunc("GET /locales", collectionHandler.FindLocales)
 	v1.HandleFunc("POST /media", mediaHandler.Upload)
 	v1.HandleFunc("GET /media", mediaHandler.FindAll)
 	v1.HandleFunc("GET /media/{id}", mediaHandler.GetById)
 	v1.HandleFunc("DELETE /media/{id}", mediaHandler.Delete)
 	v1.HandleFunc("GET /users", authHandler.GetUsers)
 	v1.HandleFunc("GET /users/{id}", authHandler.FindUser)
+	v1.HandleFunc("GET /events", feedHandler.Stream)
+	v1.HandleFunc("GET /webhooks", webhookHandler.FindEndpoints)
+	v1.HandleFunc("POST /webhooks", webhookHandler.CreateEndpoint)
+	v1.HandleFunc("DELETE /webhooks/{id}", webhookHandler.DeleteEndpoint)
+	v1.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandler.FindDeliveries)
+	v1.HandleFunc("POST /webhooks/deliveries/{id}/redeliver", webhookHandler.Redeliver)
 	v1.HandleFunc("GET /sync/status", syncHandler.Status)
 	v1.HandleFunc("GET /sync/jobs", syncHandler.Jobs)
 	v1.HandleFunc("GET /sync/active-migration", syncHandler.ActiveMigration)
+	v1.HandleFunc("GET /graphql", graphqlHandler.Query)
+	v1.HandleFunc("POST /graphql", graphqlHandler.Query)
+	v1.HandleFunc("GET /openapi.json", openapiHandler.Get)
 
 	handler := util.ApplyMiddlewares(
 		util.RequestLoggerMiddleware(),
@@ -203,7 +278,7 @@ func initCron(db *sql.DB) cron.CronService {
 	return cronService
 }
 
-func initSync(db *sql.DB, cronService cron.CronService) sync.SyncProvider {
+func initSync(db *sql.DB, cronService cron.CronService, options ...sync.MigratorOption) sync.SyncProvider {
 	slog.Info("Initializing sync service")
 
 	var pemKey string
@@ -235,6 +310,7 @@ func initSync(db *sql.DB, cronService cron.CronService) sync.SyncProvider {
 		string(pemKey),
 		appId,
 		os.Getenv("GH_REPO"),
+		options...,
 	)
 	if err != nil {
 		slog.Error("Failed to initialize sync service", "error", err)
@@ -316,6 +392,26 @@ func runMigrations(ctx context.Context, m *roll.Roll) (int, error) {
 	return migrationCount, nil
 }
 
+// getMaxPopulateDepth returns the maximum number of nested relations that can be
+// populated on resource reads, from the POPULATE_MAX_DEPTH environment variable.
+func getMaxPopulateDepth() int {
+	depth, err := strconv.Atoi(os.Getenv("POPULATE_MAX_DEPTH"))
+	if err != nil || depth < 1 {
+		return collection.DefaultMaxPopulateDepth
+	}
+	return depth
+}
+
+// getTrashRetention returns how long deleted resources are kept in the trash before
+// being purged, from the TRASH_RETENTION environment variable.
+func getTrashRetention() time.Duration {
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil || retention <= 0 {
+		return collection.DefaultTrashRetention
+	}
+	return retention
+}
+
 func getPgURL() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
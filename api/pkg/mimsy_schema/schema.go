@@ -1,6 +1,8 @@
 package mimsy_schema
 
 import (
+	"fmt"
+	"slices"
 	"time"
 )
 
@@ -9,6 +11,10 @@ type CollectionFields map[string]SchemaElement
 type MimsyConfig struct {
 	SchemaPath string `json:"manifestPath"`
 	BasePath   string `json:"basePath"`
+	// Locales are the codes of the languages in which localized fields can be written (`en`, `fr-CA`).
+	Locales []string `json:"locales,omitempty"`
+	// DefaultLocale is the locale required for localized fields, the first locale when empty.
+	DefaultLocale string `json:"defaultLocale,omitempty"`
 }
 
 type Schema struct {
@@ -23,20 +29,49 @@ type Collection struct {
 }
 
 type SchemaElement struct {
-	Type      string                `json:"type"`
-	RelatesTo string                `json:"relatesTo,omitempty"`
-	Options   *SchemaElementOptions `json:"options,omitempty"`
+	Type      string `json:"type"`
+	RelatesTo string `json:"relatesTo,omitempty"`
+	// OnDelete is what happens to the resources of a relation field when the related resource is purged.
+	OnDelete OnDeleteAction        `json:"onDelete,omitempty"`
+	Options  *SchemaElementOptions `json:"options,omitempty"`
 }
 
+// OnDeleteAction is the behaviour of a relation when the related resource is purged from the trash.
+// Moving a resource to the trash keeps the relations to it, so that it can be restored with them,
+// only OnDeleteRestrict is checked at that point.
+// Without an action, purging fails as long as the resource is referenced.
+type OnDeleteAction string
+
+const (
+	// OnDeleteCascade purges the referencing resources, or only the links of a many-to-many relation.
+	OnDeleteCascade OnDeleteAction = "cascade"
+	// OnDeleteRestrict prevents the deletion of a referenced resource, even when moving it to the trash.
+	OnDeleteRestrict OnDeleteAction = "restrict"
+	// OnDeleteSetNull clears the relation of the referencing resources, it requires an optional many-to-one relation.
+	OnDeleteSetNull OnDeleteAction = "setNull"
+)
+
 type SchemaElementOptions struct {
 	Description string                    `json:"description,omitempty"`
 	Constraints *SchemaElementConstraints `json:"constraints,omitempty"`
+	// Localized fields store a value for each locale of the project, a field cannot become localized once created.
+	Localized bool `json:"localized,omitempty"`
+	// Values are the allowed values of a select field. A value can only be removed once no resource holds it anymore.
+	Values []string `json:"values,omitempty"`
+	// Multiple select fields hold a list of values rather than a single one.
+	Multiple bool `json:"multiple,omitempty"`
+	// Blocks are the fields of each block type of a blocks field, they can hold nested blocks.
+	Blocks map[string]CollectionFields `json:"blocks,omitempty"`
 }
 
 type SchemaElementConstraints struct {
 	Required  bool `json:"required,omitempty"`
 	MinLength int  `json:"minLength,omitempty"`
 	MaxLength int  `json:"maxLength,omitempty"`
+	// Unique fields cannot hold the same value in two resources, trashed resources included.
+	Unique bool `json:"unique,omitempty"`
+	// Index adds an index on the column of the field, for the fields frequently filtered or sorted on.
+	Index bool `json:"index,omitempty"`
 }
 
 // Helper methods for SchemaElement
@@ -54,6 +89,48 @@ func (se *SchemaElement) IsRequired() bool {
 	return false
 }
 
+// IsUnique returns true if the schema element has a unique constraint
+func (se *SchemaElement) IsUnique() bool {
+	if se.Options != nil && se.Options.Constraints != nil {
+		return se.Options.Constraints.Unique
+	}
+	return false
+}
+
+// IsIndexed returns true if the schema element has an index constraint
+func (se *SchemaElement) IsIndexed() bool {
+	if se.Options != nil && se.Options.Constraints != nil {
+		return se.Options.Constraints.Index
+	}
+	return false
+}
+
+// IsLocalized returns true if the schema element stores a value for each locale
+func (se *SchemaElement) IsLocalized() bool {
+	return se.Options != nil && se.Options.Localized
+}
+
+// GetValues returns the allowed values of a select field
+func (se *SchemaElement) GetValues() []string {
+	if se.Options != nil {
+		return se.Options.Values
+	}
+	return nil
+}
+
+// IsMultiple returns true if the select field holds a list of values
+func (se *SchemaElement) IsMultiple() bool {
+	return se.Options != nil && se.Options.Multiple
+}
+
+// GetBlocks returns the fields of each block type of a blocks field
+func (se *SchemaElement) GetBlocks() map[string]CollectionFields {
+	if se.Options != nil {
+		return se.Options.Blocks
+	}
+	return nil
+}
+
 // GetDescription returns the description of the schema element
 func (se *SchemaElement) GetDescription() string {
 	if se.Options != nil {
@@ -62,6 +139,32 @@ func (se *SchemaElement) GetDescription() string {
 	return ""
 }
 
+// Helper methods for MimsyConfig
+
+// GetDefaultLocale returns the default locale, or an empty string when no locale is declared
+func (c *MimsyConfig) GetDefaultLocale() string {
+	if c.DefaultLocale != "" {
+		return c.DefaultLocale
+	}
+	if len(c.Locales) > 0 {
+		return c.Locales[0]
+	}
+	return ""
+}
+
+// ValidateLocales returns an error when the default locale is not one of the locales, or when a locale is declared twice
+func (c *MimsyConfig) ValidateLocales() error {
+	for i, locale := range c.Locales {
+		if slices.Contains(c.Locales[:i], locale) {
+			return fmt.Errorf("locale %q is declared twice", locale)
+		}
+	}
+	if c.DefaultLocale != "" && !slices.Contains(c.Locales, c.DefaultLocale) {
+		return fmt.Errorf("default locale %q is not one of the locales", c.DefaultLocale)
+	}
+	return nil
+}
+
 // Helper methods for Schema
 
 // GetCollection returns a collection by name, or nil if not found
@@ -278,3 +278,27 @@ func TestCollectionWithEmptySchema(t *testing.T) {
 		t.Errorf("Expected 0 fields in empty collection, got %d", len(emptyCollection.Schema))
 	}
 }
+
+func TestValidateLocales(t *testing.T) {
+	valid := []mimsy_schema.MimsyConfig{
+		{},
+		{Locales: []string{"en", "fr"}},
+		{Locales: []string{"en", "fr"}, DefaultLocale: "fr"},
+	}
+	for _, config := range valid {
+		if err := config.ValidateLocales(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", config, err)
+		}
+	}
+
+	invalid := []mimsy_schema.MimsyConfig{
+		{Locales: []string{"en", "fr"}, DefaultLocale: "de"},
+		{DefaultLocale: "en"},
+		{Locales: []string{"en", "en"}},
+	}
+	for _, config := range invalid {
+		if err := config.ValidateLocales(); err == nil {
+			t.Errorf("expected an error for %+v", config)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package schema_diff
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
@@ -14,13 +16,60 @@ func Diff(oldSchema schema_generator.SqlSchema, newSchema schema_generator.SqlSc
 
 	operations = append(operations, processTableChanges(oldSchema, newSchema)...)
 	operations = append(operations, processConstraintChanges(oldSchema, newSchema)...)
+	operations = append(operations, processIndexChanges(oldSchema, newSchema)...)
 	operations = append(operations, processDroppedTables(oldSchema, newSchema)...)
 	operations = append(operations, processDroppedColumns(oldSchema, newSchema)...)
 	operations = append(operations, processDroppedConstraints(oldSchema, newSchema)...)
+	operations = append(operations, processDroppedIndexes(oldSchema, newSchema)...)
 
 	return operations
 }
 
+// ColumnChangeError is returned for a column whose type changed, it tells how to make the change without altering it.
+type ColumnChangeError struct {
+	Table  string
+	Column string
+	From   string
+	To     string
+}
+
+func (e *ColumnChangeError) Error() string {
+	switch {
+	case e.To == "jsonb":
+		// Localized values, as rich text, are stored in a jsonb column
+		return fmt.Sprintf("field %s of %s cannot become localized once created: add a localized field with another name, "+
+			"copy the values to its default locale, then remove %s", e.Column, e.Table, e.Column)
+	case e.From == "jsonb":
+		return fmt.Sprintf("field %s of %s cannot stop being localized once created: add a field with another name, "+
+			"copy the values of the default locale to it, then remove %s", e.Column, e.Table, e.Column)
+	default:
+		return fmt.Sprintf("field %s of %s cannot change from %s to %s once created: add a field with another name, "+
+			"copy the values to it, then remove %s", e.Column, e.Table, e.From, e.To, e.Column)
+	}
+}
+
+// CheckColumnChanges returns an error listing the columns whose type changed between the schemas,
+// such as a field becoming localized or a select allowing multiple values.
+// Columns cannot be altered yet, so the values of the new type would be written in a column of the old type.
+// Each change is reported as a ColumnChangeError.
+func CheckColumnChanges(oldSchema schema_generator.SqlSchema, newSchema schema_generator.SqlSchema) error {
+	var errs []error
+	for _, table := range newSchema.Tables {
+		oldTable, exists := oldSchema.GetTable(table.Name)
+		if !exists {
+			continue
+		}
+
+		for _, column := range table.Columns {
+			if oldColumn, exists := oldTable.GetColumn(column.Name); exists && oldColumn.Type != column.Type {
+				errs = append(errs, &ColumnChangeError{Table: table.Name, Column: column.Name, From: oldColumn.Type, To: column.Type})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func processTableChanges(oldSchema, newSchema schema_generator.SqlSchema) []migrations.Operation {
 	operations := []migrations.Operation{}
 
@@ -111,10 +160,18 @@ func createTableConstraint(constraint schema_generator.Constraint) *migrations.C
 			Type:    migrations.ConstraintTypeForeignKey,
 			Columns: []string{c.Column},
 			References: &migrations.TableForeignKeyReference{
-				Table:   refTable,
-				Columns: []string{c.ReferenceColumn},
+				Table:    refTable,
+				Columns:  []string{c.ReferenceColumn},
+				OnDelete: migrations.ForeignKeyAction(c.OnDelete),
 			},
 		}
+	case *schema_generator.CheckConstraint:
+		return &migrations.Constraint{
+			Name:    c.Name(),
+			Type:    migrations.ConstraintTypeCheck,
+			Columns: []string{c.Column},
+			Check:   c.Expression(),
+		}
 	default:
 		return nil
 	}
@@ -294,9 +351,28 @@ func createConstraintOperation(tableName string, constraint schema_generator.Con
 			Table:   tableName,
 			Columns: []string{c.Column},
 			References: &migrations.TableForeignKeyReference{
-				Table:   refTable,
-				Columns: []string{c.ReferenceColumn},
+				Table:    refTable,
+				Columns:  []string{c.ReferenceColumn},
+				OnDelete: migrations.ForeignKeyAction(c.OnDelete),
+			},
+			Up: map[string]string{
+				c.Column: c.Column,
+			},
+			Down: map[string]string{
+				c.Column: c.Column,
 			},
+		}
+	case *schema_generator.CheckConstraint:
+		// The values of a select field changed. The existing values are kept as they are: rows still holding
+		// a removed value violate the new constraint when they are copied, which fails the migration, so that
+		// no value is lost silently. The previous constraint is dropped with the other removed constraints.
+		check := c.Expression()
+		return &migrations.OpCreateConstraint{
+			Type:    migrations.OpCreateConstraintTypeCheck,
+			Name:    c.Name(),
+			Table:   tableName,
+			Columns: []string{c.Column},
+			Check:   &check,
 			Up: map[string]string{
 				c.Column: c.Column,
 			},
@@ -309,6 +385,40 @@ func createConstraintOperation(tableName string, constraint schema_generator.Con
 	}
 }
 
+// processIndexChanges creates the indexes added to the tables, including the indexes of the new tables
+// as pgroll creates tables without indexes.
+func processIndexChanges(oldSchema, newSchema schema_generator.SqlSchema) []migrations.Operation {
+	operations := []migrations.Operation{}
+
+	for _, table := range newSchema.Tables {
+		oldTable, exists := oldSchema.GetTable(table.Name)
+
+		for _, index := range table.Indexes {
+			if exists {
+				if _, indexExists := oldTable.GetIndex(index.Name()); indexExists {
+					continue
+				}
+			}
+			operations = append(operations, createIndexOperation(index))
+		}
+	}
+
+	return operations
+}
+
+func createIndexOperation(index schema_generator.Index) migrations.Operation {
+	columns := migrations.OpCreateIndexColumns{}
+	for _, column := range index.Columns {
+		columns[column] = migrations.IndexField{}
+	}
+
+	return &migrations.OpCreateIndex{
+		Name:    index.Name(),
+		Table:   index.Table,
+		Columns: columns,
+	}
+}
+
 func processDroppedConstraints(oldSchema, newSchema schema_generator.SqlSchema) []migrations.Operation {
 	operations := []migrations.Operation{}
 
@@ -320,6 +430,18 @@ func processDroppedConstraints(oldSchema, newSchema schema_generator.SqlSchema)
 		}
 
 		for _, constraint := range oldTable.Constraints {
+			// The check and unique constraints of a dropped column are dropped with it
+			var column string
+			switch c := constraint.(type) {
+			case *schema_generator.CheckConstraint:
+				column = c.Column
+			case *schema_generator.UniqueConstraint:
+				column = c.Key
+			}
+			if _, exists := newTable.GetColumn(column); column != "" && !exists {
+				continue
+			}
+
 			if !constraintExists(newTable.Constraints, constraint) {
 				operation := createDropConstraintOperation(oldTable.Name, constraint)
 				if operation != nil {
@@ -356,8 +478,49 @@ func createDropConstraintOperation(tableName string, constraint schema_generator
 				c.Column: c.Column,
 			},
 		}
+	case *schema_generator.CheckConstraint:
+		return &migrations.OpDropMultiColumnConstraint{
+			Name:  c.Name(),
+			Table: tableName,
+			Up: map[string]string{
+				c.Column: c.Column,
+			},
+			Down: map[string]string{
+				c.Column: c.Column,
+			},
+		}
 	default:
 		// Primary key constraints cannot be dropped easily, skip for now
 		return nil
 	}
 }
+
+func processDroppedIndexes(oldSchema, newSchema schema_generator.SqlSchema) []migrations.Operation {
+	operations := []migrations.Operation{}
+
+	for _, oldTable := range oldSchema.Tables {
+		newTable, exists := newSchema.GetTable(oldTable.Name)
+		if !exists {
+			// The indexes of a dropped table are dropped with it
+			continue
+		}
+
+		for _, index := range oldTable.Indexes {
+			if _, exists := newTable.GetIndex(index.Name()); exists {
+				continue
+			}
+
+			// The index of a dropped column is dropped with it
+			if slices.ContainsFunc(index.Columns, func(column string) bool {
+				_, exists := newTable.GetColumn(column)
+				return !exists
+			}) {
+				continue
+			}
+
+			operations = append(operations, &migrations.OpDropIndex{Name: index.Name()})
+		}
+	}
+
+	return operations
+}
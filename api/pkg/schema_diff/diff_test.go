@@ -1,8 +1,11 @@
 package schema_diff_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
 	"github.com/mimsy-cms/mimsy/pkg/schema_diff"
 	"github.com/mimsy-cms/mimsy/pkg/schema_generator"
 	"github.com/xataio/pgroll/pkg/migrations"
@@ -117,6 +120,58 @@ func TestDiffColumnTypeChange(t *testing.T) {
 	}
 }
 
+func TestCheckColumnChanges(t *testing.T) {
+	generate := func(fields map[string]mimsy_schema.SchemaElement) schema_generator.SqlSchema {
+		t.Helper()
+		sqlSchema, err := schema_generator.New().GenerateSqlSchema(&mimsy_schema.Schema{
+			Collections: []mimsy_schema.Collection{{Name: "posts", Schema: fields}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sqlSchema
+	}
+
+	title := mimsy_schema.SchemaElement{Type: "string"}
+	localizedTitle := mimsy_schema.SchemaElement{Type: "string", Options: &mimsy_schema.SchemaElementOptions{Localized: true}}
+	category := mimsy_schema.SchemaElement{Type: "select", Options: &mimsy_schema.SchemaElementOptions{Values: []string{"news", "blog"}}}
+	categories := mimsy_schema.SchemaElement{Type: "select", Options: &mimsy_schema.SchemaElementOptions{Values: []string{"news", "blog"}, Multiple: true}}
+
+	tests := []struct {
+		name     string
+		old, new map[string]mimsy_schema.SchemaElement
+		message  string
+	}{
+		{name: "localized", old: map[string]mimsy_schema.SchemaElement{"title": title}, new: map[string]mimsy_schema.SchemaElement{"title": localizedTitle}, message: "cannot become localized"},
+		{name: "not localized", old: map[string]mimsy_schema.SchemaElement{"title": localizedTitle}, new: map[string]mimsy_schema.SchemaElement{"title": title}, message: "cannot stop being localized"},
+		{name: "multiple", old: map[string]mimsy_schema.SchemaElement{"category": category}, new: map[string]mimsy_schema.SchemaElement{"category": categories}, message: "cannot change from varchar to text[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema_diff.CheckColumnChanges(generate(tt.old), generate(tt.new))
+
+			var changeErr *schema_diff.ColumnChangeError
+			if !errors.As(err, &changeErr) {
+				t.Fatalf("expected a column change error, got %v", err)
+			}
+			if changeErr.Table != "posts" || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected the error to tell that the field %s, got %v", tt.message, err)
+			}
+		})
+	}
+
+	// Adding a field and changing its constraints do not change the type of the existing columns
+	old := generate(map[string]mimsy_schema.SchemaElement{"title": title})
+	updated := generate(map[string]mimsy_schema.SchemaElement{
+		"title":    {Type: "string", Options: &mimsy_schema.SchemaElementOptions{Constraints: &mimsy_schema.SchemaElementConstraints{Required: true}}},
+		"category": category,
+	})
+	if err := schema_diff.CheckColumnChanges(old, updated); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestDiffColumnAdded(t *testing.T) {
 	oldSchema := schema_generator.SqlSchema{
 		Tables: []*schema_generator.Table{
@@ -498,6 +553,138 @@ func TestDiffForeignKeyConstraintAdded(t *testing.T) {
 	}
 }
 
+func TestDiffForeignKeyOnDeleteChanged(t *testing.T) {
+	schemaWithAction := func(action string) schema_generator.SqlSchema {
+		return schema_generator.SqlSchema{
+			Tables: []*schema_generator.Table{
+				{
+					Name: "posts",
+					Columns: []schema_generator.Column{
+						{Name: "id", Type: "bigint", IsPrimaryKey: true, IsNotNull: true},
+						{Name: "user_id", Type: "bigint"},
+					},
+					Constraints: []schema_generator.Constraint{
+						&schema_generator.ForeignKeyConstraint{
+							Table:           "posts",
+							Column:          "user_id",
+							ReferenceTable:  "users",
+							ReferenceColumn: "id",
+							OnDelete:        action,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	diff := schema_diff.Diff(schemaWithAction(""), schemaWithAction("SET NULL"))
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 operations (replace foreign key constraint), got %d", len(diff))
+	}
+
+	if op, ok := diff[0].(*migrations.OpCreateConstraint); ok {
+		if op.Name != "fk__posts__user_id__users__set_null" || op.References == nil || op.References.OnDelete != migrations.ForeignKeyActionSETNULL {
+			t.Errorf("expected a foreign key constraint setting null on delete, got %s %v", op.Name, op.References)
+		}
+	} else {
+		t.Errorf("expected operation to be OpCreateConstraint, got %T", diff[0])
+	}
+
+	if op, ok := diff[1].(*migrations.OpDropMultiColumnConstraint); ok {
+		if op.Name != "fk__posts__user_id__users" {
+			t.Errorf("expected the previous constraint to be dropped, got %s", op.Name)
+		}
+	} else {
+		t.Errorf("expected operation to be OpDropMultiColumnConstraint, got %T", diff[1])
+	}
+
+	if diff := schema_diff.Diff(schemaWithAction("CASCADE"), schemaWithAction("CASCADE")); len(diff) != 0 {
+		t.Errorf("expected no operations when the action is unchanged, got %d", len(diff))
+	}
+}
+
+func TestDiffSelectValuesChanged(t *testing.T) {
+	schemaWithValues := func(isNotNull bool, multiple bool, values ...string) schema_generator.SqlSchema {
+		columnType := "varchar"
+		if multiple {
+			columnType = "text[]"
+		}
+
+		return schema_generator.SqlSchema{
+			Tables: []*schema_generator.Table{
+				{
+					Name: "posts",
+					Columns: []schema_generator.Column{
+						{Name: "id", Type: "bigint", IsPrimaryKey: true, IsNotNull: true},
+						{Name: "category", Type: columnType, IsNotNull: isNotNull},
+					},
+					Constraints: []schema_generator.Constraint{
+						&schema_generator.CheckConstraint{Table: "posts", Column: "category", Values: values, Multiple: multiple},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		isNotNull bool
+		multiple  bool
+	}{
+		{name: "optional"},
+		{name: "required", isNotNull: true},
+		{name: "multiple", multiple: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldSchema := schemaWithValues(tt.isNotNull, tt.multiple, "news", "opinion")
+			newSchema := schemaWithValues(tt.isNotNull, tt.multiple, "news")
+
+			diff := schema_diff.Diff(oldSchema, newSchema)
+			if len(diff) != 2 {
+				t.Fatalf("expected 2 operations (replace check constraint), got %d", len(diff))
+			}
+
+			if op, ok := diff[0].(*migrations.OpCreateConstraint); ok {
+				if op.Type != migrations.OpCreateConstraintTypeCheck || op.Check == nil || op.Name != newSchema.Tables[0].Constraints[0].Name() {
+					t.Errorf("expected the new check constraint, got %s %s", op.Type, op.Name)
+				}
+				// The removed values are not coerced, the rows still holding them fail the migration
+				if op.Up["category"] != "category" {
+					t.Errorf("expected the values to be kept, got %s", op.Up["category"])
+				}
+			} else {
+				t.Errorf("expected operation to be OpCreateConstraint, got %T", diff[0])
+			}
+
+			if op, ok := diff[1].(*migrations.OpDropMultiColumnConstraint); ok {
+				if op.Name != oldSchema.Tables[0].Constraints[0].Name() {
+					t.Errorf("expected the previous constraint to be dropped, got %s", op.Name)
+				}
+			} else {
+				t.Errorf("expected operation to be OpDropMultiColumnConstraint, got %T", diff[1])
+			}
+		})
+	}
+
+	if diff := schema_diff.Diff(schemaWithValues(false, false, "a", "b"), schemaWithValues(false, false, "b", "a")); len(diff) != 0 {
+		t.Errorf("expected no operations when the values are reordered, got %d", len(diff))
+	}
+
+	// The constraint of a dropped column is dropped with it
+	dropped := schemaWithValues(false, false, "news")
+	dropped.Tables[0].Columns = dropped.Tables[0].Columns[:1]
+	dropped.Tables[0].Constraints = nil
+	diff := schema_diff.Diff(schemaWithValues(false, false, "news"), dropped)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 operation (drop column), got %d", len(diff))
+	}
+	if _, ok := diff[0].(*migrations.OpDropColumn); !ok {
+		t.Errorf("expected operation to be OpDropColumn, got %T", diff[0])
+	}
+}
+
 func TestDiffCompositePrimaryKeyConstraintAdded(t *testing.T) {
 	oldSchema := schema_generator.SqlSchema{
 		Tables: []*schema_generator.Table{
@@ -619,3 +806,88 @@ func TestDiffCreateTableWithConstraints(t *testing.T) {
 		t.Errorf("expected operation to be OpCreateTable, got %T", diff[0])
 	}
 }
+
+func TestDiffIndexes(t *testing.T) {
+	subscribers := func(columns []string, indexes ...string) *schema_generator.Table {
+		table := &schema_generator.Table{Name: "subscribers"}
+		for _, column := range columns {
+			table.Columns = append(table.Columns, schema_generator.Column{Name: column, Type: "varchar"})
+		}
+		for _, column := range indexes {
+			table.Indexes = append(table.Indexes, schema_generator.Index{Table: "subscribers", Columns: []string{column}})
+		}
+		return table
+	}
+
+	oldSchema := schema_generator.SqlSchema{
+		Tables: []*schema_generator.Table{subscribers([]string{"id", "email", "country", "city"}, "country", "city")},
+	}
+	newSchema := schema_generator.SqlSchema{
+		Tables: []*schema_generator.Table{
+			subscribers([]string{"id", "email", "country"}, "email"),
+			{
+				Name:    "lists",
+				Columns: []schema_generator.Column{{Name: "id", Type: "bigint"}, {Name: "name", Type: "varchar"}},
+				Indexes: []schema_generator.Index{{Table: "lists", Columns: []string{"name"}}},
+			},
+		},
+	}
+
+	diff := schema_diff.Diff(oldSchema, newSchema)
+	if len(diff) != 5 {
+		t.Fatalf("expected 5 operations (create table, 2 create index, drop column, drop index), got %d", len(diff))
+	}
+
+	if _, ok := diff[0].(*migrations.OpCreateTable); !ok {
+		t.Errorf("expected operation to be OpCreateTable, got %T", diff[0])
+	}
+
+	// The indexes of the new tables are created along with the indexes added to the existing tables
+	for i, expected := range []struct{ name, table, column string }{
+		{name: "ix__subscribers__email", table: "subscribers", column: "email"},
+		{name: "ix__lists__name", table: "lists", column: "name"},
+	} {
+		op, ok := diff[i+1].(*migrations.OpCreateIndex)
+		if !ok {
+			t.Errorf("expected operation to be OpCreateIndex, got %T", diff[i+1])
+			continue
+		}
+		if _, hasColumn := op.Columns[expected.column]; op.Name != expected.name || op.Table != expected.table || !hasColumn || len(op.Columns) != 1 {
+			t.Errorf("expected index %s on %s(%s), got %+v", expected.name, expected.table, expected.column, op)
+		}
+	}
+
+	if _, ok := diff[3].(*migrations.OpDropColumn); !ok {
+		t.Errorf("expected operation to be OpDropColumn, got %T", diff[3])
+	}
+
+	// The index of the dropped city column is dropped with it
+	if op, ok := diff[4].(*migrations.OpDropIndex); !ok || op.Name != "ix__subscribers__country" {
+		t.Errorf("expected the country index to be dropped, got %+v", diff[4])
+	}
+}
+
+func TestDiffUniqueConstraintOfDroppedColumn(t *testing.T) {
+	oldSchema := schema_generator.SqlSchema{
+		Tables: []*schema_generator.Table{
+			{
+				Name:        "subscribers",
+				Columns:     []schema_generator.Column{{Name: "id", Type: "bigint"}, {Name: "email", Type: "varchar"}},
+				Constraints: []schema_generator.Constraint{&schema_generator.UniqueConstraint{Table: "subscribers", Key: "email"}},
+			},
+		},
+	}
+	newSchema := schema_generator.SqlSchema{
+		Tables: []*schema_generator.Table{
+			{Name: "subscribers", Columns: []schema_generator.Column{{Name: "id", Type: "bigint"}}},
+		},
+	}
+
+	diff := schema_diff.Diff(oldSchema, newSchema)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 operation (drop column), got %d", len(diff))
+	}
+	if _, ok := diff[0].(*migrations.OpDropColumn); !ok {
+		t.Errorf("expected operation to be OpDropColumn, got %T", diff[0])
+	}
+}
@@ -2,9 +2,12 @@ package schema_generator
 
 import (
 	"fmt"
+	"hash/fnv"
+	"slices"
 	"strings"
 
 	"github.com/lib/pq"
+	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
 )
 
 type Constraint interface {
@@ -61,17 +64,98 @@ type ForeignKeyConstraint struct {
 	Column          string
 	ReferenceColumn string
 	ReferenceTable  string
+	// OnDelete is the SQL action taken when the referenced row is deleted, `NO ACTION` when empty.
+	OnDelete string `json:",omitempty"`
 }
 
+// The name depends on the action, so that a change of action replaces the constraint.
 func (f *ForeignKeyConstraint) Name() string {
-	return fmt.Sprintf("fk__%s__%s__%s", f.Table, f.Column, RemoveSchemaFromReference(f.ReferenceTable))
+	name := fmt.Sprintf("fk__%s__%s__%s", f.Table, f.Column, RemoveSchemaFromReference(f.ReferenceTable))
+	if f.OnDelete != "" {
+		name += "__" + strings.ReplaceAll(strings.ToLower(f.OnDelete), " ", "_")
+	}
+	return name
 }
 
 func (f *ForeignKeyConstraint) ToSql() string {
-	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
 		f.Name(),
 		pq.QuoteIdentifier(f.Column),
 		f.ReferenceTable,
 		pq.QuoteIdentifier(f.ReferenceColumn),
 	)
+
+	if f.OnDelete != "" {
+		sql += fmt.Sprintf(" ON DELETE %s", f.OnDelete)
+	}
+
+	return sql
+}
+
+// CheckConstraint restricts a select column to its allowed values, or each item of a multiple select column.
+type CheckConstraint struct {
+	Table    string
+	Column   string
+	Values   []string
+	Multiple bool `json:",omitempty"`
+}
+
+// The name depends on the values, so that adding or removing a value replaces the constraint.
+// They are sorted first, reordering the values of a field does not change the constraint.
+func (c *CheckConstraint) Name() string {
+	values := slices.Sorted(slices.Values(c.Values))
+
+	hash := fnv.New32a()
+	for _, value := range values {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	return fmt.Sprintf("ck__%s__%s__%08x", c.Table, c.Column, hash.Sum32())
+}
+
+// Expression returns the condition checked on the column, null values are accepted.
+func (c *CheckConstraint) Expression() string {
+	column := pq.QuoteIdentifier(c.Column)
+	if c.Multiple {
+		return fmt.Sprintf("%s <@ %s", column, c.ValuesArray())
+	}
+	return fmt.Sprintf("%s IN (%s)", column, c.quotedValues())
+}
+
+// ValuesArray returns the allowed values as a text[] expression.
+func (c *CheckConstraint) ValuesArray() string {
+	return fmt.Sprintf("ARRAY[%s]::text[]", c.quotedValues())
+}
+
+func (c *CheckConstraint) quotedValues() string {
+	values := make([]string, len(c.Values))
+	for i, value := range c.Values {
+		values[i] = pq.QuoteLiteral(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func (c *CheckConstraint) ToSql() string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", c.Name(), c.Expression())
+}
+
+// GetForeignKeyAction returns the SQL action of the onDelete option of a relation field.
+func GetForeignKeyAction(name string, element *mimsy_schema.SchemaElement) (string, error) {
+	switch element.OnDelete {
+	case "":
+		return "", nil
+	case mimsy_schema.OnDeleteCascade:
+		return "CASCADE", nil
+	case mimsy_schema.OnDeleteRestrict:
+		return "RESTRICT", nil
+	case mimsy_schema.OnDeleteSetNull:
+		// The links of a many-to-many relation are part of the primary key of the join table
+		if element.Type == "multi_relation" || element.IsRequired() {
+			return "", fmt.Errorf("field %q: onDelete setNull requires an optional many-to-one relation", name)
+		}
+		return "SET NULL", nil
+	default:
+		return "", fmt.Errorf("field %q: unknown onDelete action %q", name, element.OnDelete)
+	}
 }
@@ -2,7 +2,9 @@ package schema_generator
 
 import (
 	"fmt"
+	"maps"
 	"slices"
+	"strings"
 
 	"github.com/lib/pq"
 	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
@@ -75,6 +77,48 @@ func (s *schemaGenerator) GenerateUpdatedAtColumn() Column {
 	}
 }
 
+// GenerateStatusColumn returns the publication status of the resources, either `draft` or `published`.
+// The default only applies to the rows that existed before the column, new resources are created as drafts.
+func (s *schemaGenerator) GenerateStatusColumn() Column {
+	return Column{
+		Name:         "status",
+		Type:         "varchar(20)",
+		IsNotNull:    true,
+		DefaultValue: "'published'",
+	}
+}
+
+func (s *schemaGenerator) GeneratePublishedAtColumn() Column {
+	return Column{
+		Name: "published_at",
+		Type: "timestamptz",
+	}
+}
+
+// GenerateDeletedAtColumn returns the timestamp when a resource was moved to the trash, null for the other resources.
+func (s *schemaGenerator) GenerateDeletedAtColumn() Column {
+	return Column{
+		Name: "deleted_at",
+		Type: "timestamptz",
+	}
+}
+
+// GeneratePublishAtColumn returns the timestamp when a draft is scheduled to be published.
+func (s *schemaGenerator) GeneratePublishAtColumn() Column {
+	return Column{
+		Name: "publish_at",
+		Type: "timestamptz",
+	}
+}
+
+// GenerateUnpublishAtColumn returns the timestamp when a published resource is scheduled to go back to draft.
+func (s *schemaGenerator) GenerateUnpublishAtColumn() Column {
+	return Column{
+		Name: "unpublish_at",
+		Type: "timestamptz",
+	}
+}
+
 func (s *schemaGenerator) GenerateCreatedByColumn() Column {
 	return Column{
 		Name:      "created_by",
@@ -162,6 +206,11 @@ func (s *schemaGenerator) HandleCollection(collection mimsy_schema.Collection) (
 		s.GenerateUpdatedAtColumn(),
 		s.GenerateCreatedByColumn(),
 		s.GenerateUpdatedByColumn(),
+		s.GenerateStatusColumn(),
+		s.GeneratePublishedAtColumn(),
+		s.GenerateDeletedAtColumn(),
+		s.GeneratePublishAtColumn(),
+		s.GenerateUnpublishAtColumn(),
 	)
 
 	baseTable.Constraints = append(baseTable.Constraints,
@@ -174,25 +223,78 @@ func (s *schemaGenerator) HandleCollection(collection mimsy_schema.Collection) (
 	schema := SqlSchema{
 		Tables: []*Table{&baseTable},
 	}
-	for _, entry := range OrderFields(&collection) {
+
+	fieldsSchema, err := s.HandleFields(&baseTable, collection.Schema, "")
+	if err != nil {
+		return SqlSchema{}, err
+	}
+
+	return MergeSchemas(schema, &fieldsSchema), nil
+}
+
+// HandleFields adds the columns of the fields to a table, and returns the tables of their many-to-many relations
+// and blocks. The fields of a block type are stored in columns prefixed by the type, see GetBlockColumnName.
+func (s *schemaGenerator) HandleFields(table *Table, fields mimsy_schema.CollectionFields, blockType string) (SqlSchema, error) {
+	schema := SqlSchema{}
+	for _, entry := range OrderFields(&mimsy_schema.Collection{Schema: fields}) {
 		name, element := entry.Name, entry.Value
 
+		if blockType != "" {
+			if err := checkBlockField(blockType, name, element); err != nil {
+				return SqlSchema{}, err
+			}
+			name = GetBlockColumnName(blockType, name)
+		} else if err := checkReservedField(name, element); err != nil {
+			return SqlSchema{}, err
+		}
+
+		if err := checkIndexConstraints(name, element); err != nil {
+			return SqlSchema{}, err
+		}
+
+		if element.Type == "blocks" {
+			blocksSchema, err := s.HandleBlocksField(name, element, table)
+			if err != nil {
+				return SqlSchema{}, err
+			}
+
+			schema = MergeSchemas(schema, blocksSchema)
+			continue
+		}
+
 		if !element.IsRelation() {
 			// So it is a direct field
 			column, err := s.HandleDirectField(name, element)
 			if err != nil {
 				return SqlSchema{}, err
 			}
-			baseTable.Columns = append(baseTable.Columns, column)
+			// The columns of a block type are empty in the rows of the other types,
+			// so its required fields are only checked when the blocks are written
+			column.IsNotNull = column.IsNotNull && blockType == ""
+			table.Columns = append(table.Columns, column)
+
+			if constraint := s.GenerateSelectConstraint(table.Name, name, element); constraint != nil {
+				table.Constraints = append(table.Constraints, constraint)
+			}
+			s.HandleIndexConstraints(table, name, element)
 
 			continue
 		}
 
 		// Handle a relation field
-		relationSchema, err := s.HandleRelationField(name, element, &baseTable)
+		columns := len(table.Columns)
+		relationSchema, err := s.HandleRelationField(name, element, table)
 		if err != nil {
 			return SqlSchema{}, err
 		}
+		if blockType != "" {
+			for i := columns; i < len(table.Columns); i++ {
+				table.Columns[i].IsNotNull = false
+			}
+		}
+		if element.Type == "relation" {
+			s.HandleIndexConstraints(table, fmt.Sprintf("%s_id", name), element)
+		}
 
 		schema = MergeSchemas(schema, relationSchema)
 	}
@@ -200,7 +302,148 @@ func (s *schemaGenerator) HandleCollection(collection mimsy_schema.Collection) (
 	return schema, nil
 }
 
+// checkReservedField checks that neither the name of a field nor its column is one of the system columns.
+func checkReservedField(name string, element mimsy_schema.SchemaElement) error {
+	column := name
+	if element.Type == "relation" {
+		column = fmt.Sprintf("%s_id", name)
+	}
+
+	for _, reserved := range []string{name, column} {
+		if slices.Contains(ReservedColumns, reserved) {
+			return fmt.Errorf("field %s cannot be declared, %s is a reserved system column", name, reserved)
+		}
+	}
+	return nil
+}
+
+// checkBlockField checks that a field can be declared in a block type.
+// The values of the blocks are not localized on their own, and many-to-many relations would need a join table per block type.
+func checkBlockField(blockType string, name string, element mimsy_schema.SchemaElement) error {
+	if name == BlockTypeColumn {
+		return fmt.Errorf("block %s cannot declare the reserved field %s", blockType, name)
+	}
+	if element.IsLocalized() {
+		return fmt.Errorf("field %s of block %s cannot be localized", name, blockType)
+	}
+	if element.Type == "multi_relation" {
+		return fmt.Errorf("field %s of block %s cannot be a multi_relation", name, blockType)
+	}
+	if element.IsUnique() {
+		return fmt.Errorf("field %s of block %s cannot be unique", name, blockType)
+	}
+	return nil
+}
+
+// checkIndexConstraints checks that the unique and index constraints of a field apply to a column.
+// Localized values are stored in a jsonb object, and many-to-many relations and blocks have no column.
+func checkIndexConstraints(name string, element mimsy_schema.SchemaElement) error {
+	if !element.IsUnique() && !element.IsIndexed() {
+		return nil
+	}
+	if element.IsLocalized() {
+		return fmt.Errorf("localized field %s cannot be unique or indexed", name)
+	}
+	if element.Type == "multi_relation" || element.Type == "blocks" {
+		return fmt.Errorf("%s field %s cannot be unique or indexed", element.Type, name)
+	}
+	return nil
+}
+
+// HandleIndexConstraints adds the unique constraint or the index of a field on its column.
+// Unique constraints are backed by an index, so unique fields get no other index.
+func (s *schemaGenerator) HandleIndexConstraints(table *Table, column string, element mimsy_schema.SchemaElement) {
+	if element.IsUnique() {
+		table.Constraints = append(table.Constraints, &UniqueConstraint{Table: table.Name, Key: column})
+	} else if element.IsIndexed() {
+		table.Indexes = append(table.Indexes, Index{Table: table.Name, Columns: []string{column}})
+	}
+}
+
+// HandleBlocksField returns the table storing the blocks of a field, with a row per block referencing the row
+// owning it. The blocks are ordered by position, and their type is checked against the declared block types.
+func (s *schemaGenerator) HandleBlocksField(name string, element mimsy_schema.SchemaElement, table *Table) (*SqlSchema, error) {
+	if element.IsLocalized() {
+		return nil, fmt.Errorf("blocks field %s cannot be localized", name)
+	}
+
+	blocks := element.GetBlocks()
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("blocks field %s must declare its block types", name)
+	}
+
+	blockTypes := slices.Sorted(maps.Keys(blocks))
+	for _, blockType := range blockTypes {
+		if blockType == "" || strings.Contains(blockType, "__") {
+			return nil, fmt.Errorf("blocks field %s has an invalid block type %q", name, blockType)
+		}
+	}
+
+	parentTable, err := GetPrefixedTableName(table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	blocksTableName := GetBlocksTableName(table.Name, name)
+	blocksTable := Table{
+		Name: blocksTableName,
+		Columns: []Column{
+			s.GenerateIdColumn(),
+			{
+				Name:      BlockParentColumn,
+				Type:      "bigint",
+				IsNotNull: true,
+			},
+			{
+				Name:      BlockPositionColumn,
+				Type:      "integer",
+				IsNotNull: true,
+			},
+			{
+				Name:      BlockTypeColumn,
+				Type:      "varchar",
+				IsNotNull: true,
+			},
+		},
+		Constraints: []Constraint{
+			&PrimaryKeyConstraint{
+				Table: blocksTableName,
+				Key:   "id",
+			},
+			// The blocks are part of their parent, they are deleted with it
+			&ForeignKeyConstraint{
+				Table:           blocksTableName,
+				Column:          BlockParentColumn,
+				ReferenceTable:  parentTable,
+				ReferenceColumn: "id",
+				OnDelete:        "CASCADE",
+			},
+			&CheckConstraint{
+				Table:  blocksTableName,
+				Column: BlockTypeColumn,
+				Values: blockTypes,
+			},
+		},
+	}
+
+	schema := SqlSchema{Tables: []*Table{&blocksTable}}
+	for _, blockType := range blockTypes {
+		blockSchema, err := s.HandleFields(&blocksTable, blocks[blockType], blockType)
+		if err != nil {
+			return nil, err
+		}
+
+		schema = MergeSchemas(schema, &blockSchema)
+	}
+
+	return &schema, nil
+}
+
 func (s *schemaGenerator) HandleDirectField(name string, element mimsy_schema.SchemaElement) (Column, error) {
+	if element.IsLocalized() {
+		return s.HandleLocalizedField(name, element)
+	}
+
 	switch element.Type {
 	case "string":
 		return Column{
@@ -258,12 +501,82 @@ func (s *schemaGenerator) HandleDirectField(name string, element mimsy_schema.Sc
 			IsNotNull:    element.IsRequired(),
 			DefaultValue: "",
 		}, nil
+	case "select":
+		if err := checkSelectValues(name, element); err != nil {
+			return Column{}, err
+		}
+
+		columnType := "varchar"
+		if element.IsMultiple() {
+			columnType = "text[]"
+		}
+		return Column{
+			Name:      name,
+			Type:      columnType,
+			IsNotNull: element.IsRequired(),
+		}, nil
 	default:
 		return Column{}, fmt.Errorf("unsupported type: %s", element.Type)
 	}
 }
 
+// checkSelectValues checks that a select field declares its values, without duplicates.
+func checkSelectValues(name string, element mimsy_schema.SchemaElement) error {
+	values := element.GetValues()
+	if len(values) == 0 {
+		return fmt.Errorf("select field %s must declare its values", name)
+	}
+
+	for i, value := range values {
+		if value == "" {
+			return fmt.Errorf("select field %s cannot have an empty value", name)
+		}
+		if slices.Contains(values[:i], value) {
+			return fmt.Errorf("select field %s has the duplicate value %q", name, value)
+		}
+	}
+
+	return nil
+}
+
+// GenerateSelectConstraint returns the constraint restricting the column of a select field to its values,
+// nil for the other fields. Localized values are stored in a jsonb object and only checked on write.
+func (s *schemaGenerator) GenerateSelectConstraint(tableName string, name string, element mimsy_schema.SchemaElement) Constraint {
+	if element.Type != "select" || element.IsLocalized() {
+		return nil
+	}
+
+	return &CheckConstraint{
+		Table:    tableName,
+		Column:   name,
+		Values:   element.GetValues(),
+		Multiple: element.IsMultiple(),
+	}
+}
+
+// HandleLocalizedField stores the values of a localized field in a jsonb object indexed by locale.
+func (s *schemaGenerator) HandleLocalizedField(name string, element mimsy_schema.SchemaElement) (Column, error) {
+	// Check that the type of the values is supported
+	values := mimsy_schema.SchemaElement{Type: element.Type}
+	if element.Type == "select" {
+		values.Options = &mimsy_schema.SchemaElementOptions{Values: element.GetValues(), Multiple: element.IsMultiple()}
+	}
+	if _, err := s.HandleDirectField(name, values); err != nil {
+		return Column{}, err
+	}
+
+	return Column{
+		Name:      name,
+		Type:      "jsonb",
+		IsNotNull: element.IsRequired(),
+	}, nil
+}
+
 func (s *schemaGenerator) HandleRelationField(name string, element mimsy_schema.SchemaElement, table *Table) (*SqlSchema, error) {
+	if element.IsLocalized() {
+		return nil, fmt.Errorf("relation field %s cannot be localized", name)
+	}
+
 	switch element.Type {
 	case "relation":
 		return s.HandleManyToOneField(name, element, table)
@@ -290,6 +603,11 @@ func (s *schemaGenerator) HandleManyToManyField(name string, element mimsy_schem
 		return nil, err
 	}
 
+	onDelete, err := GetForeignKeyAction(name, &element)
+	if err != nil {
+		return nil, err
+	}
+
 	rowId := fmt.Sprintf("%s_id", table.Name)
 	relatesToId := fmt.Sprintf("%s_id", referenceTableName)
 	relatesToSlug := fmt.Sprintf("%s_slug", referenceTableName)
@@ -324,17 +642,20 @@ func (s *schemaGenerator) HandleManyToManyField(name string, element mimsy_schem
 				Table:   joinTableIdentifier,
 				Columns: []string{rowId, relatesToId},
 			},
+			// The links belong to their owner, they are deleted with it, also when it is deleted by a cascade
 			&ForeignKeyConstraint{
 				Table:           joinTableIdentifier,
 				Column:          rowId,
 				ReferenceTable:  baseTableName,
 				ReferenceColumn: "id",
+				OnDelete:        "CASCADE",
 			},
 			&ForeignKeyConstraint{
 				Table:           joinTableIdentifier,
 				Column:          relatesToId,
 				ReferenceTable:  referenceTable,
 				ReferenceColumn: "id",
+				OnDelete:        onDelete,
 			},
 		},
 	}
@@ -373,11 +694,17 @@ func (s *schemaGenerator) HandleManyToOneField(name string, element mimsy_schema
 		table.Columns = append(table.Columns, slugColumn)
 	}
 
+	onDelete, err := GetForeignKeyAction(name, &element)
+	if err != nil {
+		return nil, err
+	}
+
 	foreignKeyConstraint := &ForeignKeyConstraint{
 		Table:           table.Name,
 		Column:          idColumnName,
 		ReferenceTable:  referenceTable,
 		ReferenceColumn: "id",
+		OnDelete:        onDelete,
 	}
 
 	table.Constraints = append(table.Constraints, foreignKeyConstraint)
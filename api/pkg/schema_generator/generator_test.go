@@ -1,6 +1,8 @@
 package schema_generator_test
 
 import (
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -43,6 +45,11 @@ func TestGeneratorSimple(t *testing.T) {
         	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 		    "name" varchar NOT NULL,
 	        CONSTRAINT pk__test PRIMARY KEY ("id"),
 	        CONSTRAINT uq__test__slug UNIQUE ("slug"),
@@ -55,6 +62,63 @@ func TestGeneratorSimple(t *testing.T) {
 	}
 }
 
+func TestGeneratorLocalized(t *testing.T) {
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{
+				Name: "test",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"name": {
+						Type: "string",
+						Options: &mimsy_schema.SchemaElementOptions{
+							Localized: true,
+						},
+					},
+				},
+			},
+		},
+		GeneratedAt: time.Time{},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff := test_utils.Diff(
+		sqlSchema.ToSql(),
+		`CREATE TABLE "test" (
+	        "id" bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+	        "slug" varchar(60) NOT NULL,
+			"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			"created_by" bigint NOT NULL,
+			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
+		    "name" jsonb,
+	        CONSTRAINT pk__test PRIMARY KEY ("id"),
+	        CONSTRAINT uq__test__slug UNIQUE ("slug"),
+			CONSTRAINT fk__test__created_by__user FOREIGN KEY ("created_by") REFERENCES user ("id"),
+			CONSTRAINT fk__test__updated_by__user FOREIGN KEY ("updated_by") REFERENCES user ("id")
+        );`,
+	)
+	if diff != "" {
+		t.Fatalf("unexpected schema definition (-want +got):\n%s", diff)
+	}
+
+	schema.Collections[0].Schema["author"] = mimsy_schema.SchemaElement{
+		Type:      "relation",
+		RelatesTo: "<builtins.user>",
+		Options:   &mimsy_schema.SchemaElementOptions{Localized: true},
+	}
+	if _, err := schema_generator.New().GenerateSqlSchema(schema); err == nil {
+		t.Fatalf("expected localized relations to be rejected")
+	}
+}
+
 func TestGeneratorOneToMany(t *testing.T) {
 	schema := &mimsy_schema.Schema{
 		Collections: []mimsy_schema.Collection{
@@ -98,6 +162,11 @@ func TestGeneratorOneToMany(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 			"title" varchar NOT NULL,
 
 			"foo_id" bigint NOT NULL,
@@ -115,6 +184,352 @@ func TestGeneratorOneToMany(t *testing.T) {
 	}
 }
 
+func TestGeneratorOnDelete(t *testing.T) {
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{
+				Name: "posts",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"foo":  {Type: "relation", RelatesTo: "foo", OnDelete: mimsy_schema.OnDeleteSetNull},
+					"tags": {Type: "multi_relation", RelatesTo: "tags", OnDelete: mimsy_schema.OnDeleteCascade},
+				},
+			},
+		},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sql := sqlSchema.ToSql()
+	for _, expected := range []string{
+		`CONSTRAINT fk__posts__foo_id__foo__set_null FOREIGN KEY ("foo_id") REFERENCES mimsy_collections."foo" ("id") ON DELETE SET NULL`,
+		`CONSTRAINT fk__posts_tags_relation_tags__tags_id__tags__cascade FOREIGN KEY ("tags_id") REFERENCES mimsy_collections."tags" ("id") ON DELETE CASCADE`,
+		`CONSTRAINT fk__posts_tags_relation_tags__posts_id__posts__cascade FOREIGN KEY ("posts_id") REFERENCES mimsy_collections."posts" ("id") ON DELETE CASCADE`,
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected %q in\n%s", expected, sql)
+		}
+	}
+}
+
+func TestGeneratorOnDeleteCascadesToRelations(t *testing.T) {
+	// Purging an author deletes their books, whose links to their tags must not block the cascade
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{Name: "authors", Schema: map[string]mimsy_schema.SchemaElement{}},
+			{
+				Name: "books",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"author": {Type: "relation", RelatesTo: "authors", OnDelete: mimsy_schema.OnDeleteCascade},
+					"tags":   {Type: "multi_relation", RelatesTo: "tags"},
+				},
+			},
+		},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sql := sqlSchema.ToSql()
+	for _, expected := range []string{
+		`CONSTRAINT fk__books__author_id__authors__cascade FOREIGN KEY ("author_id") REFERENCES mimsy_collections."authors" ("id") ON DELETE CASCADE`,
+		`CONSTRAINT fk__books_tags_relation_tags__books_id__books__cascade FOREIGN KEY ("books_id") REFERENCES mimsy_collections."books" ("id") ON DELETE CASCADE`,
+		`CONSTRAINT fk__books_tags_relation_tags__tags_id__tags FOREIGN KEY ("tags_id") REFERENCES mimsy_collections."tags" ("id")` + "\n",
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected %q in\n%s", expected, sql)
+		}
+	}
+}
+
+func TestGeneratorOnDeleteInvalid(t *testing.T) {
+	for name, element := range map[string]mimsy_schema.SchemaElement{
+		"unknown action": {Type: "relation", RelatesTo: "foo", OnDelete: "nothing"},
+		"required set null": {Type: "relation", RelatesTo: "foo", OnDelete: mimsy_schema.OnDeleteSetNull, Options: &mimsy_schema.SchemaElementOptions{
+			Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+		}},
+		"many-to-many set null": {Type: "multi_relation", RelatesTo: "foo", OnDelete: mimsy_schema.OnDeleteSetNull},
+	} {
+		schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+			{Name: "posts", Schema: map[string]mimsy_schema.SchemaElement{"foo": element}},
+		}}
+
+		if _, err := schema_generator.New().GenerateSqlSchema(schema); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGeneratorSelect(t *testing.T) {
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{
+				Name: "posts",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"category": {Type: "select", Options: &mimsy_schema.SchemaElementOptions{
+						Values:      []string{"news", "it's"},
+						Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+					}},
+					"labels": {Type: "select", Options: &mimsy_schema.SchemaElementOptions{
+						Values:   []string{"new", "featured"},
+						Multiple: true,
+					}},
+					"tone": {Type: "select", Options: &mimsy_schema.SchemaElementOptions{
+						Values:    []string{"formal"},
+						Localized: true,
+					}},
+				},
+			},
+		},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sql := sqlSchema.ToSql()
+	for _, expected := range []string{
+		`"category" varchar NOT NULL,`,
+		`"labels" text[],`,
+		`"tone" jsonb,`,
+		`CHECK ("category" IN ('news', 'it''s'))`,
+		`CHECK ("labels" <@ ARRAY['new', 'featured']::text[])`,
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected %q in\n%s", expected, sql)
+		}
+	}
+	if strings.Contains(sql, `"tone" IN`) {
+		t.Errorf("expected localized selects to have no check constraint in\n%s", sql)
+	}
+
+	// Reordering the values does not replace the constraint
+	reordered := &schema_generator.CheckConstraint{Table: "posts", Column: "labels", Values: []string{"featured", "new"}, Multiple: true}
+	if table, _ := sqlSchema.GetTable("posts"); !slices.ContainsFunc(table.Constraints, func(c schema_generator.Constraint) bool {
+		return c.Name() == reordered.Name()
+	}) {
+		t.Errorf("expected the constraint name to ignore the order of the values")
+	}
+}
+
+func TestGeneratorSelectInvalid(t *testing.T) {
+	for name, options := range map[string]*mimsy_schema.SchemaElementOptions{
+		"no values":          nil,
+		"empty value":        {Values: []string{"a", ""}},
+		"duplicate value":    {Values: []string{"a", "b", "a"}},
+		"localized no value": {Localized: true},
+	} {
+		schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+			{Name: "posts", Schema: map[string]mimsy_schema.SchemaElement{"category": {Type: "select", Options: options}}},
+		}}
+
+		if _, err := schema_generator.New().GenerateSqlSchema(schema); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGeneratorBlocks(t *testing.T) {
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{
+				Name: "pages",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"title": {Type: "string"},
+					"content": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+						Blocks: map[string]mimsy_schema.CollectionFields{
+							"hero": {
+								"heading": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{
+									Constraints: &mimsy_schema.SchemaElementConstraints{Required: true},
+								}},
+								"image": {Type: "relation", RelatesTo: "media"},
+							},
+							"columns": {
+								"items": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+									Blocks: map[string]mimsy_schema.CollectionFields{
+										"text": {"body": {Type: "rich_text"}},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pages, ok := sqlSchema.GetTable("pages")
+	if !ok {
+		t.Fatalf("expected the pages table")
+	}
+	if slices.ContainsFunc(pages.Columns, func(c schema_generator.Column) bool { return c.Name == "content" }) {
+		t.Errorf("expected the blocks to have no column in the parent table")
+	}
+
+	sql := sqlSchema.ToSql()
+	for _, expected := range []string{
+		`CREATE TABLE "pages_content_blocks"`,
+		`"parent_id" bigint NOT NULL,`,
+		`"position" integer NOT NULL,`,
+		`"block_type" varchar NOT NULL,`,
+		`"hero__heading" varchar,`,
+		`"hero__image_id" bigint,`,
+		`REFERENCES mimsy_collections."pages" ("id") ON DELETE CASCADE`,
+		`CHECK ("block_type" IN ('columns', 'hero'))`,
+		`CREATE TABLE "pages_content_blocks_columns__items_blocks"`,
+		`"text__body" jsonb,`,
+		`REFERENCES mimsy_collections."pages_content_blocks" ("id") ON DELETE CASCADE`,
+		`CHECK ("block_type" IN ('text'))`,
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected %q in\n%s", expected, sql)
+		}
+	}
+}
+
+func TestGeneratorBlocksInvalid(t *testing.T) {
+	for name, element := range map[string]mimsy_schema.SchemaElement{
+		"no blocks": {Type: "blocks"},
+		"localized": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Localized: true,
+			Blocks:    map[string]mimsy_schema.CollectionFields{"hero": {}},
+		}},
+		"invalid block type": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{"hero__main": {}},
+		}},
+		"block_type field": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{"hero": {"block_type": {Type: "string"}}},
+		}},
+		"localized field": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{"hero": {"heading": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{Localized: true}}}},
+		}},
+		"many-to-many field": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{"hero": {"tags": {Type: "multi_relation", RelatesTo: "tags"}}},
+		}},
+	} {
+		schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+			{Name: "pages", Schema: map[string]mimsy_schema.SchemaElement{"content": element}},
+		}}
+
+		if _, err := schema_generator.New().GenerateSqlSchema(schema); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGeneratorReservedFields(t *testing.T) {
+	for name, element := range map[string]mimsy_schema.SchemaElement{
+		"status":       {Type: "select", Options: &mimsy_schema.SchemaElementOptions{Values: []string{"open", "closed"}}},
+		"slug":         {Type: "string"},
+		"published_at": {Type: "date_time"},
+		"deleted_at":   {Type: "date_time"},
+		"publish_at":   {Type: "date_time"},
+		"unpublish_at": {Type: "date_time"},
+		"id":           {Type: "multi_relation", RelatesTo: "tags"},
+		"created_by":   {Type: "relation", RelatesTo: "<builtins.user>"},
+	} {
+		schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+			{Name: "posts", Schema: map[string]mimsy_schema.SchemaElement{name: element}},
+		}}
+
+		_, err := schema_generator.New().GenerateSqlSchema(schema)
+		if err == nil || !strings.Contains(err.Error(), "reserved system column") {
+			t.Errorf("%s: expected a reserved column error, got %v", name, err)
+		}
+	}
+
+	// The system columns can still be used by the fields of a block type, as their columns are prefixed
+	schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+		{Name: "pages", Schema: map[string]mimsy_schema.SchemaElement{
+			"content": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+				Blocks: map[string]mimsy_schema.CollectionFields{"hero": {"status": {Type: "string"}}},
+			}},
+		}},
+	}}
+	if _, err := schema_generator.New().GenerateSqlSchema(schema); err != nil {
+		t.Errorf("unexpected error for a block field: %v", err)
+	}
+}
+
+func TestGeneratorUniqueAndIndex(t *testing.T) {
+	constraints := func(c mimsy_schema.SchemaElementConstraints) *mimsy_schema.SchemaElementOptions {
+		return &mimsy_schema.SchemaElementOptions{Constraints: &c}
+	}
+
+	schema := &mimsy_schema.Schema{
+		Collections: []mimsy_schema.Collection{
+			{
+				Name: "subscribers",
+				Schema: map[string]mimsy_schema.SchemaElement{
+					"email":    {Type: "email", Options: constraints(mimsy_schema.SchemaElementConstraints{Required: true, Unique: true})},
+					"code":     {Type: "string", Options: constraints(mimsy_schema.SchemaElementConstraints{Unique: true, Index: true})},
+					"country":  {Type: "string", Options: constraints(mimsy_schema.SchemaElementConstraints{Index: true})},
+					"referrer": {Type: "relation", RelatesTo: "subscribers", Options: constraints(mimsy_schema.SchemaElementConstraints{Index: true})},
+				},
+			},
+		},
+	}
+
+	sqlSchema, err := schema_generator.New().GenerateSqlSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sql := sqlSchema.ToSql()
+	for _, expected := range []string{
+		`CONSTRAINT uq__subscribers__email UNIQUE ("email")`,
+		`CONSTRAINT uq__subscribers__code UNIQUE ("code")`,
+		`CREATE INDEX ix__subscribers__country ON "subscribers" ("country");`,
+		`CREATE INDEX ix__subscribers__referrer_id ON "subscribers" ("referrer_id");`,
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("expected %q in\n%s", expected, sql)
+		}
+	}
+
+	// Unique constraints are already backed by an index
+	if strings.Contains(sql, "ix__subscribers__code") {
+		t.Errorf("expected no index on the unique field in\n%s", sql)
+	}
+}
+
+func TestGeneratorUniqueAndIndexInvalid(t *testing.T) {
+	unique := &mimsy_schema.SchemaElementConstraints{Unique: true}
+
+	for name, element := range map[string]mimsy_schema.SchemaElement{
+		"localized": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{Localized: true, Constraints: unique}},
+		"many-to-many": {Type: "multi_relation", RelatesTo: "tags", Options: &mimsy_schema.SchemaElementOptions{
+			Constraints: &mimsy_schema.SchemaElementConstraints{Index: true},
+		}},
+		"blocks": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Constraints: unique,
+			Blocks:      map[string]mimsy_schema.CollectionFields{"hero": {}},
+		}},
+		"unique block field": {Type: "blocks", Options: &mimsy_schema.SchemaElementOptions{
+			Blocks: map[string]mimsy_schema.CollectionFields{"hero": {"heading": {Type: "string", Options: &mimsy_schema.SchemaElementOptions{Constraints: unique}}}},
+		}},
+	} {
+		schema := &mimsy_schema.Schema{Collections: []mimsy_schema.Collection{
+			{Name: "posts", Schema: map[string]mimsy_schema.SchemaElement{"field": element}},
+			{Name: "tags", Schema: map[string]mimsy_schema.SchemaElement{}},
+		}}
+
+		if _, err := schema_generator.New().GenerateSqlSchema(schema); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
 func TestGeneratorOneToManyBuiltin(t *testing.T) {
 	schema := &mimsy_schema.Schema{
 		Collections: []mimsy_schema.Collection{
@@ -158,6 +573,11 @@ func TestGeneratorOneToManyBuiltin(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 			"title" varchar NOT NULL,
 
 			"author_id" bigint NOT NULL,
@@ -212,6 +632,11 @@ func TestGeneratorManyToMany(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 	        "title" varchar NOT NULL,
 	        CONSTRAINT pk__posts PRIMARY KEY ("id"),
 	        CONSTRAINT uq__posts__slug UNIQUE ("slug"),
@@ -223,7 +648,7 @@ func TestGeneratorManyToMany(t *testing.T) {
         	        "tags_id" bigint NOT NULL,
         	        "tags_slug" varchar NOT NULL GENERATED ALWAYS AS (SELECT slug FROM mimsy_collections."tags" WHERE id = "tags_id") STORED,
         	        CONSTRAINT pk__posts_tags_relation_tags PRIMARY KEY ("posts_id", "tags_id"),
-        	        CONSTRAINT fk__posts_tags_relation_tags__posts_id__posts FOREIGN KEY ("posts_id") REFERENCES mimsy_collections."posts" ("id"),
+        	        CONSTRAINT fk__posts_tags_relation_tags__posts_id__posts__cascade FOREIGN KEY ("posts_id") REFERENCES mimsy_collections."posts" ("id") ON DELETE CASCADE,
         	        CONSTRAINT fk__posts_tags_relation_tags__tags_id__tags FOREIGN KEY ("tags_id") REFERENCES mimsy_collections."tags" ("id")
         );`,
 	)
@@ -270,6 +695,11 @@ func TestGeneratorManyToManyBuiltins(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 	        "title" varchar NOT NULL,
 	        CONSTRAINT pk__posts PRIMARY KEY ("id"),
 	        CONSTRAINT uq__posts__slug UNIQUE ("slug"),
@@ -281,7 +711,7 @@ func TestGeneratorManyToManyBuiltins(t *testing.T) {
         	        "media_id" bigint NOT NULL,
         	        "media_slug" varchar NOT NULL GENERATED ALWAYS AS (SELECT slug FROM mimsy_internal."media" WHERE id = "media_id") STORED,
         	        CONSTRAINT pk__posts_medias_relation_media PRIMARY KEY ("posts_id", "media_id"),
-        	        CONSTRAINT fk__posts_medias_relation_media__posts_id__posts FOREIGN KEY ("posts_id") REFERENCES mimsy_collections."posts" ("id"),
+        	        CONSTRAINT fk__posts_medias_relation_media__posts_id__posts__cascade FOREIGN KEY ("posts_id") REFERENCES mimsy_collections."posts" ("id") ON DELETE CASCADE,
         	        CONSTRAINT fk__posts_medias_relation_media__media_id__media FOREIGN KEY ("media_id") REFERENCES mimsy_internal."media" ("id")
         );`,
 	)
@@ -361,6 +791,11 @@ func TestGeneratorExample(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 		    "content" jsonb NOT NULL,
 		    "created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		    "title" varchar NOT NULL,
@@ -376,7 +811,7 @@ func TestGeneratorExample(t *testing.T) {
 		    "tag_id" bigint NOT NULL,
 		    "tag_slug" varchar NOT NULL GENERATED ALWAYS AS (SELECT slug FROM mimsy_collections."tag" WHERE id = "tag_id") STORED,
 		    CONSTRAINT pk__post_tags_relation_tag PRIMARY KEY ("post_id", "tag_id"),
-		    CONSTRAINT fk__post_tags_relation_tag__post_id__post FOREIGN KEY ("post_id") REFERENCES mimsy_collections."post" ("id"),
+		    CONSTRAINT fk__post_tags_relation_tag__post_id__post__cascade FOREIGN KEY ("post_id") REFERENCES mimsy_collections."post" ("id") ON DELETE CASCADE,
 		    CONSTRAINT fk__post_tags_relation_tag__tag_id__tag FOREIGN KEY ("tag_id") REFERENCES mimsy_collections."tag" ("id")
 		);
 		CREATE TABLE "tag" (
@@ -386,6 +821,11 @@ func TestGeneratorExample(t *testing.T) {
 			"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			"created_by" bigint NOT NULL,
 			"updated_by" bigint NOT NULL,
+			"status" varchar(20) NOT NULL DEFAULT 'published',
+			"published_at" timestamptz,
+			"deleted_at" timestamptz,
+			"publish_at" timestamptz,
+			"unpublish_at" timestamptz,
 		    "name" varchar NOT NULL,
 		    CONSTRAINT pk__tag PRIMARY KEY ("id"),
 		    CONSTRAINT uq__tag__slug UNIQUE ("slug"),
@@ -443,7 +883,7 @@ func TestGeneratorStableColumnOrdering(t *testing.T) {
 						Type:      "multi_relation",
 						RelatesTo: "tags",
 					},
-					"status": {
+					"state": {
 						Type: "string",
 					},
 					"metadata": {
@@ -492,12 +932,17 @@ func TestGeneratorStableColumnOrdering(t *testing.T) {
 	"updated_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	"created_by" bigint NOT NULL,
 	"updated_by" bigint NOT NULL,
+	"status" varchar(20) NOT NULL DEFAULT 'published',
+	"published_at" timestamptz,
+	"deleted_at" timestamptz,
+	"publish_at" timestamptz,
+	"unpublish_at" timestamptz,
 	"created_at" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	"description" jsonb,
 	"excerpt" varchar,
 	"metadata" jsonb,
 	"name" varchar NOT NULL,
-	"status" varchar,
+	"state" varchar,
 	"subtitle" varchar NOT NULL,
 	"summary" varchar,
 	"category_id" bigint,
@@ -513,7 +958,7 @@ CREATE TABLE "products_tags_relation_tags" (
 	"tags_id" bigint NOT NULL,
 	"tags_slug" varchar NOT NULL GENERATED ALWAYS AS (SELECT slug FROM mimsy_collections."tags" WHERE id = "tags_id") STORED,
 	CONSTRAINT pk__products_tags_relation_tags PRIMARY KEY ("products_id", "tags_id"),
-	CONSTRAINT fk__products_tags_relation_tags__products_id__products FOREIGN KEY ("products_id") REFERENCES mimsy_collections."products" ("id"),
+	CONSTRAINT fk__products_tags_relation_tags__products_id__products__cascade FOREIGN KEY ("products_id") REFERENCES mimsy_collections."products" ("id") ON DELETE CASCADE,
 	CONSTRAINT fk__products_tags_relation_tags__tags_id__tags FOREIGN KEY ("tags_id") REFERENCES mimsy_collections."tags" ("id")
 );`
 
@@ -28,6 +28,8 @@ func (t *Table) MarshalJSON() ([]byte, error) {
 			constraintType = "composite_primary_key"
 		case *ForeignKeyConstraint:
 			constraintType = "foreign_key"
+		case *CheckConstraint:
+			constraintType = "check"
 		default:
 			return nil, fmt.Errorf("unknown constraint type: %T", c)
 		}
@@ -94,6 +96,12 @@ func (t *Table) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			t.Constraints[i] = &c
+		case "check":
+			var c CheckConstraint
+			if err := json.Unmarshal(wrapper.Constraint, &c); err != nil {
+				return err
+			}
+			t.Constraints[i] = &c
 		default:
 			return fmt.Errorf("unknown constraint type: %s", wrapper.Type)
 		}
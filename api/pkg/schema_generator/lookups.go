@@ -9,6 +9,23 @@ import (
 	"github.com/mimsy-cms/mimsy/pkg/mimsy_schema"
 )
 
+// The columns of the tables storing the blocks of a field, along with the columns of the fields of each block type.
+const (
+	// BlockParentColumn references the row owning the block, a resource or a parent block.
+	BlockParentColumn = "parent_id"
+	// BlockPositionColumn is the index of the block in the list of its parent.
+	BlockPositionColumn = "position"
+	// BlockTypeColumn is the type of the block, it is also the key of the type in the blocks read and written.
+	BlockTypeColumn = "block_type"
+)
+
+// ReservedColumns are the system columns added to every collection table, no field can be named after them.
+// `created_at` is left out, as schemas may still declare it as a field of the `created_at` type.
+var ReservedColumns = []string{
+	"id", "slug", "updated_at", "created_by", "updated_by",
+	"status", "published_at", "deleted_at", "publish_at", "unpublish_at",
+}
+
 type DatabaseCollection struct {
 	collection *mimsy_schema.Collection
 }
@@ -79,3 +96,14 @@ func GetRelationTableName(c *mimsy_schema.SchemaElement, collectionTableName str
 
 	return fmt.Sprintf("%s_%s_relation_%s", collectionTableName, fieldName, relatesTo), nil
 }
+
+// GetBlocksTableName returns the table storing the blocks of a field, `<table>_<field>_blocks`.
+func GetBlocksTableName(tableName string, fieldName string) string {
+	return fmt.Sprintf("%s_%s_blocks", tableName, fieldName)
+}
+
+// GetBlockColumnName returns the column storing a field of a block type, the fields of each type are
+// prefixed by it so that block types can declare fields of the same name.
+func GetBlockColumnName(blockType string, fieldName string) string {
+	return fmt.Sprintf("%s__%s", blockType, fieldName)
+}
@@ -253,6 +253,12 @@ func TestSqlSchema_MarshalUnmarshal_ProducesSameSQL(t *testing.T) {
 						ReferenceColumn: "id",
 					},
 				},
+				Indexes: []Index{
+					{
+						Table:   "posts",
+						Columns: []string{"title"},
+					},
+				},
 			},
 		},
 	}
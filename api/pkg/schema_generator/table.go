@@ -2,6 +2,7 @@ package schema_generator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/lib/pq"
 )
@@ -37,10 +38,30 @@ func (c *Column) ToSql() string {
 	return sql
 }
 
+// Index is a non-unique index on columns of a table, unique indexes are declared as a UniqueConstraint.
+type Index struct {
+	Table   string
+	Columns []string
+}
+
+func (i *Index) Name() string {
+	return fmt.Sprintf("ix__%s__%s", i.Table, strings.Join(i.Columns, "__"))
+}
+
+func (i *Index) ToSql() string {
+	quotedColumns := make([]string, len(i.Columns))
+	for j, column := range i.Columns {
+		quotedColumns[j] = pq.QuoteIdentifier(column)
+	}
+
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", i.Name(), pq.QuoteIdentifier(i.Table), strings.Join(quotedColumns, ", "))
+}
+
 type Table struct {
 	Name        string
 	Columns     []Column
 	Constraints []Constraint
+	Indexes     []Index `json:",omitempty"`
 }
 
 func (t *Table) ToSql() string {
@@ -64,9 +85,23 @@ func (t *Table) ToSql() string {
 
 	sql += "\n);"
 
+	for _, index := range t.Indexes {
+		sql += "\n" + index.ToSql()
+	}
+
 	return sql
 }
 
+func (t *Table) GetIndex(indexName string) (*Index, bool) {
+	for _, index := range t.Indexes {
+		if index.Name() == indexName {
+			return &index, true
+		}
+	}
+
+	return nil, false
+}
+
 func (t *Table) GetColumn(columnName string) (*Column, bool) {
 	for _, column := range t.Columns {
 		if column.Name == columnName {
@@ -102,3 +102,32 @@ func TestBasicTable(t *testing.T) {
 		t.Fatalf("unexpected table definition (-want +got):\n%s", diff)
 	}
 }
+
+func TestTableWithIndex(t *testing.T) {
+	table := schema_generator.Table{
+		Name: "users",
+		Columns: []schema_generator.Column{
+			{
+				Name: "name",
+				Type: "varchar(255)",
+			},
+		},
+		Indexes: []schema_generator.Index{
+			{
+				Table:   "users",
+				Columns: []string{"name"},
+			},
+		},
+	}
+
+	diff := test_utils.Diff(
+		table.ToSql(),
+		`CREATE TABLE "users" (
+			"name" varchar(255)
+		);
+		CREATE INDEX ix__users__name ON "users" ("name");`,
+	)
+	if diff != "" {
+		t.Fatalf("unexpected table definition (-want +got):\n%s", diff)
+	}
+}
@@ -82,5 +82,7 @@ export type CollectionResource = {
 	created_by: number;
 	updated_at: string;
 	updated_by: number;
+	status?: 'draft' | 'published';
+	published_at?: string | null;
 	[key: string]: string | number | boolean | Date | undefined | null;
 };
@@ -17,7 +17,7 @@ export const load: PageServerLoad = async ({ params, fetch }) => {
 	}
 	const collectionDef = await defRes.json();
 
-	const response = await fetch(`${env.PUBLIC_API_URL}/v1/collections/${collectionSlug}`);
+	const response = await fetch(`${env.PUBLIC_API_URL}/v1/collections/${collectionSlug}?draft=true`);
 	const resources = (await response.json()) as Resource[];
 
 	return {
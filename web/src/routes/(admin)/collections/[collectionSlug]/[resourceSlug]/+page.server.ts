@@ -7,7 +7,8 @@ import { superValidate, message } from 'sveltekit-superforms';
 import { getFlash } from 'sveltekit-flash-message';
 import { zod } from 'sveltekit-superforms/adapters';
 import type { Actions } from './$types';
-import { fail } from '@sveltejs/kit';
+import { fail, type Cookies } from '@sveltejs/kit';
+import { redirect } from 'sveltekit-flash-message/server';
 
 async function fetchCollectionDefinition(
 	collectionSlug: string,
@@ -23,7 +24,7 @@ async function fetchResource(
 	fetch: typeof globalThis.fetch
 ): Promise<CollectionResource> {
 	const response = await fetch(
-		`${env.PUBLIC_API_URL}/v1/collections/${collectionSlug}/${resourceSlug}`
+		`${env.PUBLIC_API_URL}/v1/collections/${collectionSlug}/${resourceSlug}?draft=true`
 	);
 	return response.json();
 }
@@ -60,8 +61,32 @@ export const load: PageServerLoad = async ({ params, fetch, cookies }) => {
 	};
 };
 
+async function setStatus(
+	action: 'publish' | 'unpublish',
+	params: { collectionSlug: string; resourceSlug: string },
+	fetch: typeof globalThis.fetch,
+	cookies: Cookies
+) {
+	const response = await fetch(
+		`${env.PUBLIC_API_URL}/v1/collections/${params.collectionSlug}/${params.resourceSlug}/${action}`,
+		{ method: 'POST' }
+	);
+
+	const location = `/collections/${params.collectionSlug}/${params.resourceSlug}`;
+	if (!response.ok) {
+		const errorText = await response.text();
+		console.error('API Error:', response.status, errorText);
+		redirect(location, { type: 'error', message: `Failed to ${action} resource: ${errorText}` }, cookies);
+	}
+
+	const message = action === 'publish' ? 'Resource published successfully' : 'Resource unpublished successfully';
+	redirect(location, { type: 'success', message }, cookies);
+}
+
 export const actions: Actions = {
-	default: async ({ request, params, fetch, cookies }) => {
+	publish: async ({ params, fetch, cookies }) => setStatus('publish', params, fetch, cookies),
+	unpublish: async ({ params, fetch, cookies }) => setStatus('unpublish', params, fetch, cookies),
+	update: async ({ request, params, fetch, cookies }) => {
 		const form = await superValidate(request, zod(updateSchema));
 		if (!form.valid) {
 			return message(form, 'Invalid form data', { status: 400 });
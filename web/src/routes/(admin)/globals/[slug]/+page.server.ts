@@ -17,7 +17,7 @@ async function fetchResource(
 	collectionSlug: string,
 	fetch: typeof globalThis.fetch
 ): Promise<CollectionResource> {
-	const response = await fetch(`${env.PUBLIC_API_URL}/v1/globals/${collectionSlug}`);
+	const response = await fetch(`${env.PUBLIC_API_URL}/v1/globals/${collectionSlug}?draft=true`);
 	return response.json();
 }
 